@@ -44,6 +44,7 @@ func Execute() error {
 func init() {
 	rootCmd.AddCommand(runCmd)
 	rootCmd.AddCommand(utilsBootstrapCmd)
+	rootCmd.AddCommand(utilsExportMetadataCmd)
 }
 
 // handleSignals handles OS signals so we can ensure we close database
@@ -93,7 +93,7 @@ func runRunCmd(cmd *cobra.Command, args []string) error {
 		}
 
 		var err error
-		client, err = wemix.NewClient(cfg.GwemixURL, cfg.Params, cfg.SkipGwemixAdmin)
+		client, err = wemix.NewClient(cfg.GwemixURL, cfg.Params, cfg.SkipGwemixAdmin, cfg.Tokens, cfg.Nfts)
 		if err != nil {
 			return fmt.Errorf("%w: cannot initialize wemix client", err)
 		}
@@ -0,0 +1,97 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+	"github.com/wemixarchive/rosetta-wemix/services"
+	"github.com/wemixarchive/rosetta-wemix/wemix"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	utilsExportMetadataCmd = &cobra.Command{
+		Use:   "utils:export-metadata",
+		Short: "Export a metadata bundle for offline construction",
+		Long: `Offline instances cannot fetch the nonce and gas price
+needed by /construction/metadata. This command fetches them
+from gwemix for a list of accounts and writes them to a
+checksummed metadata bundle, which an offline instance loads
+from METADATA_BUNDLE.
+
+The command uses the same environment variables as run and
+must be started in ONLINE mode with gwemix reachable.
+
+When calling this command, you must provide at least 2 arguments:
+[1] the location of where to write the metadata bundle file
+[2...] the accounts to include in the bundle`,
+		RunE: runUtilsExportMetadataCmd,
+		Args: cobra.MinimumNArgs(2), //nolint:gomnd
+	}
+
+	exportGasLimit uint64
+)
+
+func init() {
+	utilsExportMetadataCmd.Flags().Uint64Var(
+		&exportGasLimit,
+		"gas-limit",
+		0,
+		"gas limit of offline transactions carrying call data (unsupported when 0)",
+	)
+}
+
+func runUtilsExportMetadataCmd(cmd *cobra.Command, args []string) error {
+	cfg, err := configuration.LoadConfiguration()
+	if err != nil {
+		return fmt.Errorf("%w: unable to load configuration", err)
+	}
+	if cfg.Mode != configuration.Online {
+		return errors.New("metadata can only be exported in ONLINE mode")
+	}
+
+	var accounts []common.Address
+	for _, account := range args[1:] {
+		if !common.IsHexAddress(account) {
+			return fmt.Errorf("%s is not a valid address", account)
+		}
+		accounts = append(accounts, common.HexToAddress(account))
+	}
+
+	client, err := wemix.NewClient(cfg.GwemixURL, cfg.Params, cfg.SkipGwemixAdmin, cfg.Tokens, cfg.Nfts)
+	if err != nil {
+		return fmt.Errorf("%w: cannot initialize wemix client", err)
+	}
+	defer client.Close()
+
+	bundle, err := services.NewMetadataBundle(context.Background(), client, cfg, accounts, exportGasLimit)
+	if err != nil {
+		return fmt.Errorf("%w: unable to export metadata", err)
+	}
+
+	if err := bundle.WriteFile(args[0]); err != nil {
+		return fmt.Errorf("%w: unable to write metadata bundle", err)
+	}
+
+	log.Printf("exported metadata of %d accounts to %s", len(accounts), args[0])
+	return nil
+}
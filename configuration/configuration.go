@@ -15,10 +15,13 @@
 package configuration
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/ethereum/go-ethereum/params"
@@ -76,6 +79,73 @@ const (
 	// by hosted node services. When not set, defaults to false.
 	SkipGwemixAdminEnv = "SKIP_GWEMIX_ADMIN"
 
+	// TokenListEnv is an optional environment variable
+	// pointing to a JSON file of ERC-20 token currencies
+	// that rosetta-wemix should support. Each currency must
+	// hold its contract address in metadata.
+	TokenListEnv = "TOKEN_LIST"
+
+	// NftListEnv is an optional environment variable
+	// pointing to a JSON file of the non-fungible token
+	// contracts whose transfers rosetta-wemix should
+	// represent. Each currency must hold the contract
+	// address and standard in metadata.
+	NftListEnv = "NFT_LIST"
+
+	// GasLimitMultiplierEnv is an optional environment variable
+	// used to scale the gas limit estimated for constructed
+	// transactions. When not set, defaults to
+	// DefaultGasLimitMultiplier.
+	GasLimitMultiplierEnv = "GAS_LIMIT_MULTIPLIER"
+
+	// DefaultGasLimitMultiplier is the default safety margin
+	// applied to eth_estimateGas results.
+	DefaultGasLimitMultiplier = 1.2
+
+	// NonceManagerEnv is an optional environment variable
+	// to allocate nonces locally, so that concurrent
+	// constructions from one sender do not collide. When
+	// not set, defaults to false.
+	NonceManagerEnv = "NONCE_MANAGER"
+
+	// NonceReservationTimeoutEnv is an optional environment
+	// variable setting how long a nonce allocated by the
+	// nonce manager stays reserved. When not set, defaults
+	// to DefaultNonceReservationTimeout.
+	NonceReservationTimeoutEnv = "NONCE_RESERVATION_TIMEOUT"
+
+	// DefaultNonceReservationTimeout is the default
+	// lifetime of a nonce reservation.
+	DefaultNonceReservationTimeout = 5 * time.Minute
+
+	// SubmitPreflightEnv is an optional environment variable
+	// to validate and simulate signed transactions before
+	// broadcasting them in /construction/submit. When not
+	// set, defaults to false.
+	SubmitPreflightEnv = "SUBMIT_PREFLIGHT"
+
+	// RawSignedTxEnv is an optional environment variable
+	// to emit signed transactions from /construction/combine
+	// as 0x-prefixed raw transaction bytes instead of JSON.
+	// When not set, defaults to false.
+	RawSignedTxEnv = "RAW_SIGNED_TX"
+
+	// MetadataBundleEnv is an optional environment variable
+	// pointing to a metadata bundle exported by an online
+	// instance. Offline instances use it to answer
+	// /construction/metadata for the accounts it lists.
+	MetadataBundleEnv = "METADATA_BUNDLE"
+
+	// MetadataBundleMaxAgeEnv is an optional environment
+	// variable setting how old a metadata bundle may be
+	// before /construction/metadata stops using it. When
+	// not set, defaults to DefaultMetadataBundleMaxAge.
+	MetadataBundleMaxAgeEnv = "METADATA_BUNDLE_MAX_AGE"
+
+	// DefaultMetadataBundleMaxAge is the default
+	// maximum age of a metadata bundle.
+	DefaultMetadataBundleMaxAge = time.Hour
+
 	// MiddlewareVersion is the version of rosetta-wemix.
 	MiddlewareVersion = "0.0.4"
 )
@@ -90,6 +160,20 @@ type Configuration struct {
 	Port                   int
 	GwemixArguments        string
 	SkipGwemixAdmin        bool
+	Tokens                 []*types.Currency
+	Nfts                   []*types.Currency
+	GasLimitMultiplier     float64
+
+	// Nonce Allocation
+	NonceManager            bool
+	NonceReservationTimeout time.Duration
+
+	SubmitPreflight bool
+	RawSignedTx     bool
+
+	// Offline Metadata
+	MetadataBundle       *MetadataBundle
+	MetadataBundleMaxAge time.Duration
 
 	// Block Reward Data
 	Params *params.ChainConfig
@@ -153,6 +237,115 @@ func LoadConfiguration() (*Configuration, error) {
 		config.SkipGwemixAdmin = val
 	}
 
+	envTokenList := os.Getenv(TokenListEnv)
+	if len(envTokenList) > 0 {
+		tokens, err := loadTokens(envTokenList)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load TOKEN_LIST %s", err, envTokenList)
+		}
+		config.Tokens = tokens
+	}
+
+	envNftList := os.Getenv(NftListEnv)
+	if len(envNftList) > 0 {
+		nfts, err := loadNfts(envNftList)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load NFT_LIST %s", err, envNftList)
+		}
+		config.Nfts = nfts
+	}
+
+	envMetadataBundle := os.Getenv(MetadataBundleEnv)
+	if len(envMetadataBundle) > 0 {
+		bundle, err := LoadMetadataBundle(envMetadataBundle)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to load METADATA_BUNDLE %s", err, envMetadataBundle)
+		}
+		if types.Hash(bundle.Network) != types.Hash(config.Network) {
+			return nil, fmt.Errorf("METADATA_BUNDLE %s is for network %s", envMetadataBundle, bundle.Network.Network)
+		}
+		if bundle.ChainID.ToInt().Cmp(config.Params.ChainID) != 0 {
+			return nil, fmt.Errorf("METADATA_BUNDLE %s is for chain ID %s", envMetadataBundle, bundle.ChainID.ToInt())
+		}
+		config.MetadataBundle = bundle
+
+		config.MetadataBundleMaxAge = DefaultMetadataBundleMaxAge
+		envMaxAge := os.Getenv(MetadataBundleMaxAgeEnv)
+		if len(envMaxAge) > 0 {
+			val, err := time.ParseDuration(envMaxAge)
+			if err != nil {
+				return nil, fmt.Errorf("%w: unable to parse METADATA_BUNDLE_MAX_AGE %s", err, envMaxAge)
+			}
+			if val <= 0 {
+				return nil, fmt.Errorf("METADATA_BUNDLE_MAX_AGE %s must be positive", envMaxAge)
+			}
+			config.MetadataBundleMaxAge = val
+		}
+	}
+
+	config.GasLimitMultiplier = DefaultGasLimitMultiplier
+	envGasLimitMultiplier := os.Getenv(GasLimitMultiplierEnv)
+	if len(envGasLimitMultiplier) > 0 {
+		val, err := strconv.ParseFloat(envGasLimitMultiplier, 64)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"%w: unable to parse GAS_LIMIT_MULTIPLIER %s",
+				err,
+				envGasLimitMultiplier,
+			)
+		}
+		if val < 1 {
+			return nil, fmt.Errorf("GAS_LIMIT_MULTIPLIER %s must be at least 1", envGasLimitMultiplier)
+		}
+		config.GasLimitMultiplier = val
+	}
+
+	envNonceManager := os.Getenv(NonceManagerEnv)
+	if len(envNonceManager) > 0 {
+		val, err := strconv.ParseBool(envNonceManager)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to parse NONCE_MANAGER %s", err, envNonceManager)
+		}
+		config.NonceManager = val
+	}
+
+	if config.NonceManager {
+		config.NonceReservationTimeout = DefaultNonceReservationTimeout
+		envNonceTimeout := os.Getenv(NonceReservationTimeoutEnv)
+		if len(envNonceTimeout) > 0 {
+			val, err := time.ParseDuration(envNonceTimeout)
+			if err != nil {
+				return nil, fmt.Errorf(
+					"%w: unable to parse NONCE_RESERVATION_TIMEOUT %s",
+					err,
+					envNonceTimeout,
+				)
+			}
+			if val <= 0 {
+				return nil, fmt.Errorf("NONCE_RESERVATION_TIMEOUT %s must be positive", envNonceTimeout)
+			}
+			config.NonceReservationTimeout = val
+		}
+	}
+
+	envSubmitPreflight := os.Getenv(SubmitPreflightEnv)
+	if len(envSubmitPreflight) > 0 {
+		val, err := strconv.ParseBool(envSubmitPreflight)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to parse SUBMIT_PREFLIGHT %s", err, envSubmitPreflight)
+		}
+		config.SubmitPreflight = val
+	}
+
+	envRawSignedTx := os.Getenv(RawSignedTxEnv)
+	if len(envRawSignedTx) > 0 {
+		val, err := strconv.ParseBool(envRawSignedTx)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to parse RAW_SIGNED_TX %s", err, envRawSignedTx)
+		}
+		config.RawSignedTx = val
+	}
+
 	portValue := os.Getenv(PortEnv)
 	if len(portValue) == 0 {
 		return nil, errors.New("PORT must be populated")
@@ -166,3 +359,71 @@ func LoadConfiguration() (*Configuration, error) {
 
 	return config, nil
 }
+
+// Token returns the configured token *types.Currency
+// deployed at address. If no token is configured at
+// address, it returns !ok.
+func (c *Configuration) Token(address string) (*types.Currency, bool) {
+	checkAddress, ok := wemix.ChecksumAddress(address)
+	if !ok {
+		return nil, false
+	}
+
+	for _, token := range c.Tokens {
+		tokenAddress, _ := wemix.TokenAddress(token)
+		if tokenAddress == checkAddress {
+			return token, true
+		}
+	}
+
+	return nil, false
+}
+
+// loadTokens reads a list of token currencies from
+// the JSON file at path.
+func loadTokens(path string) ([]*types.Currency, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []*types.Currency
+	if err := json.Unmarshal(content, &tokens); err != nil {
+		return nil, err
+	}
+
+	for _, token := range tokens {
+		if _, ok := wemix.TokenAddress(token); !ok {
+			return nil, fmt.Errorf("%s has no valid %s", token.Symbol, wemix.ContractAddressKey)
+		}
+	}
+
+	return tokens, nil
+}
+
+// loadNfts reads a list of non-fungible token
+// contract currencies from the JSON file at path.
+func loadNfts(path string) ([]*types.Currency, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var nfts []*types.Currency
+	if err := json.Unmarshal(content, &nfts); err != nil {
+		return nil, err
+	}
+
+	for _, nft := range nfts {
+		if _, _, ok := wemix.NftContract(nft); !ok {
+			return nil, fmt.Errorf(
+				"%s has no valid %s and %s",
+				nft.Symbol,
+				wemix.ContractAddressKey,
+				wemix.StandardKey,
+			)
+		}
+	}
+
+	return nfts, nil
+}
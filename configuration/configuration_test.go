@@ -17,23 +17,36 @@ package configuration
 import (
 	"errors"
 
+	"math/big"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/wemixarchive/rosetta-wemix/wemix"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestLoadConfiguration(t *testing.T) {
 	tests := map[string]struct {
-		Mode            string
-		Network         string
-		Port            string
-		Gwemix          string
-		SkipGwemixAdmin string
+		Mode               string
+		Network            string
+		Port               string
+		Gwemix             string
+		SkipGwemixAdmin    string
+		TokenList          string
+		NftList            string
+		GasLimitMultiplier string
+		NonceManager       string
+		NonceTimeout       string
+		SubmitPreflight    string
+		RawSignedTx        string
+		MetadataBundle     string
+		MetadataBundleAge  string
 
 		cfg *Configuration
 		err error
@@ -67,6 +80,7 @@ func TestLoadConfiguration(t *testing.T) {
 				GwemixURL:              DefaultGwemixURL,
 				GwemixArguments:        wemix.MainnetGwemixArguments,
 				SkipGwemixAdmin:        false,
+				GasLimitMultiplier:     DefaultGasLimitMultiplier,
 			},
 		},
 		"all set (mainnet) + gwemix": {
@@ -88,6 +102,7 @@ func TestLoadConfiguration(t *testing.T) {
 				RemoteGwemix:           true,
 				GwemixArguments:        wemix.MainnetGwemixArguments,
 				SkipGwemixAdmin:        true,
+				GasLimitMultiplier:     DefaultGasLimitMultiplier,
 			},
 		},
 		"all set (testnet)": {
@@ -107,8 +122,255 @@ func TestLoadConfiguration(t *testing.T) {
 				GwemixURL:              DefaultGwemixURL,
 				GwemixArguments:        wemix.TestnetGwemixArguments,
 				SkipGwemixAdmin:        true,
+				GasLimitMultiplier:     DefaultGasLimitMultiplier,
 			},
 		},
+		"all set (testnet) + token list": {
+			Mode:      string(Online),
+			Network:   Testnet,
+			Port:      "1000",
+			TokenList: "testdata/tokens.json",
+			cfg: &Configuration{
+				Mode: Online,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                 params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier: wemix.TestnetGenesisBlockIdentifier,
+				Port:                   1000,
+				GwemixURL:              DefaultGwemixURL,
+				GwemixArguments:        wemix.TestnetGwemixArguments,
+				Tokens: []*types.Currency{
+					{
+						Symbol:   "WEMIX$",
+						Decimals: 18,
+						Metadata: map[string]interface{}{
+							wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+						},
+					},
+				},
+				GasLimitMultiplier: DefaultGasLimitMultiplier,
+			},
+		},
+		"all set (testnet) + nft list": {
+			Mode:    string(Online),
+			Network: Testnet,
+			Port:    "1000",
+			NftList: "testdata/nfts.json",
+			cfg: &Configuration{
+				Mode: Online,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                 params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier: wemix.TestnetGenesisBlockIdentifier,
+				Port:                   1000,
+				GwemixURL:              DefaultGwemixURL,
+				GwemixArguments:        wemix.TestnetGwemixArguments,
+				Nfts: []*types.Currency{
+					{
+						Symbol:   "WEMIX Heroes",
+						Decimals: 0,
+						Metadata: map[string]interface{}{
+							wemix.ContractAddressKey: "0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+							wemix.StandardKey:        wemix.Erc721Standard,
+						},
+					},
+				},
+				GasLimitMultiplier: DefaultGasLimitMultiplier,
+			},
+		},
+		"all set (testnet) + gas limit multiplier": {
+			Mode:               string(Online),
+			Network:            Testnet,
+			Port:               "1000",
+			GasLimitMultiplier: "1.5",
+			cfg: &Configuration{
+				Mode: Online,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                 params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier: wemix.TestnetGenesisBlockIdentifier,
+				Port:                   1000,
+				GwemixURL:              DefaultGwemixURL,
+				GwemixArguments:        wemix.TestnetGwemixArguments,
+				GasLimitMultiplier:     1.5,
+			},
+		},
+		"all set (testnet) + nonce manager": {
+			Mode:         string(Online),
+			Network:      Testnet,
+			Port:         "1000",
+			NonceManager: "TRUE",
+			NonceTimeout: "30s",
+			cfg: &Configuration{
+				Mode: Online,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                  params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier:  wemix.TestnetGenesisBlockIdentifier,
+				Port:                    1000,
+				GwemixURL:               DefaultGwemixURL,
+				GwemixArguments:         wemix.TestnetGwemixArguments,
+				GasLimitMultiplier:      DefaultGasLimitMultiplier,
+				NonceManager:            true,
+				NonceReservationTimeout: 30 * time.Second,
+			},
+		},
+		"all set (testnet) + submit preflight": {
+			Mode:            string(Online),
+			Network:         Testnet,
+			Port:            "1000",
+			SubmitPreflight: "TRUE",
+			cfg: &Configuration{
+				Mode: Online,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                 params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier: wemix.TestnetGenesisBlockIdentifier,
+				Port:                   1000,
+				GwemixURL:              DefaultGwemixURL,
+				GwemixArguments:        wemix.TestnetGwemixArguments,
+				GasLimitMultiplier:     DefaultGasLimitMultiplier,
+				SubmitPreflight:        true,
+			},
+		},
+		"all set (testnet) + raw signed tx": {
+			Mode:        string(Online),
+			Network:     Testnet,
+			Port:        "1000",
+			RawSignedTx: "true",
+			cfg: &Configuration{
+				Mode: Online,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                 params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier: wemix.TestnetGenesisBlockIdentifier,
+				Port:                   1000,
+				GwemixURL:              DefaultGwemixURL,
+				GwemixArguments:        wemix.TestnetGwemixArguments,
+				GasLimitMultiplier:     DefaultGasLimitMultiplier,
+				RawSignedTx:            true,
+			},
+		},
+		"all set (testnet, offline) + metadata bundle": {
+			Mode:           string(Offline),
+			Network:        Testnet,
+			Port:           "1000",
+			MetadataBundle: "testdata/metadata_bundle.json",
+			cfg: &Configuration{
+				Mode: Offline,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                 params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier: wemix.TestnetGenesisBlockIdentifier,
+				Port:                   1000,
+				GwemixURL:              DefaultGwemixURL,
+				GwemixArguments:        wemix.TestnetGwemixArguments,
+				GasLimitMultiplier:     DefaultGasLimitMultiplier,
+				MetadataBundle: &MetadataBundle{
+					Version: MetadataBundleVersion,
+					Network: &types.NetworkIdentifier{
+						Network:    wemix.TestnetNetwork,
+						Blockchain: wemix.Blockchain,
+					},
+					ChainID:   (*hexutil.Big)(big.NewInt(1112)),
+					Timestamp: 1700000000,
+					GasPrice:  (*hexutil.Big)(big.NewInt(101000000000)),
+					GasTipCap: (*hexutil.Big)(big.NewInt(1000000000)),
+					Accounts: []*BundleAccount{
+						{
+							Address:  common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"),
+							Nonce:    7,
+							GasLimit: 100000,
+						},
+					},
+					Checksum: "0x2815984dac6790c6a7c3e2624cba2fb1e23021602da78553945dc4399ed9ee8d",
+				},
+				MetadataBundleMaxAge: DefaultMetadataBundleMaxAge,
+			},
+		},
+		"metadata bundle max age": {
+			Mode:              string(Offline),
+			Network:           Testnet,
+			Port:              "1000",
+			MetadataBundle:    "testdata/metadata_bundle.json",
+			MetadataBundleAge: "24h",
+			cfg: &Configuration{
+				Mode: Offline,
+				Network: &types.NetworkIdentifier{
+					Network:    wemix.TestnetNetwork,
+					Blockchain: wemix.Blockchain,
+				},
+				Params:                 params.WemixTestnetChainConfig,
+				GenesisBlockIdentifier: wemix.TestnetGenesisBlockIdentifier,
+				Port:                   1000,
+				GwemixURL:              DefaultGwemixURL,
+				GwemixArguments:        wemix.TestnetGwemixArguments,
+				GasLimitMultiplier:     DefaultGasLimitMultiplier,
+				MetadataBundle: &MetadataBundle{
+					Version: MetadataBundleVersion,
+					Network: &types.NetworkIdentifier{
+						Network:    wemix.TestnetNetwork,
+						Blockchain: wemix.Blockchain,
+					},
+					ChainID:   (*hexutil.Big)(big.NewInt(1112)),
+					Timestamp: 1700000000,
+					GasPrice:  (*hexutil.Big)(big.NewInt(101000000000)),
+					GasTipCap: (*hexutil.Big)(big.NewInt(1000000000)),
+					Accounts: []*BundleAccount{
+						{
+							Address:  common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"),
+							Nonce:    7,
+							GasLimit: 100000,
+						},
+					},
+					Checksum: "0x2815984dac6790c6a7c3e2624cba2fb1e23021602da78553945dc4399ed9ee8d",
+				},
+				MetadataBundleMaxAge: 24 * time.Hour,
+			},
+		},
+		"invalid metadata bundle max age": {
+			Mode:              string(Offline),
+			Network:           Testnet,
+			Port:              "1000",
+			MetadataBundle:    "testdata/metadata_bundle.json",
+			MetadataBundleAge: "-1h",
+			err:               errors.New("METADATA_BUNDLE_MAX_AGE -1h must be positive"),
+		},
+		"metadata bundle for another network": {
+			Mode:           string(Offline),
+			Network:        Mainnet,
+			Port:           "1000",
+			MetadataBundle: "testdata/metadata_bundle.json",
+			err:            errors.New("METADATA_BUNDLE testdata/metadata_bundle.json is for network Testnet"),
+		},
+		"missing metadata bundle": {
+			Mode:           string(Offline),
+			Network:        Testnet,
+			Port:           "1000",
+			MetadataBundle: "testdata/missing.json",
+			err:            errors.New("unable to load METADATA_BUNDLE testdata/missing.json"),
+		},
+		"invalid raw signed tx": {
+			Mode:        string(Online),
+			Network:     Testnet,
+			Port:        "1000",
+			RawSignedTx: "raw",
+			err:         errors.New("unable to parse RAW_SIGNED_TX raw"),
+		},
 		"invalid mode": {
 			Mode:    "bad mode",
 			Network: Testnet,
@@ -127,6 +389,35 @@ func TestLoadConfiguration(t *testing.T) {
 			Port:    "bad port",
 			err:     errors.New("unable to parse port bad port"),
 		},
+		"invalid token list": {
+			Mode:      string(Offline),
+			Network:   Testnet,
+			Port:      "1000",
+			TokenList: "testdata/tokens_invalid.json",
+			err:       errors.New("WEMIX$ has no valid contractAddress"),
+		},
+		"invalid nft list": {
+			Mode:    string(Offline),
+			Network: Testnet,
+			Port:    "1000",
+			NftList: "testdata/nfts_invalid.json",
+			err:     errors.New("WEMIX Heroes has no valid contractAddress and standard"),
+		},
+		"invalid gas limit multiplier": {
+			Mode:               string(Offline),
+			Network:            Testnet,
+			Port:               "1000",
+			GasLimitMultiplier: "0.5",
+			err:                errors.New("GAS_LIMIT_MULTIPLIER 0.5 must be at least 1"),
+		},
+		"invalid nonce reservation timeout": {
+			Mode:         string(Offline),
+			Network:      Testnet,
+			Port:         "1000",
+			NonceManager: "TRUE",
+			NonceTimeout: "soon",
+			err:          errors.New("unable to parse NONCE_RESERVATION_TIMEOUT soon"),
+		},
 	}
 
 	for name, test := range tests {
@@ -136,6 +427,15 @@ func TestLoadConfiguration(t *testing.T) {
 			os.Setenv(PortEnv, test.Port)
 			os.Setenv(GwemixEnv, test.Gwemix)
 			os.Setenv(SkipGwemixAdminEnv, test.SkipGwemixAdmin)
+			os.Setenv(TokenListEnv, test.TokenList)
+			os.Setenv(NftListEnv, test.NftList)
+			os.Setenv(GasLimitMultiplierEnv, test.GasLimitMultiplier)
+			os.Setenv(NonceManagerEnv, test.NonceManager)
+			os.Setenv(NonceReservationTimeoutEnv, test.NonceTimeout)
+			os.Setenv(SubmitPreflightEnv, test.SubmitPreflight)
+			os.Setenv(RawSignedTxEnv, test.RawSignedTx)
+			os.Setenv(MetadataBundleEnv, test.MetadataBundle)
+			os.Setenv(MetadataBundleMaxAgeEnv, test.MetadataBundleAge)
 
 			cfg, err := LoadConfiguration()
 			if test.err != nil {
@@ -0,0 +1,153 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configuration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MetadataBundleVersion is the version of the
+// metadata bundle format.
+const MetadataBundleVersion = 1
+
+// MetadataBundle holds the construction metadata of a set of
+// accounts, exported by an online instance so that an offline
+// instance can answer /construction/metadata for them.
+type MetadataBundle struct {
+	Version   int                      `json:"version"`
+	Network   *types.NetworkIdentifier `json:"network_identifier"`
+	ChainID   *hexutil.Big             `json:"chain_id"`
+	Timestamp int64                    `json:"timestamp"`
+
+	GasPrice  *hexutil.Big `json:"gas_price"`
+	GasTipCap *hexutil.Big `json:"gas_tip_cap"`
+
+	Accounts []*BundleAccount `json:"accounts"`
+
+	// Checksum is the Keccak-256 hash of the bundle
+	// encoded without its checksum.
+	Checksum string `json:"checksum"`
+}
+
+// BundleAccount is the metadata of one account
+// in a MetadataBundle.
+type BundleAccount struct {
+	Address common.Address `json:"address"`
+	Nonce   hexutil.Uint64 `json:"nonce"`
+
+	// GasLimit is used for transactions that carry call
+	// data, whose gas cannot be estimated offline.
+	GasLimit hexutil.Uint64 `json:"gas_limit,omitempty"`
+}
+
+// Account returns the metadata of address, if the
+// bundle holds it.
+func (b *MetadataBundle) Account(address common.Address) (*BundleAccount, bool) {
+	for _, account := range b.Accounts {
+		if account.Address == address {
+			return account, true
+		}
+	}
+
+	return nil, false
+}
+
+// Age returns how long before now the bundle was exported.
+func (b *MetadataBundle) Age(now time.Time) time.Duration {
+	return now.Sub(time.Unix(b.Timestamp, 0))
+}
+
+// Seal sets the checksum of the bundle.
+func (b *MetadataBundle) Seal() error {
+	checksum, err := b.checksum()
+	if err != nil {
+		return err
+	}
+
+	b.Checksum = checksum
+	return nil
+}
+
+// Verify checks the version and checksum of the bundle.
+func (b *MetadataBundle) Verify() error {
+	if b.Version != MetadataBundleVersion {
+		return fmt.Errorf("unsupported metadata bundle version %d", b.Version)
+	}
+
+	checksum, err := b.checksum()
+	if err != nil {
+		return err
+	}
+	if checksum != b.Checksum {
+		return fmt.Errorf("checksum %s does not match contents %s", b.Checksum, checksum)
+	}
+
+	if b.Network == nil || b.ChainID == nil || b.GasPrice == nil || b.GasTipCap == nil {
+		return errors.New("metadata bundle is incomplete")
+	}
+
+	return nil
+}
+
+// WriteFile writes the bundle as JSON to path.
+func (b *MetadataBundle) WriteFile(path string) error {
+	content, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0600) // nolint:gomnd
+}
+
+// checksum computes the checksum of the bundle.
+func (b *MetadataBundle) checksum() (string, error) {
+	unsealed := *b
+	unsealed.Checksum = ""
+	content, err := json.Marshal(&unsealed)
+	if err != nil {
+		return "", err
+	}
+
+	return crypto.Keccak256Hash(content).Hex(), nil
+}
+
+// LoadMetadataBundle reads and verifies the
+// metadata bundle at path.
+func LoadMetadataBundle(path string) (*MetadataBundle, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var bundle MetadataBundle
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		return nil, err
+	}
+
+	if err := bundle.Verify(); err != nil {
+		return nil, err
+	}
+
+	return &bundle, nil
+}
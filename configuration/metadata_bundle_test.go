@@ -0,0 +1,52 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configuration
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataBundle(t *testing.T) {
+	bundle, err := LoadMetadataBundle("testdata/metadata_bundle.json")
+	assert.NoError(t, err)
+
+	account, ok := bundle.Account(common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(7), uint64(account.Nonce))
+
+	_, ok = bundle.Account(common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d"))
+	assert.False(t, ok)
+
+	// A written bundle loads back unchanged.
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	assert.NoError(t, bundle.WriteFile(path))
+	loaded, err := LoadMetadataBundle(path)
+	assert.NoError(t, err)
+	assert.Equal(t, bundle, loaded)
+
+	// Tampering breaks the checksum.
+	account.Nonce++
+	assert.Contains(t, bundle.Verify().Error(), "does not match contents")
+	assert.NoError(t, bundle.Seal())
+	assert.NoError(t, bundle.Verify())
+
+	bundle.Version = MetadataBundleVersion + 1
+	assert.NoError(t, bundle.Seal())
+	assert.EqualError(t, bundle.Verify(), "unsupported metadata bundle version 2")
+}
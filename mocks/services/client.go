@@ -8,11 +8,15 @@ import (
 
 	common "github.com/ethereum/go-ethereum/common"
 
+	ethereum "github.com/ethereum/go-ethereum"
+
 	coretypes "github.com/ethereum/go-ethereum/core/types"
 
 	mock "github.com/stretchr/testify/mock"
 
 	types "github.com/coinbase/rosetta-sdk-go/types"
+
+	wemix "github.com/wemixarchive/rosetta-wemix/wemix"
 )
 
 // Client is an autogenerated mock type for the Client type
@@ -20,13 +24,13 @@ type Client struct {
 	mock.Mock
 }
 
-// Balance provides a mock function with given fields: _a0, _a1, _a2
-func (_m *Client) Balance(_a0 context.Context, _a1 *types.AccountIdentifier, _a2 *types.PartialBlockIdentifier) (*types.AccountBalanceResponse, error) {
-	ret := _m.Called(_a0, _a1, _a2)
+// Balance provides a mock function with given fields: _a0, _a1, _a2, _a3
+func (_m *Client) Balance(_a0 context.Context, _a1 *types.AccountIdentifier, _a2 *types.PartialBlockIdentifier, _a3 []*types.Currency) (*types.AccountBalanceResponse, error) {
+	ret := _m.Called(_a0, _a1, _a2, _a3)
 
 	var r0 *types.AccountBalanceResponse
-	if rf, ok := ret.Get(0).(func(context.Context, *types.AccountIdentifier, *types.PartialBlockIdentifier) *types.AccountBalanceResponse); ok {
-		r0 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(0).(func(context.Context, *types.AccountIdentifier, *types.PartialBlockIdentifier, []*types.Currency) *types.AccountBalanceResponse); ok {
+		r0 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*types.AccountBalanceResponse)
@@ -34,8 +38,8 @@ func (_m *Client) Balance(_a0 context.Context, _a1 *types.AccountIdentifier, _a2
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *types.AccountIdentifier, *types.PartialBlockIdentifier) error); ok {
-		r1 = rf(_a0, _a1, _a2)
+	if rf, ok := ret.Get(1).(func(context.Context, *types.AccountIdentifier, *types.PartialBlockIdentifier, []*types.Currency) error); ok {
+		r1 = rf(_a0, _a1, _a2, _a3)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -89,6 +93,73 @@ func (_m *Client) Call(ctx context.Context, request *types.CallRequest) (*types.
 	return r0, r1
 }
 
+// CreateAccessList provides a mock function with given fields: ctx, msg
+func (_m *Client) CreateAccessList(ctx context.Context, msg ethereum.CallMsg) (coretypes.AccessList, error) {
+	ret := _m.Called(ctx, msg)
+
+	var r0 coretypes.AccessList
+	if rf, ok := ret.Get(0).(func(context.Context, ethereum.CallMsg) coretypes.AccessList); ok {
+		r0 = rf(ctx, msg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(coretypes.AccessList)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ethereum.CallMsg) error); ok {
+		r1 = rf(ctx, msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstimateGas provides a mock function with given fields: ctx, msg
+func (_m *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	ret := _m.Called(ctx, msg)
+
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func(context.Context, ethereum.CallMsg) uint64); ok {
+		r0 = rf(ctx, msg)
+	} else {
+		r0 = ret.Get(0).(uint64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ethereum.CallMsg) error); ok {
+		r1 = rf(ctx, msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FeeHistory provides a mock function with given fields: ctx, blockCount, lastBlock, rewardPercentiles
+func (_m *Client) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*wemix.FeeHistory, error) {
+	ret := _m.Called(ctx, blockCount, lastBlock, rewardPercentiles)
+
+	var r0 *wemix.FeeHistory
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, *big.Int, []float64) *wemix.FeeHistory); ok {
+		r0 = rf(ctx, blockCount, lastBlock, rewardPercentiles)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wemix.FeeHistory)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, *big.Int, []float64) error); ok {
+		r1 = rf(ctx, blockCount, lastBlock, rewardPercentiles)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMempool provides a mock function with given fields: ctx
 func (_m *Client) GetMempool(ctx context.Context) (*types.MempoolResponse, error) {
 	ret := _m.Called(ctx)
@@ -112,6 +183,75 @@ func (_m *Client) GetMempool(ctx context.Context) (*types.MempoolResponse, error
 	return r0, r1
 }
 
+// GetTxPoolStatus provides a mock function with given fields: ctx
+func (_m *Client) GetTxPoolStatus(ctx context.Context) (*wemix.TxPoolStatus, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *wemix.TxPoolStatus
+	if rf, ok := ret.Get(0).(func(context.Context) *wemix.TxPoolStatus); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*wemix.TxPoolStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MempoolEntries provides a mock function with given fields: ctx, account
+func (_m *Client) MempoolEntries(ctx context.Context, account *common.Address) ([]*wemix.MempoolEntry, error) {
+	ret := _m.Called(ctx, account)
+
+	var r0 []*wemix.MempoolEntry
+	if rf, ok := ret.Get(0).(func(context.Context, *common.Address) []*wemix.MempoolEntry); ok {
+		r0 = rf(ctx, account)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*wemix.MempoolEntry)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *common.Address) error); ok {
+		r1 = rf(ctx, account)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MempoolTransaction provides a mock function with given fields: ctx, hash
+func (_m *Client) MempoolTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *types.Transaction
+	if rf, ok := ret.Get(0).(func(context.Context, common.Hash) *types.Transaction); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.Transaction)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Hash) error); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // PendingNonceAt provides a mock function with given fields: _a0, _a1
 func (_m *Client) PendingNonceAt(_a0 context.Context, _a1 common.Address) (uint64, error) {
 	ret := _m.Called(_a0, _a1)
@@ -133,6 +273,96 @@ func (_m *Client) PendingNonceAt(_a0 context.Context, _a1 common.Address) (uint6
 	return r0, r1
 }
 
+// PendingBalanceAt provides a mock function with given fields: ctx, account
+func (_m *Client) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	ret := _m.Called(ctx, account)
+
+	var r0 *big.Int
+	if rf, ok := ret.Get(0).(func(context.Context, common.Address) *big.Int); ok {
+		r0 = rf(ctx, account)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Address) error); ok {
+		r1 = rf(ctx, account)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PendingCallContract provides a mock function with given fields: ctx, msg
+func (_m *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	ret := _m.Called(ctx, msg)
+
+	var r0 []byte
+	if rf, ok := ret.Get(0).(func(context.Context, ethereum.CallMsg) []byte); ok {
+		r0 = rf(ctx, msg)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, ethereum.CallMsg) error); ok {
+		r1 = rf(ctx, msg)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PendingTransaction provides a mock function with given fields: ctx, hash
+func (_m *Client) PendingTransaction(ctx context.Context, hash common.Hash) (*coretypes.Transaction, common.Address, error) {
+	ret := _m.Called(ctx, hash)
+
+	var r0 *coretypes.Transaction
+	if rf, ok := ret.Get(0).(func(context.Context, common.Hash) *coretypes.Transaction); ok {
+		r0 = rf(ctx, hash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*coretypes.Transaction)
+		}
+	}
+
+	var r1 common.Address
+	if rf, ok := ret.Get(1).(func(context.Context, common.Hash) common.Address); ok {
+		r1 = rf(ctx, hash)
+	} else {
+		r1 = ret.Get(1).(common.Address)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, common.Hash) error); ok {
+		r2 = rf(ctx, hash)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SendRawTransaction provides a mock function with given fields: ctx, raw
+func (_m *Client) SendRawTransaction(ctx context.Context, raw []byte) error {
+	ret := _m.Called(ctx, raw)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []byte) error); ok {
+		r0 = rf(ctx, raw)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SendTransaction provides a mock function with given fields: ctx, tx
 func (_m *Client) SendTransaction(ctx context.Context, tx *coretypes.Transaction) error {
 	ret := _m.Called(ctx, tx)
@@ -218,6 +448,29 @@ func (_m *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return r0, r1
 }
 
+// SuggestGasTipCap provides a mock function with given fields: ctx
+func (_m *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *big.Int
+	if rf, ok := ret.Get(0).(func(context.Context) *big.Int); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Transaction provides a mock function with given fields: _a0, _a1, _a2
 func (_m *Client) Transaction(_a0 context.Context, _a1 *types.BlockIdentifier, _a2 *types.TransactionIdentifier) (*types.Transaction, error) {
 	ret := _m.Called(_a0, _a1, _a2)
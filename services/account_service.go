@@ -16,8 +16,10 @@ package services
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/wemixarchive/rosetta-wemix/configuration"
+	"github.com/wemixarchive/rosetta-wemix/wemix"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
@@ -48,13 +50,25 @@ func (s *AccountAPIService) AccountBalance(
 		return nil, ErrUnavailableOffline
 	}
 
+	// Token balances are read from the contract
+	// address in the currency metadata.
+	for _, currency := range request.Currencies {
+		if _, ok := wemix.TokenAddress(currency); !ok && types.Hash(currency) != types.Hash(wemix.Currency) {
+			return nil, wrapErr(
+				ErrInvalidInput,
+				fmt.Errorf("%s is not WEMIX or a token with a contract address", currency.Symbol),
+			)
+		}
+	}
+
 	balanceResponse, err := s.client.Balance(
 		ctx,
 		request.AccountIdentifier,
 		request.BlockIdentifier,
+		request.Currencies,
 	)
 	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+		return nil, gwemixErr(err)
 	}
 
 	return balanceResponse, nil
@@ -80,6 +80,7 @@ func TestAccountBalance_Online(t *testing.T) {
 		ctx,
 		account,
 		types.ConstructPartialBlockIdentifier(block),
+		[]*types.Currency(nil),
 	).Return(resp, nil).Once()
 
 	bal, err := servicer.AccountBalance(ctx, &types.AccountBalanceRequest{
@@ -89,6 +90,52 @@ func TestAccountBalance_Online(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, resp, bal)
 
+	// Token balances are read at the same block.
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+	currencies := []*types.Currency{wemix.Currency, token}
+	tokenResp := &types.AccountBalanceResponse{
+		BlockIdentifier: block,
+		Balances: []*types.Amount{
+			{
+				Value:    "25",
+				Currency: wemix.Currency,
+			},
+			{
+				Value:    "1000",
+				Currency: token,
+			},
+		},
+	}
+	mockClient.On(
+		"Balance",
+		ctx,
+		account,
+		types.ConstructPartialBlockIdentifier(block),
+		currencies,
+	).Return(tokenResp, nil).Once()
+
+	bal, err = servicer.AccountBalance(ctx, &types.AccountBalanceRequest{
+		AccountIdentifier: account,
+		BlockIdentifier:   types.ConstructPartialBlockIdentifier(block),
+		Currencies:        currencies,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, tokenResp, bal)
+
+	// Currencies without a contract address are rejected.
+	bal, err = servicer.AccountBalance(ctx, &types.AccountBalanceRequest{
+		AccountIdentifier: account,
+		Currencies:        []*types.Currency{{Symbol: "BTC", Decimals: 8}},
+	})
+	assert.Nil(t, bal)
+	assert.Equal(t, ErrInvalidInput.Code, err.Code)
+
 	coins, err := servicer.AccountCoins(ctx, nil)
 	assert.Nil(t, coins)
 	assert.Equal(t, ErrUnimplemented.Code, err.Code)
@@ -0,0 +1,455 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BatchPayloadsRequest is the request of the
+// /construction/batch/payloads extension endpoint. Each
+// intent holds the operations of one transfer, as sent to
+// /construction/preprocess. Metadata is the preprocess
+// metadata applied to every transfer.
+type BatchPayloadsRequest struct {
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier"`
+	Intents           [][]*types.Operation     `json:"intents"`
+	Metadata          map[string]interface{}   `json:"metadata,omitempty"`
+}
+
+// BatchPayloadsResponse is the response of the
+// /construction/batch/payloads extension endpoint,
+// with one transaction per intent.
+type BatchPayloadsResponse struct {
+	Transactions []*BatchTransaction `json:"transactions"`
+}
+
+// BatchTransaction is an unsigned transaction of a batch,
+// with its signing payloads and suggested fee.
+type BatchTransaction struct {
+	UnsignedTransaction string                  `json:"unsigned_transaction"`
+	Payloads            []*types.SigningPayload `json:"payloads"`
+	SuggestedFee        []*types.Amount         `json:"suggested_fee"`
+}
+
+// BatchSubmitRequest is the request of the
+// /construction/batch/submit extension endpoint.
+type BatchSubmitRequest struct {
+	NetworkIdentifier  *types.NetworkIdentifier `json:"network_identifier"`
+	SignedTransactions []string                 `json:"signed_transactions"`
+}
+
+// BatchSubmitResponse is the response of the
+// /construction/batch/submit extension endpoint, with
+// one result per signed transaction.
+type BatchSubmitResponse struct {
+	Results []*BatchSubmitResult `json:"results"`
+}
+
+// BatchSubmitResult holds either the identifier of a
+// broadcast transaction or the error that prevented it.
+type BatchSubmitResult struct {
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier,omitempty"`
+	Error                 *types.Error                 `json:"error,omitempty"`
+}
+
+// BatchAPIService constructs and submits batches of
+// transfers from one sender.
+type BatchAPIService struct {
+	config       *configuration.Configuration
+	client       Client
+	construction *ConstructionAPIService
+}
+
+// NewBatchAPIService creates a new instance of a BatchAPIService
+// that constructs transactions with construction.
+func NewBatchAPIService(
+	cfg *configuration.Configuration,
+	client Client,
+	construction *ConstructionAPIService,
+) *BatchAPIService {
+	return &BatchAPIService{
+		config:       cfg,
+		client:       client,
+		construction: construction,
+	}
+}
+
+// BatchPayloads implements the /construction/batch/payloads
+// endpoint. The transfers get consecutive nonces starting at
+// the pending nonce of the sender.
+func (s *BatchAPIService) BatchPayloads(
+	ctx context.Context,
+	request *BatchPayloadsRequest,
+) (*BatchPayloadsResponse, *types.Error) {
+	if s.config.Mode != configuration.Online {
+		return nil, ErrUnavailableOffline
+	}
+
+	if len(request.Intents) == 0 {
+		return nil, wrapErr(ErrInvalidInput, errors.New("batch has no intents"))
+	}
+
+	batchOptions := make([]*options, len(request.Intents))
+	for i, ops := range request.Intents {
+		preprocessResponse, rErr := s.construction.ConstructionPreprocess(
+			ctx,
+			&types.ConstructionPreprocessRequest{
+				NetworkIdentifier: request.NetworkIdentifier,
+				Operations:        ops,
+				Metadata:          request.Metadata,
+			},
+		)
+		if rErr != nil {
+			return nil, batchErr(rErr, i)
+		}
+
+		var input options
+		if err := unmarshalJSONMap(preprocessResponse.Options, &input); err != nil {
+			return nil, batchErr(wrapErr(ErrUnableToParseIntermediateResult, err), i)
+		}
+
+		if input.Method == permitMethod {
+			return nil, batchErr(
+				wrapErr(ErrInvalidInput, errors.New("permits are signed messages, not transactions")),
+				i,
+			)
+		}
+		if input.Safe {
+			return nil, batchErr(
+				wrapErr(ErrInvalidInput, errors.New("safe transactions are signed messages, not transactions")),
+				i,
+			)
+		}
+		if len(input.FeePayer) > 0 {
+			return nil, batchErr(
+				wrapErr(ErrInvalidInput, errors.New("fee payers sign after the sender, so fee-delegated transactions cannot be batched")),
+				i,
+			)
+		}
+		if input.Nonce != nil || len(input.ReplaceHash) > 0 {
+			return nil, batchErr(
+				wrapErr(ErrInvalidInput, errors.New("batch nonces cannot be pinned")),
+				i,
+			)
+		}
+		if i > 0 && common.HexToAddress(input.From) != common.HexToAddress(batchOptions[0].From) {
+			return nil, batchErr(
+				wrapErr(ErrInvalidInput, fmt.Errorf("batch mixes senders %s and %s", batchOptions[0].From, input.From)),
+				i,
+			)
+		}
+
+		batchOptions[i] = &input
+	}
+
+	from := common.HexToAddress(batchOptions[0].From)
+	start, rErr := s.reserveNonces(ctx, from, uint64(len(batchOptions)))
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	response := &BatchPayloadsResponse{}
+	for i, input := range batchOptions {
+		tx, rErr := s.payloads(ctx, request, i, input, start+uint64(i))
+		if rErr != nil {
+			// No transaction of the batch is returned,
+			// so none of its nonces are used.
+			s.releaseNonces(from, start, start+uint64(len(batchOptions)))
+			return nil, batchErr(rErr, i)
+		}
+
+		response.Transactions = append(response.Transactions, tx)
+	}
+
+	return response, nil
+}
+
+// BatchSubmit implements the /construction/batch/submit
+// endpoint. Transactions are submitted in order until one
+// fails. The transactions after it are not submitted and
+// their nonces are released, as they could not be mined
+// before the nonce of the failed transaction.
+func (s *BatchAPIService) BatchSubmit(
+	ctx context.Context,
+	request *BatchSubmitRequest,
+) (*BatchSubmitResponse, *types.Error) {
+	if s.config.Mode != configuration.Online {
+		return nil, ErrUnavailableOffline
+	}
+
+	if len(request.SignedTransactions) == 0 {
+		return nil, wrapErr(ErrInvalidInput, errors.New("batch has no signed transactions"))
+	}
+
+	response := &BatchSubmitResponse{}
+	for i, signedTx := range request.SignedTransactions {
+		submitResponse, rErr := s.construction.ConstructionSubmit(
+			ctx,
+			&types.ConstructionSubmitRequest{
+				NetworkIdentifier: request.NetworkIdentifier,
+				SignedTransaction: signedTx,
+			},
+		)
+		if rErr != nil {
+			response.Results = append(response.Results, &BatchSubmitResult{Error: rErr})
+			response.Results = append(response.Results, s.skipSubmit(request.SignedTransactions[i+1:], i)...)
+			break
+		}
+
+		response.Results = append(response.Results, &BatchSubmitResult{
+			TransactionIdentifier: submitResponse.TransactionIdentifier,
+		})
+	}
+
+	return response, nil
+}
+
+// skipSubmit returns the results of the signed transactions
+// left unsubmitted after the failure of the transaction at
+// index failed, releasing their nonces from the highest down.
+func (s *BatchAPIService) skipSubmit(signedTxs []string, failed int) []*BatchSubmitResult {
+	results := make([]*BatchSubmitResult, len(signedTxs))
+	for i := len(signedTxs) - 1; i >= 0; i-- {
+		results[i] = &BatchSubmitResult{
+			Error: wrapErr(
+				ErrBatchNotSubmitted,
+				fmt.Errorf("transaction %d of the batch could not be submitted", failed),
+			),
+		}
+
+		if s.construction.nonces == nil {
+			continue
+		}
+
+		// Transactions that cannot be decoded
+		// hold no reservation to release.
+		tx, _, rErr := decodeParsedTx(signedTxs[i])
+		if rErr != nil {
+			continue
+		}
+
+		s.construction.nonces.Release(common.HexToAddress(tx.From), tx.Nonce)
+	}
+
+	return results
+}
+
+// payloads constructs the unsigned transaction of the
+// intent at index i of request, with the given nonce.
+func (s *BatchAPIService) payloads(
+	ctx context.Context,
+	request *BatchPayloadsRequest,
+	i int,
+	input *options,
+	nonce uint64,
+) (*BatchTransaction, *types.Error) {
+	input.Nonce = &nonce
+	optionsMap, err := marshalJSONMap(input)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	metadataResponse, rErr := s.construction.ConstructionMetadata(
+		ctx,
+		&types.ConstructionMetadataRequest{
+			NetworkIdentifier: request.NetworkIdentifier,
+			Options:           optionsMap,
+		},
+	)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	payloadsResponse, rErr := s.construction.ConstructionPayloads(
+		ctx,
+		&types.ConstructionPayloadsRequest{
+			NetworkIdentifier: request.NetworkIdentifier,
+			Operations:        request.Intents[i],
+			Metadata:          metadataResponse.Metadata,
+		},
+	)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	return &BatchTransaction{
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Payloads:            payloadsResponse.Payloads,
+		SuggestedFee:        metadataResponse.SuggestedFee,
+	}, nil
+}
+
+// reserveNonces returns the first of n consecutive nonces
+// of from, reserving them when nonces are managed locally.
+func (s *BatchAPIService) reserveNonces(
+	ctx context.Context,
+	from common.Address,
+	n uint64,
+) (uint64, *types.Error) {
+	if nonces := s.construction.nonces; nonces != nil {
+		start, err := nonces.ReserveRange(ctx, s.client, from, n)
+		if err != nil {
+			return 0, gwemixErr(err)
+		}
+
+		return start, nil
+	}
+
+	start, err := s.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return 0, gwemixErr(err)
+	}
+
+	return start, nil
+}
+
+// releaseNonces releases the reserved nonces of
+// from in [start, end), from the highest down.
+func (s *BatchAPIService) releaseNonces(from common.Address, start uint64, end uint64) {
+	nonces := s.construction.nonces
+	if nonces == nil {
+		return
+	}
+
+	for nonce := end; nonce > start; nonce-- {
+		nonces.Release(from, nonce-1)
+	}
+}
+
+// batchErr adds the index of the failing batch
+// entry to rErr.
+func batchErr(rErr *types.Error, i int) *types.Error {
+	newErr := wrapErr(rErr, nil)
+	newErr.Details = map[string]interface{}{"index": i}
+	for k, v := range rErr.Details {
+		newErr.Details[k] = v
+	}
+
+	return newErr
+}
+
+// BatchAPIController serves the batch
+// construction extension endpoints.
+type BatchAPIController struct {
+	service  *BatchAPIService
+	asserter *asserter.Asserter
+}
+
+// NewBatchAPIController creates a server.Router
+// for the batch construction extension endpoints.
+func NewBatchAPIController(
+	service *BatchAPIService,
+	asserter *asserter.Asserter,
+) server.Router {
+	return &BatchAPIController{
+		service:  service,
+		asserter: asserter,
+	}
+}
+
+// Routes returns all of the api route for the BatchAPIController
+func (c *BatchAPIController) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "BatchPayloads",
+			Method:      http.MethodPost,
+			Pattern:     "/construction/batch/payloads",
+			HandlerFunc: c.BatchPayloads,
+		},
+		{
+			Name:        "BatchSubmit",
+			Method:      http.MethodPost,
+			Pattern:     "/construction/batch/submit",
+			HandlerFunc: c.BatchSubmit,
+		},
+	}
+}
+
+// BatchPayloads - Construct a batch of transfers
+func (c *BatchAPIController) BatchPayloads(w http.ResponseWriter, r *http.Request) {
+	request := &BatchPayloadsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	// Each intent must be a valid preprocess request.
+	for _, ops := range request.Intents {
+		if err := c.asserter.ConstructionPreprocessRequest(&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: request.NetworkIdentifier,
+			Operations:        ops,
+			Metadata:          request.Metadata,
+		}); err != nil {
+			server.EncodeJSONResponse(&types.Error{
+				Message: err.Error(),
+			}, http.StatusInternalServerError, w)
+
+			return
+		}
+	}
+
+	result, serviceErr := c.service.BatchPayloads(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
+
+// BatchSubmit - Submit a batch of signed transactions
+func (c *BatchAPIController) BatchSubmit(w http.ResponseWriter, r *http.Request) {
+	request := &BatchSubmitRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	result, serviceErr := c.service.BatchSubmit(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
@@ -0,0 +1,336 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+	mocks "github.com/wemixarchive/rosetta-wemix/mocks/services"
+	"github.com/wemixarchive/rosetta-wemix/wemix"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatchService_Offline(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode: configuration.Offline,
+	}
+	mockClient := &mocks.Client{}
+	servicer := NewBatchAPIService(cfg, mockClient, NewConstructionAPIService(cfg, mockClient))
+	ctx := context.Background()
+
+	payloadsResponse, rErr := servicer.BatchPayloads(ctx, &BatchPayloadsRequest{})
+	assert.Nil(t, payloadsResponse)
+	assert.Equal(t, ErrUnavailableOffline, rErr)
+
+	submitResponse, rErr := servicer.BatchSubmit(ctx, &BatchSubmitRequest{})
+	assert.Nil(t, submitResponse)
+	assert.Equal(t, ErrUnavailableOffline, rErr)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestBatchService_Payloads(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:                    configuration.Online,
+		Network:                 networkIdentifier,
+		Params:                  params.WemixTestnetChainConfig,
+		NonceManager:            true,
+		NonceReservationTimeout: time.Minute,
+	}
+	mockClient := &mocks.Client{}
+	servicer := NewBatchAPIService(cfg, mockClient, NewConstructionAPIService(cfg, mockClient))
+	ctx := context.Background()
+
+	from := "0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"
+	recipients := []string{
+		"0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+		"0x2d74530C0C196De44d3906822053bf336F18a16e",
+		"0xbe862AD9AbFe6f22BCb087716c7D89a26051f74C",
+	}
+	var intents [][]*types.Operation
+	for i, to := range recipients {
+		intents = append(intents, transferOps(from, to, big.NewInt(int64(1000*(i+1))), wemix.Currency))
+	}
+
+	mockClient.On("PendingNonceAt", ctx, common.HexToAddress(from)).Return(uint64(5), nil)
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil)
+
+	t.Run("consecutive nonces", func(t *testing.T) {
+		mockClient.On("EstimateGas", ctx, mock.Anything).Return(uint64(21000), nil).Times(3)
+
+		resp, rErr := servicer.BatchPayloads(ctx, &BatchPayloadsRequest{
+			NetworkIdentifier: networkIdentifier,
+			Intents:           intents,
+		})
+		assert.Nil(t, rErr)
+		assert.Len(t, resp.Transactions, 3)
+
+		for i, tx := range resp.Transactions {
+			var unsignedTx transaction
+			assert.NoError(t, json.Unmarshal([]byte(tx.UnsignedTransaction), &unsignedTx))
+			assert.Equal(t, uint64(5+i), unsignedTx.Nonce)
+			assert.Equal(t, recipients[i], unsignedTx.To)
+			assert.Equal(t, big.NewInt(int64(1000*(i+1))), unsignedTx.Value)
+			assert.Equal(t, uint64(21000), unsignedTx.GasLimit)
+
+			assert.Len(t, tx.Payloads, 1)
+			assert.Equal(t, from, tx.Payloads[0].AccountIdentifier.Address)
+			assert.Equal(t, "2100000000000000", tx.SuggestedFee[0].Value)
+		}
+	})
+
+	t.Run("failure releases nonces", func(t *testing.T) {
+		mockClient.On("EstimateGas", ctx, mock.Anything).Return(uint64(21000), nil).Once()
+		mockClient.On("EstimateGas", ctx, mock.Anything).Return(uint64(0), errors.New("boom")).Once()
+
+		resp, rErr := servicer.BatchPayloads(ctx, &BatchPayloadsRequest{
+			NetworkIdentifier: networkIdentifier,
+			Intents:           intents,
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrGwemix.Code, rErr.Code)
+		assert.Equal(t, 1, rErr.Details["index"])
+
+		// A failure in the middle of the batch releases all
+		// of its nonces, so the next batch starts right
+		// after the first one.
+		mockClient.On("EstimateGas", ctx, mock.Anything).Return(uint64(21000), nil).Times(3)
+		resp, rErr = servicer.BatchPayloads(ctx, &BatchPayloadsRequest{
+			NetworkIdentifier: networkIdentifier,
+			Intents:           intents,
+		})
+		assert.Nil(t, rErr)
+
+		for i, tx := range resp.Transactions {
+			var unsignedTx transaction
+			assert.NoError(t, json.Unmarshal([]byte(tx.UnsignedTransaction), &unsignedTx))
+			assert.Equal(t, uint64(8+i), unsignedTx.Nonce)
+		}
+	})
+
+	t.Run("mixed senders", func(t *testing.T) {
+		resp, rErr := servicer.BatchPayloads(ctx, &BatchPayloadsRequest{
+			NetworkIdentifier: networkIdentifier,
+			Intents: [][]*types.Operation{
+				intents[0],
+				transferOps(recipients[0], from, big.NewInt(1000), wemix.Currency),
+			},
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+		assert.Equal(t, 1, rErr.Details["index"])
+	})
+
+	t.Run("pinned nonce", func(t *testing.T) {
+		resp, rErr := servicer.BatchPayloads(ctx, &BatchPayloadsRequest{
+			NetworkIdentifier: networkIdentifier,
+			Intents:           intents,
+			Metadata:          map[string]interface{}{"nonce": "0x1"},
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+		assert.Equal(t, 0, rErr.Details["index"])
+	})
+
+	t.Run("fee payer", func(t *testing.T) {
+		resp, rErr := servicer.BatchPayloads(ctx, &BatchPayloadsRequest{
+			NetworkIdentifier: networkIdentifier,
+			Intents:           intents,
+			Metadata:          map[string]interface{}{"fee_payer": recipients[0]},
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+		assert.Equal(t, 0, rErr.Details["index"])
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		resp, rErr := servicer.BatchPayloads(ctx, &BatchPayloadsRequest{
+			NetworkIdentifier: networkIdentifier,
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+	})
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestBatchService_Submit(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+	mockClient := &mocks.Client{}
+	a, err := asserter.NewServer(
+		wemix.OperationTypes,
+		wemix.HistoricalBalanceSupported,
+		[]*types.NetworkIdentifier{networkIdentifier},
+		wemix.CallMethods,
+		wemix.IncludeMempoolCoins,
+		"",
+	)
+	assert.NoError(t, err)
+	router := NewBlockchainRouter(cfg, mockClient, a)
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+
+	var signedTxs []string
+	var hashes []common.Hash
+	for nonce := uint64(0); nonce < 3; nonce++ {
+		tx, err := ethTypes.SignNewTx(
+			privateKey,
+			ethTypes.NewLondonSigner(big.NewInt(1112)),
+			&ethTypes.LegacyTx{
+				Nonce:    nonce,
+				GasPrice: big.NewInt(100000000000),
+				Gas:      21000,
+				To:       &to,
+				Value:    big.NewInt(1000),
+			},
+		)
+		assert.NoError(t, err)
+
+		signedTx, err := tx.MarshalJSON()
+		assert.NoError(t, err)
+		signedTxs = append(signedTxs, string(signedTx))
+		hashes = append(hashes, tx.Hash())
+	}
+
+	isTx := func(hash common.Hash) interface{} {
+		return mock.MatchedBy(func(tx *ethTypes.Transaction) bool {
+			return tx.Hash() == hash
+		})
+	}
+	mockClient.On("SendTransaction", mock.Anything, isTx(hashes[0])).Return(nil).Once()
+	mockClient.On("SendTransaction", mock.Anything, isTx(hashes[1])).Return(errors.New("nonce too low")).Once()
+
+	body, err := json.Marshal(&BatchSubmitRequest{
+		NetworkIdentifier:  networkIdentifier,
+		SignedTransactions: append(signedTxs, "0x00"),
+	})
+	assert.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(
+		recorder,
+		httptest.NewRequest(http.MethodPost, "/construction/batch/submit", bytes.NewReader(body)),
+	)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	var resp BatchSubmitResponse
+	assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &resp))
+	assert.Len(t, resp.Results, 4)
+
+	assert.Nil(t, resp.Results[0].Error)
+	assert.Equal(t, hashes[0].Hex(), resp.Results[0].TransactionIdentifier.Hash)
+
+	assert.Nil(t, resp.Results[1].TransactionIdentifier)
+	assert.Equal(t, ErrNonceTooLow.Code, resp.Results[1].Error.Code)
+
+	// The batch stops at the first failure.
+	for _, result := range resp.Results[2:] {
+		assert.Nil(t, result.TransactionIdentifier)
+		assert.Equal(t, ErrBatchNotSubmitted.Code, result.Error.Code)
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestBatchService_SubmitReleasesNonces(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:                    configuration.Online,
+		Network:                 networkIdentifier,
+		Params:                  params.WemixTestnetChainConfig,
+		NonceManager:            true,
+		NonceReservationTimeout: time.Minute,
+	}
+	mockClient := &mocks.Client{}
+	construction := NewConstructionAPIService(cfg, mockClient)
+	servicer := NewBatchAPIService(cfg, mockClient, construction)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(0), nil).Once()
+	start, err := construction.nonces.ReserveRange(ctx, mockClient, from, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), start)
+
+	var signedTxs []string
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		tx, err := ethTypes.SignNewTx(
+			privateKey,
+			ethTypes.NewLondonSigner(big.NewInt(1112)),
+			&ethTypes.LegacyTx{
+				Nonce:    nonce,
+				GasPrice: big.NewInt(100000000000),
+				Gas:      21000,
+				To:       &to,
+				Value:    big.NewInt(1000),
+			},
+		)
+		assert.NoError(t, err)
+
+		signedTx, err := tx.MarshalJSON()
+		assert.NoError(t, err)
+		signedTxs = append(signedTxs, string(signedTx))
+	}
+
+	mockClient.On("SendTransaction", ctx, mock.Anything).Return(nil).Once()
+	mockClient.On("SendTransaction", ctx, mock.Anything).Return(errors.New("insufficient funds")).Once()
+	resp, rErr := servicer.BatchSubmit(ctx, &BatchSubmitRequest{
+		NetworkIdentifier:  networkIdentifier,
+		SignedTransactions: signedTxs,
+	})
+	assert.Nil(t, rErr)
+	assert.Len(t, resp.Results, 4)
+	assert.Nil(t, resp.Results[0].Error)
+	assert.Equal(t, ErrInsufficientFunds.Code, resp.Results[1].Error.Code)
+	assert.Equal(t, ErrBatchNotSubmitted.Code, resp.Results[2].Error.Code)
+	assert.Equal(t, ErrBatchNotSubmitted.Code, resp.Results[3].Error.Code)
+
+	// The nonces of the failed and unsubmitted transactions
+	// are released, so the next reservations reuse them.
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(1), nil).Times(3)
+	for expected := uint64(1); expected < 4; expected++ {
+		nonce, err := construction.nonces.Reserve(ctx, mockClient, from)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, nonce)
+	}
+
+	mockClient.AssertExpectations(t)
+}
@@ -54,7 +54,7 @@ func (s *BlockAPIService) Block(
 		return nil, wrapErr(ErrBlockOrphaned, err)
 	}
 	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+		return nil, gwemixErr(err)
 	}
 
 	return &types.BlockResponse{
@@ -73,7 +73,7 @@ func (s *BlockAPIService) BlockTransaction(
 
 	tx, err := s.client.Transaction(ctx, request.BlockIdentifier, request.TransactionIdentifier)
 	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+		return nil, gwemixErr(err)
 	}
 
 	return &types.BlockTransactionResponse{
@@ -17,6 +17,7 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/wemixarchive/rosetta-wemix/configuration"
@@ -24,6 +25,7 @@ import (
 	"github.com/wemixarchive/rosetta-wemix/wemix"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,6 +106,33 @@ func TestBlockService_Online(t *testing.T) {
 		assert.Equal(t, ErrBlockOrphaned.Retriable, err.Retriable)
 	})
 
+	t.Run("unknown block", func(t *testing.T) {
+		pbIdentifier := types.ConstructPartialBlockIdentifier(block.BlockIdentifier)
+		mockClient.On("Block", ctx, pbIdentifier).Return(nil, ethereum.NotFound).Once()
+		b, err := servicer.Block(ctx, &types.BlockRequest{
+			BlockIdentifier: pbIdentifier,
+		})
+
+		assert.Nil(t, b)
+		assert.Equal(t, ErrNotFound.Code, err.Code)
+		assert.False(t, err.Retriable)
+	})
+
+	t.Run("gwemix timeout", func(t *testing.T) {
+		pbIdentifier := types.ConstructPartialBlockIdentifier(block.BlockIdentifier)
+		mockClient.On("Block", ctx, pbIdentifier).Return(
+			nil,
+			fmt.Errorf("%w: block fetch failed", context.DeadlineExceeded),
+		).Once()
+		b, err := servicer.Block(ctx, &types.BlockRequest{
+			BlockIdentifier: pbIdentifier,
+		})
+
+		assert.Nil(t, b)
+		assert.Equal(t, ErrGwemixUnavailable.Code, err.Code)
+		assert.True(t, err.Retriable)
+	})
+
 	mockClient.AssertExpectations(t)
 }
 
@@ -58,7 +58,7 @@ func (s *CallAPIService) Call(
 		return nil, wrapErr(ErrCallMethodInvalid, err)
 	}
 	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+		return nil, gwemixErr(err)
 	}
 
 	return response, nil
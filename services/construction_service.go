@@ -15,28 +15,87 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/big"
-	"strconv"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/wemixarchive/rosetta-wemix/configuration"
 	"github.com/wemixarchive/rosetta-wemix/wemix"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/coinbase/rosetta-sdk-go/parser"
 	"github.com/coinbase/rosetta-sdk-go/types"
 )
 
+const (
+	// Fee priorities accepted in /construction/preprocess metadata.
+	slowPriority     = "slow"
+	standardPriority = "standard"
+	fastPriority     = "fast"
+
+	// feeHistoryBlocks is the number of recent blocks whose
+	// priority fees are averaged to price a fee priority.
+	feeHistoryBlocks = 20
+
+	// Replacement modes accepted in /construction/preprocess metadata.
+	speedUpReplacement = "speed_up"
+	cancelReplacement  = "cancel"
+
+	// replacementPriceBump is the minimum fee increase (in percent)
+	// gwemix requires to replace a pending transaction
+	// (txpool.pricebump).
+	replacementPriceBump = 10
+
+	// ERC-20 methods of token intents other than transfer.
+	approveMethod      = "approve"
+	transferFromMethod = "transfer_from"
+	permitMethod       = "permit"
+
+	// Contract address derivations accepted in
+	// /construction/derive metadata.
+	createDerivation  = "create"
+	create2Derivation = "create2"
+
+	// uint256Bits is the size of the EVM integers
+	// that hold values, token amounts and deadlines.
+	uint256Bits = 256
+)
+
+var (
+	// feeHistoryPercentiles are the eth_feeHistory reward
+	// percentiles, indexed by feePriorities.
+	feeHistoryPercentiles = []float64{10, 50, 90}
+
+	feePriorities = map[string]int{
+		slowPriority:     0,
+		standardPriority: 1,
+		fastPriority:     2,
+	}
+)
+
 // ConstructionAPIService implements the server.ConstructionAPIServicer interface.
 type ConstructionAPIService struct {
 	config *configuration.Configuration
 	client Client
+	nonces *nonceManager // nil unless configuration.NonceManager
+
+	// bundleNonces are the nonces handed out
+	// from the metadata bundle, if any.
+	bundleNonces *bundleNonces
 }
 
 // NewConstructionAPIService creates a new instance of a ConstructionAPIService.
@@ -44,158 +103,1970 @@ func NewConstructionAPIService(
 	cfg *configuration.Configuration,
 	client Client,
 ) *ConstructionAPIService {
-	return &ConstructionAPIService{
+	s := &ConstructionAPIService{
 		config: cfg,
 		client: client,
 	}
+	if cfg.NonceManager {
+		s.nonces = newNonceManager(cfg.NonceReservationTimeout)
+	}
+	if cfg.MetadataBundle != nil {
+		s.bundleNonces = newBundleNonces()
+	}
+
+	return s
 }
 
 // ConstructionDerive implements the /construction/derive endpoint.
+// Unless the metadata asks for a contract address derivation,
+// the address of the public key is returned.
 func (s *ConstructionAPIService) ConstructionDerive(
 	ctx context.Context,
 	request *types.ConstructionDeriveRequest,
 ) (*types.ConstructionDeriveResponse, *types.Error) {
-	var pubkey *ecdsa.PublicKey
-	var err error
-	if len(request.PublicKey.Bytes) != 33 {
-		pubkey, err = crypto.UnmarshalPubkey(request.PublicKey.Bytes)
-	} else {
-		pubkey, err = crypto.DecompressPubkey(request.PublicKey.Bytes)
-	}
+	pubkey, err := parsePublicKey(request.PublicKey.Bytes)
 	if err != nil {
 		return nil, wrapErr(ErrUnableToDecompressPubkey, err)
 	}
 
 	addr := crypto.PubkeyToAddress(*pubkey)
+
+	var input deriveMetadata
+	if err := unmarshalJSONMap(request.Metadata, &input); err != nil {
+		return nil, wrapErr(ErrInvalidInput, err)
+	}
+
+	if len(input.Derivation) == 0 {
+		return &types.ConstructionDeriveResponse{
+			AccountIdentifier: &types.AccountIdentifier{
+				Address: addr.Hex(),
+			},
+		}, nil
+	}
+
+	deployer := addr
+	if len(input.Deployer) > 0 {
+		checkDeployer, ok := wemix.ChecksumAddress(input.Deployer)
+		if !ok {
+			return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", input.Deployer))
+		}
+
+		deployer = common.HexToAddress(checkDeployer)
+	}
+
+	contract, err := contractAddress(&input, deployer)
+	if err != nil {
+		return nil, wrapErr(ErrInvalidInput, err)
+	}
+
 	return &types.ConstructionDeriveResponse{
 		AccountIdentifier: &types.AccountIdentifier{
-			Address: addr.Hex(),
+			Address: contract.Hex(),
+		},
+		Metadata: map[string]interface{}{
+			"derivation": input.Derivation,
+			"deployer":   deployer.Hex(),
+		},
+	}, nil
+}
+
+// contractAddress derives the address of a contract
+// deployed by deployer: from its nonce with CREATE, or from
+// a salt and the hash of the init code with CREATE2.
+func contractAddress(input *deriveMetadata, deployer common.Address) (common.Address, error) {
+	switch input.Derivation {
+	case createDerivation:
+		nonce, err := parseOptionalBig(input.Nonce)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if nonce == nil || !nonce.IsUint64() {
+			return common.Address{}, errors.New("create derivation requires a nonce")
+		}
+
+		return crypto.CreateAddress(deployer, nonce.Uint64()), nil
+	case create2Derivation:
+		salt, err := hexutil.Decode(input.Salt)
+		if err != nil || len(salt) != common.HashLength {
+			return common.Address{}, fmt.Errorf("salt %s is not a 32-byte hex string", input.Salt)
+		}
+
+		var initCodeHash []byte
+		switch {
+		case len(input.InitCodeHash) > 0 && len(input.InitCode) > 0:
+			return common.Address{}, errors.New("init_code_hash and init_code cannot both be provided")
+		case len(input.InitCodeHash) > 0:
+			initCodeHash, err = hexutil.Decode(input.InitCodeHash)
+			if err != nil || len(initCodeHash) != common.HashLength {
+				return common.Address{}, fmt.Errorf("init_code_hash %s is not a 32-byte hex string", input.InitCodeHash)
+			}
+		case len(input.InitCode) > 0:
+			initCode, err := hexutil.Decode(input.InitCode)
+			if err != nil {
+				return common.Address{}, fmt.Errorf("%w: init_code %s is not a hex string", err, input.InitCode)
+			}
+
+			initCodeHash = crypto.Keccak256(initCode)
+		default:
+			return common.Address{}, errors.New("create2 derivation requires init_code_hash or init_code")
+		}
+
+		return crypto.CreateAddress2(deployer, common.BytesToHash(salt), initCodeHash), nil
+	default:
+		return common.Address{}, fmt.Errorf("%s is not a valid derivation", input.Derivation)
+	}
+}
+
+// ConstructionPreprocess implements the /construction/preprocess
+// endpoint.
+func (s *ConstructionAPIService) ConstructionPreprocess(
+	ctx context.Context,
+	request *types.ConstructionPreprocessRequest,
+) (*types.ConstructionPreprocessResponse, *types.Error) {
+	intent, rErr := s.matchOperations(request.Operations)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	var input preprocessMetadata
+	if err := unmarshalJSONMap(request.Metadata, &input); err != nil {
+		return nil, wrapErr(ErrInvalidInput, err)
+	}
+
+	// The full intent is needed to estimate gas.
+	preprocessOutput := &options{
+		From:          intent.From,
+		To:            intent.To,
+		TokenAddress:  intent.TokenAddress,
+		Value:         intent.Value,
+		DynamicFee:    input.DynamicFee,
+		Method:        intent.Method,
+		Owner:         intent.Owner,
+		Spender:       intent.Spender,
+		Deadline:      intent.Deadline,
+		Priority:      input.Priority,
+		FeeMultiplier: input.FeeMultiplier,
+	}
+
+	if intent.Method == permitMethod && (len(input.Nonce) > 0 || len(input.ReplaceHash) > 0) {
+		return nil, wrapErr(ErrInvalidInput, errors.New("permits are signed messages, not transactions"))
+	}
+
+	if input.Safe {
+		if rErr := safeOptions(intent, &input); rErr != nil {
+			return nil, rErr
+		}
+
+		preprocessOutput.Safe = true
+	}
+
+	if err := feeOptions(&input, preprocessOutput); err != nil {
+		return nil, wrapErr(ErrInvalidInput, err)
+	}
+
+	if input.AccessList != nil && input.CreateAccessList {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			errors.New("access_list and create_access_list cannot both be provided"),
+		)
+	}
+	preprocessOutput.AccessList = input.AccessList
+	preprocessOutput.CreateAccessList = input.CreateAccessList
+
+	if intent.Create {
+		data, err := deploymentData(&input)
+		if err != nil {
+			return nil, wrapErr(ErrInvalidInput, err)
+		}
+
+		preprocessOutput.Create = true
+		preprocessOutput.Data = data
+	} else {
+		data, err := contractCallData(&input)
+		if err != nil {
+			return nil, wrapErr(ErrInvalidInput, err)
+		}
+
+		if len(data) > 0 {
+			if len(intent.TokenAddress) > 0 {
+				return nil, wrapErr(ErrUnclearIntent, errors.New("token transfers cannot carry call data"))
+			}
+
+			preprocessOutput.Data = data
+			preprocessOutput.MethodSignature = input.MethodSignature
+			preprocessOutput.MethodArgs = input.MethodArgs
+		}
+	}
+
+	if rErr := replacementOptions(intent, &input, preprocessOutput); rErr != nil {
+		return nil, rErr
+	}
+
+	if len(input.FeePayer) > 0 {
+		feePayer, rErr := feePayerOptions(intent, &input)
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		// Fee-delegated transactions are dynamic-fee
+		// transactions of the sender.
+		preprocessOutput.FeePayer = feePayer
+		preprocessOutput.DynamicFee = true
+	}
+
+	marshaled, err := marshalJSONMap(preprocessOutput)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionPreprocessResponse{
+		Options: marshaled,
+	}, nil
+}
+
+// ConstructionMetadata implements the /construction/metadata endpoint.
+func (s *ConstructionAPIService) ConstructionMetadata(
+	ctx context.Context,
+	request *types.ConstructionMetadataRequest,
+) (*types.ConstructionMetadataResponse, *types.Error) {
+	if s.config.Mode != configuration.Online && s.config.MetadataBundle == nil {
+		return nil, ErrUnavailableOffline
+	}
+
+	var input options
+	if err := unmarshalJSONMap(request.Options, &input); err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	if s.config.Mode != configuration.Online {
+		return s.offlineMetadata(ctx, &input)
+	}
+
+	if input.Method == permitMethod {
+		return s.permitMetadata(ctx, &input)
+	}
+
+	if input.Safe {
+		return s.safeMetadata(ctx, &input)
+	}
+
+	var replaced *ethTypes.Transaction
+	if len(input.ReplaceHash) > 0 {
+		var rErr *types.Error
+		replaced, rErr = s.pendingTransaction(ctx, input.ReplaceHash, input.From)
+		if rErr != nil {
+			return nil, rErr
+		}
+	}
+
+	if input.Value == nil {
+		return nil, wrapErr(
+			ErrUnableToParseIntermediateResult,
+			errors.New("transfer value is missing"),
+		)
+	}
+
+	msg := ethereum.CallMsg{
+		From:  common.HexToAddress(input.From),
+		Value: input.Value,
+		Data:  input.Data,
+	}
+	switch {
+	case len(input.TokenAddress) > 0:
+		// Token intents are calls to the token contract
+		// that carry no native value.
+		tokenAddress := common.HexToAddress(input.TokenAddress)
+		msg.To = &tokenAddress
+		msg.Value = nil
+		msg.Data = tokenCallData(input.Method, input.Owner, input.To, input.Spender, input.Value)
+	case !input.Create:
+		to := common.HexToAddress(input.To)
+		msg.To = &to
+	}
+
+	if replaced != nil && input.ReplaceMode == speedUpReplacement && !sameCall(replaced, &msg) {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			fmt.Errorf("speed-up of %s must keep its intent", input.ReplaceHash),
+		)
+	}
+
+	if input.CreateAccessList {
+		accessList, err := s.client.CreateAccessList(ctx, msg)
+		if err != nil {
+			return nil, callErr(err)
+		}
+
+		input.AccessList = &accessList
+	}
+	if input.AccessList != nil {
+		msg.AccessList = *input.AccessList
+	}
+
+	estimatedGas, err := s.client.EstimateGas(ctx, msg)
+	if err != nil {
+		return nil, gwemixErr(err)
+	}
+
+	metadata := &metadata{
+		GasLimit:        s.gasLimit(estimatedGas),
+		Data:            input.Data,
+		MethodSignature: input.MethodSignature,
+		MethodArgs:      input.MethodArgs,
+		AccessList:      input.AccessList,
+		FeePayer:        input.FeePayer,
+	}
+
+	if rErr := s.suggestFees(ctx, &input, metadata); rErr != nil {
+		return nil, rErr
+	}
+
+	if replaced != nil {
+		bumpFees(metadata, replaced)
+	}
+
+	suggestedFee, rErr := suggestedFee(&input, metadata)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	// The nonce is picked last so that a reserved
+	// nonce is only handed out with valid metadata.
+	nonce, rErr := s.nonce(ctx, &input, replaced)
+	if rErr != nil {
+		return nil, rErr
+	}
+	metadata.Nonce = nonce
+
+	return metadataResponse(metadata, suggestedFee)
+}
+
+// offlineMetadata answers /construction/metadata from the
+// metadata bundle exported by an online instance. Gas cannot
+// be estimated offline, so transactions carrying call data
+// use the gas limit of the bundle.
+func (s *ConstructionAPIService) offlineMetadata(
+	ctx context.Context,
+	input *options,
+) (*types.ConstructionMetadataResponse, *types.Error) {
+	bundle := s.config.MetadataBundle
+	if age := bundle.Age(time.Now()); age > s.config.MetadataBundleMaxAge {
+		return nil, wrapErr(
+			ErrUnavailableOffline,
+			fmt.Errorf("metadata bundle is %s old", age.Round(time.Second)),
+		)
+	}
+
+	if len(input.ReplaceHash) > 0 {
+		return nil, wrapErr(ErrUnavailableOffline, errors.New("replacements need the txpool"))
+	}
+
+	if input.Method == permitMethod {
+		return nil, wrapErr(ErrUnavailableOffline, errors.New("permits need the token nonce"))
+	}
+
+	if input.Safe {
+		return nil, wrapErr(ErrUnavailableOffline, errors.New("safe transactions need the safe owners"))
+	}
+
+	if input.CreateAccessList {
+		return nil, wrapErr(ErrUnavailableOffline, errors.New("access lists need to simulate the call"))
+	}
+
+	if input.Value == nil {
+		return nil, wrapErr(
+			ErrUnableToParseIntermediateResult,
+			errors.New("transfer value is missing"),
+		)
+	}
+
+	account, ok := bundle.Account(common.HexToAddress(input.From))
+	if !ok {
+		return nil, wrapErr(
+			ErrUnavailableOffline,
+			fmt.Errorf("%s is not in the metadata bundle", input.From),
+		)
+	}
+
+	metadata := &metadata{
+		GasLimit:        params.TxGas,
+		Data:            input.Data,
+		MethodSignature: input.MethodSignature,
+		MethodArgs:      input.MethodArgs,
+		FeePayer:        input.FeePayer,
+	}
+	if len(input.TokenAddress) > 0 || len(input.Data) > 0 || input.Create {
+		if account.GasLimit == 0 {
+			return nil, wrapErr(
+				ErrUnavailableOffline,
+				fmt.Errorf("metadata bundle has no gas limit for contract calls from %s", input.From),
+			)
+		}
+
+		metadata.GasLimit = uint64(account.GasLimit)
+	}
+
+	// Each entry of an access list is charged upfront.
+	if input.AccessList != nil {
+		metadata.AccessList = input.AccessList
+		metadata.GasLimit += accessListGas(*input.AccessList)
+	}
+
+	if rErr := s.suggestFees(ctx, input, metadata); rErr != nil {
+		return nil, rErr
+	}
+
+	suggestedFee, rErr := suggestedFee(input, metadata)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	metadata.Nonce = s.bundleNonces.next(account, input.Nonce)
+
+	return metadataResponse(metadata, suggestedFee)
+}
+
+// suggestedFee returns the fee of the transaction described
+// by metadata, checking it against the max_fee of input.
+func suggestedFee(input *options, metadata *metadata) (*big.Int, *types.Error) {
+	feePrice := metadata.GasPrice
+	if metadata.MaxFeePerGas != nil {
+		feePrice = metadata.MaxFeePerGas
+	}
+
+	fee := new(big.Int).Mul(feePrice, new(big.Int).SetUint64(metadata.GasLimit))
+	if input.MaxFee != nil && fee.Cmp(input.MaxFee) > 0 {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			fmt.Errorf("suggested fee %s exceeds max_fee %s", fee, input.MaxFee),
+		)
+	}
+
+	return fee, nil
+}
+
+// metadataResponse builds the /construction/metadata
+// response for metadata.
+func metadataResponse(
+	metadata *metadata,
+	suggestedFee *big.Int,
+) (*types.ConstructionMetadataResponse, *types.Error) {
+	metadataMap, err := marshalJSONMap(metadata)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionMetadataResponse{
+		Metadata: metadataMap,
+		SuggestedFee: []*types.Amount{
+			{
+				Value:    suggestedFee.String(),
+				Currency: wemix.Currency,
+			},
 		},
 	}, nil
 }
 
-// ConstructionPreprocess implements the /construction/preprocess
-// endpoint.
-func (s *ConstructionAPIService) ConstructionPreprocess(
-	ctx context.Context,
-	request *types.ConstructionPreprocessRequest,
-) (*types.ConstructionPreprocessResponse, *types.Error) {
+// ConstructionPayloads implements the /construction/payloads endpoint.
+func (s *ConstructionAPIService) ConstructionPayloads(
+	ctx context.Context,
+	request *types.ConstructionPayloadsRequest,
+) (*types.ConstructionPayloadsResponse, *types.Error) {
+	intent, rErr := s.matchOperations(request.Operations)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	// Convert map to Metadata struct
+	var metadata metadata
+	if err := unmarshalJSONMap(request.Metadata, &metadata); err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	if intent.Method == permitMethod {
+		return permitPayloads(intent, &metadata)
+	}
+
+	if metadata.SafeThreshold > 0 {
+		return safePayloads(intent, &metadata)
+	}
+
+	// Required Fields for constructing a real Wemix transaction
+	toAdd := intent.To
+	amount := intent.Value
+	nonce := metadata.Nonce
+	gasPrice := metadata.GasPrice
+	chainID := s.config.Params.ChainID
+	transferData := []byte{}
+
+	// ERC-20 intents are calls to the token contract
+	// that carry no native value.
+	if len(intent.TokenAddress) > 0 {
+		toAdd = intent.TokenAddress
+		amount = big.NewInt(0)
+		transferData = tokenCallData(intent.Method, intent.Owner, intent.To, intent.Spender, intent.Value)
+	}
+
+	if intent.Create && len(metadata.Data) == 0 {
+		return nil, wrapErr(
+			ErrUnableToParseIntermediateResult,
+			errors.New("contract deployment bytecode is missing"),
+		)
+	}
+
+	// Contract calls and deployments carry the calldata
+	// prepared in /construction/metadata.
+	if len(metadata.Data) > 0 {
+		if len(intent.TokenAddress) > 0 {
+			return nil, wrapErr(ErrUnclearIntent, errors.New("token transfers cannot carry call data"))
+		}
+
+		transferData = metadata.Data
+	}
+
+	// Plain transfers default to the intrinsic gas of a
+	// transfer. Any other transaction needs the gas limit
+	// estimated in /construction/metadata.
+	gasLimit := metadata.GasLimit
+	if gasLimit == 0 {
+		if len(transferData) > 0 || intent.Create {
+			return nil, wrapErr(
+				ErrUnableToParseIntermediateResult,
+				errors.New("gas limit is missing"),
+			)
+		}
+
+		gasLimit = uint64(wemix.TransferGasLimit)
+	}
+
+	unsignedTx := &transaction{
+		From:     intent.From,
+		To:       toAdd,
+		Value:    amount,
+		Data:     transferData,
+		Nonce:    nonce,
+		GasPrice: gasPrice,
+		GasLimit: gasLimit,
+		ChainID:  chainID,
+
+		AccessList: metadata.AccessList,
+
+		MethodSignature: metadata.MethodSignature,
+		MethodArgs:      metadata.MethodArgs,
+	}
+
+	// Dynamic-fee transactions report their fee cap
+	// as the gas price (like *ethTypes.Transaction).
+	if metadata.MaxFeePerGas != nil {
+		unsignedTx.GasPrice = metadata.MaxFeePerGas
+		unsignedTx.MaxFeePerGas = metadata.MaxFeePerGas
+		unsignedTx.MaxPriorityFeePerGas = metadata.MaxPriorityFeePerGas
+	}
+
+	tx := ethTransaction(unsignedTx)
+
+	// Construct SigningPayload
+	signer := ethTypes.NewLondonSigner(chainID)
+	payload := &types.SigningPayload{
+		AccountIdentifier: &types.AccountIdentifier{Address: intent.From},
+		Bytes:             signer.Hash(tx).Bytes(),
+		SignatureType:     types.EcdsaRecovery,
+	}
+
+	// The fee payer of a fee-delegated transaction signs over
+	// the signed transaction of the sender, so its payload is
+	// returned by /construction/combine.
+	if len(metadata.FeePayer) > 0 {
+		if unsignedTx.MaxFeePerGas == nil {
+			return nil, wrapErr(
+				ErrUnableToParseIntermediateResult,
+				errors.New("fee-delegated transactions need a fee cap"),
+			)
+		}
+
+		unsignedTx.FeePayer = metadata.FeePayer
+	}
+
+	unsignedTxJSON, err := json.Marshal(unsignedTx)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionPayloadsResponse{
+		UnsignedTransaction: string(unsignedTxJSON),
+		Payloads:            []*types.SigningPayload{payload},
+	}, nil
+}
+
+// feePayerOptions validates the fee payer requested in
+// /construction/preprocess metadata and returns its checksum
+// address.
+func feePayerOptions(intent *transferIntent, input *preprocessMetadata) (string, *types.Error) {
+	feePayer, ok := wemix.ChecksumAddress(input.FeePayer)
+	if !ok {
+		return "", wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", input.FeePayer))
+	}
+
+	if intent.Method == permitMethod || input.Safe {
+		return "", wrapErr(
+			ErrInvalidInput,
+			errors.New("permits and safe transactions are signed messages, not transactions"),
+		)
+	}
+
+	return feePayer, nil
+}
+
+// feeDelegatedTransaction returns the fee-delegated
+// transaction of tx signed by its sender with senderSig,
+// without the signature of the fee payer.
+func feeDelegatedTransaction(tx *transaction, senderSig []byte) (*wemix.FeeDelegatedTx, *types.Error) {
+	if len(senderSig) != crypto.SignatureLength {
+		return nil, wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("expected %d sender signature bytes but got %d", crypto.SignatureLength, len(senderSig)),
+		)
+	}
+
+	signer := ethTypes.NewLondonSigner(tx.ChainID)
+	senderTx, err := ethTransaction(tx).WithSignature(signer, senderSig)
+	if err != nil {
+		return nil, wrapErr(ErrSignatureInvalid, err)
+	}
+
+	sender, err := ethTypes.Sender(signer, senderTx)
+	if err != nil {
+		return nil, wrapErr(ErrSignatureInvalid, err)
+	}
+	if sender != common.HexToAddress(tx.From) {
+		return nil, wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("sender signature recovers to %s but sender is %s", sender.Hex(), tx.From),
+		)
+	}
+
+	return &wemix.FeeDelegatedTx{
+		SenderTx: senderTx,
+		FeePayer: common.HexToAddress(tx.FeePayer),
+	}, nil
+}
+
+// ConstructionCombine implements the /construction/combine
+// endpoint.
+func (s *ConstructionAPIService) ConstructionCombine(
+	ctx context.Context,
+	request *types.ConstructionCombineRequest,
+) (*types.ConstructionCombineResponse, *types.Error) {
+	if p, ok := decodePermit(request.UnsignedTransaction); ok {
+		return combinePermit(p, request.Signatures)
+	}
+
+	if st, ok := decodeSafeTransaction(request.UnsignedTransaction); ok {
+		return combineSafeTransaction(st, request.Signatures)
+	}
+
+	var unsignedTx transaction
+	if err := json.Unmarshal([]byte(request.UnsignedTransaction), &unsignedTx); err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	if len(unsignedTx.FeePayer) > 0 {
+		return combineFeeDelegated(&unsignedTx, request.Signatures)
+	}
+
+	ethTx := ethTransaction(&unsignedTx)
+
+	signer := ethTypes.NewLondonSigner(unsignedTx.ChainID)
+	if rErr := verifySignatures(unsignedTx.From, signer.Hash(ethTx).Bytes(), request.Signatures); rErr != nil {
+		return nil, rErr
+	}
+
+	signedTx, err := ethTx.WithSignature(signer, request.Signatures[0].Bytes)
+	if err != nil {
+		return nil, wrapErr(ErrSignatureInvalid, err)
+	}
+
+	encodedTx, err := encodeSignedTx(signedTx, s.config.RawSignedTx)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionCombineResponse{
+		SignedTransaction: encodedTx,
+	}, nil
+}
+
+// verifySignatures checks that signatures holds exactly one
+// signature of payload by signer, so a bad signature is
+// rejected before it yields a transaction from another
+// account.
+func verifySignatures(
+	signer string,
+	payload []byte,
+	signatures []*types.Signature,
+) *types.Error {
+	if len(signatures) != 1 {
+		return wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("expected 1 signature but got %d", len(signatures)),
+		)
+	}
+
+	return verifySignature(signer, payload, signatures[0])
+}
+
+// verifySignature checks that sig is a signature
+// of payload by signer.
+func verifySignature(
+	signer string,
+	payload []byte,
+	sig *types.Signature,
+) *types.Error {
+	if sig.SignatureType != types.EcdsaRecovery {
+		return wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("expected signature type %s but got %s", types.EcdsaRecovery, sig.SignatureType),
+		)
+	}
+
+	if sig.SigningPayload == nil || !bytes.Equal(sig.SigningPayload.Bytes, payload) {
+		return wrapErr(ErrSignatureInvalid, errors.New("signing payload does not match transaction"))
+	}
+
+	from := common.HexToAddress(signer)
+	if account := sig.SigningPayload.AccountIdentifier; account != nil &&
+		common.HexToAddress(account.Address) != from {
+		return wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("signing payload is for %s but signer is %s", account.Address, signer),
+		)
+	}
+
+	if len(sig.Bytes) != crypto.SignatureLength {
+		return wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("expected %d signature bytes but got %d", crypto.SignatureLength, len(sig.Bytes)),
+		)
+	}
+
+	pubkey, err := crypto.SigToPub(payload, sig.Bytes)
+	if err != nil {
+		return wrapErr(ErrSignatureInvalid, err)
+	}
+	if recovered := crypto.PubkeyToAddress(*pubkey); recovered != from {
+		return wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("signature recovers to %s but signer is %s", recovered.Hex(), signer),
+		)
+	}
+
+	if sig.PublicKey != nil {
+		if sig.PublicKey.CurveType != types.Secp256k1 {
+			return wrapErr(
+				ErrSignatureInvalid,
+				fmt.Errorf("expected curve type %s but got %s", types.Secp256k1, sig.PublicKey.CurveType),
+			)
+		}
+
+		signerKey, err := parsePublicKey(sig.PublicKey.Bytes)
+		if err != nil {
+			return wrapErr(ErrSignatureInvalid, err)
+		}
+		if signerAddress := crypto.PubkeyToAddress(*signerKey); signerAddress != from {
+			return wrapErr(
+				ErrSignatureInvalid,
+				fmt.Errorf("public key is for %s but signer is %s", signerAddress.Hex(), signer),
+			)
+		}
+	}
+
+	return nil
+}
+
+// parsePublicKey parses a compressed or uncompressed
+// secp256k1 public key.
+func parsePublicKey(b []byte) (*ecdsa.PublicKey, error) {
+	if len(b) != 33 {
+		return crypto.UnmarshalPubkey(b)
+	}
+
+	return crypto.DecompressPubkey(b)
+}
+
+// combineFeeDelegated signs a fee-delegated transaction in
+// two steps, as its fee payer signs over the signed transaction
+// of the sender. Combined with the sender signature, unsignedTx
+// is returned with the payload of the fee payer. Combined again
+// with the fee payer signature, it is returned as raw bytes, as
+// the go-ethereum JSON form cannot hold the fee payer.
+func combineFeeDelegated(
+	unsignedTx *transaction,
+	signatures []*types.Signature,
+) (*types.ConstructionCombineResponse, *types.Error) {
+	if len(unsignedTx.SenderSignature) == 0 {
+		signer := ethTypes.NewLondonSigner(unsignedTx.ChainID)
+		payload := signer.Hash(ethTransaction(unsignedTx)).Bytes()
+		if rErr := verifySignatures(unsignedTx.From, payload, signatures); rErr != nil {
+			return nil, rErr
+		}
+
+		feeDelegatedTx, rErr := feeDelegatedTransaction(unsignedTx, signatures[0].Bytes)
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		hash, err := feeDelegatedTx.FeePayerHash()
+		if err != nil {
+			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+		}
+
+		unsignedTx.SenderSignature = signatures[0].Bytes
+		unsignedTx.FeePayerPayload = &types.SigningPayload{
+			AccountIdentifier: &types.AccountIdentifier{Address: unsignedTx.FeePayer},
+			Bytes:             hash.Bytes(),
+			SignatureType:     types.EcdsaRecovery,
+		}
+
+		unsignedTxJSON, err := json.Marshal(unsignedTx)
+		if err != nil {
+			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+		}
+
+		return &types.ConstructionCombineResponse{
+			SignedTransaction: string(unsignedTxJSON),
+		}, nil
+	}
+
+	feeDelegatedTx, rErr := feeDelegatedTransaction(unsignedTx, unsignedTx.SenderSignature)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	hash, err := feeDelegatedTx.FeePayerHash()
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	if rErr := verifySignatures(unsignedTx.FeePayer, hash.Bytes(), signatures); rErr != nil {
+		return nil, rErr
+	}
+
+	sig := signatures[0].Bytes
+	feeDelegatedTx.FR = new(big.Int).SetBytes(sig[:32])
+	feeDelegatedTx.FS = new(big.Int).SetBytes(sig[32:64])
+	feeDelegatedTx.FV = new(big.Int).SetUint64(uint64(sig[64]))
+
+	raw, err := feeDelegatedTx.MarshalBinary()
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionCombineResponse{
+		SignedTransaction: hexutil.Encode(raw),
+	}, nil
+}
+
+// ConstructionHash implements the /construction/hash endpoint.
+func (s *ConstructionAPIService) ConstructionHash(
+	ctx context.Context,
+	request *types.ConstructionHashRequest,
+) (*types.TransactionIdentifierResponse, *types.Error) {
+	// Permits are identified by their typed-data hash.
+	if p, ok := decodePermit(request.SignedTransaction); ok {
+		return &types.TransactionIdentifierResponse{
+			TransactionIdentifier: &types.TransactionIdentifier{
+				Hash: p.hash().Hex(),
+			},
+		}, nil
+	}
+
+	// Safe transactions are identified by their Safe
+	// transaction hash.
+	if st, ok := decodeSafeTransaction(request.SignedTransaction); ok {
+		return &types.TransactionIdentifierResponse{
+			TransactionIdentifier: &types.TransactionIdentifier{
+				Hash: st.hash().Hex(),
+			},
+		}, nil
+	}
+
+	feeDelegatedTx, err := decodeFeeDelegatedTx(request.SignedTransaction)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+	if feeDelegatedTx != nil {
+		hash, err := feeDelegatedTx.Hash()
+		if err != nil {
+			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+		}
+
+		return &types.TransactionIdentifierResponse{
+			TransactionIdentifier: &types.TransactionIdentifier{
+				Hash: hash.Hex(),
+			},
+		}, nil
+	}
+
+	signedTx, err := decodeSignedTx(request.SignedTransaction)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	hash := signedTx.Hash().Hex()
+
+	return &types.TransactionIdentifierResponse{
+		TransactionIdentifier: &types.TransactionIdentifier{
+			Hash: hash,
+		},
+	}, nil
+}
+
+// ConstructionParse implements the /construction/parse endpoint.
+// Signed transactions may be legacy, access-list, dynamic-fee or
+// fee-delegated envelopes.
+func (s *ConstructionAPIService) ConstructionParse(
+	ctx context.Context,
+	request *types.ConstructionParseRequest,
+) (*types.ConstructionParseResponse, *types.Error) {
+	if p, ok := decodePermit(request.Transaction); ok {
+		return s.parsePermit(p, request.Signed)
+	}
+
+	if st, ok := decodeSafeTransaction(request.Transaction); ok {
+		return s.parseSafeTransaction(st, request.Signed)
+	}
+
+	var tx transaction
+	var txType uint8
+	var feePayer string
+	if !request.Signed {
+		err := json.Unmarshal([]byte(request.Transaction), &tx)
+		if err != nil {
+			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+		}
+
+		txType = ethTransaction(&tx).Type()
+		if len(tx.FeePayer) > 0 {
+			txType = wemix.FeeDelegateDynamicFeeTxType
+			feePayer = tx.FeePayer
+		}
+	} else {
+		t, payer, rErr := decodeParsedTx(request.Transaction)
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		tx = *t
+		txType = ethTypes.LegacyTxType
+		switch {
+		case payer != nil:
+			txType = wemix.FeeDelegateDynamicFeeTxType
+			feePayer = payer.Hex()
+		case tx.MaxFeePerGas != nil:
+			txType = ethTypes.DynamicFeeTxType
+		case tx.AccessList != nil:
+			txType = ethTypes.AccessListTxType
+		}
+	}
+
+	// Ensure valid from address
+	checkFrom, ok := wemix.ChecksumAddress(tx.From)
+	if !ok {
+		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", tx.From))
+	}
+
+	metadata := &parseMetadata{
+		Type:                 txType,
+		Nonce:                tx.Nonce,
+		GasPrice:             tx.GasPrice,
+		ChainID:              tx.ChainID,
+		MaxFeePerGas:         tx.MaxFeePerGas,
+		MaxPriorityFeePerGas: tx.MaxPriorityFeePerGas,
+		AccessList:           tx.AccessList,
+		FeePayer:             feePayer,
+		MethodSignature:      tx.MethodSignature,
+		MethodArgs:           tx.MethodArgs,
+	}
+
+	ops, rErr := s.parseOps(checkFrom, &tx, metadata)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	metaMap, err := marshalJSONMap(metadata)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	signers := []*types.AccountIdentifier{}
+	if request.Signed {
+		signers = append(signers, &types.AccountIdentifier{Address: checkFrom})
+		if len(feePayer) > 0 {
+			signers = append(signers, &types.AccountIdentifier{Address: feePayer})
+		}
+	}
+
+	return &types.ConstructionParseResponse{
+		Operations:               ops,
+		AccountIdentifierSigners: signers,
+		Metadata:                 metaMap,
+	}, nil
+}
+
+// parseOps returns the operations of tx, sent by from: a
+// CREATE operation for deployments, token operations for
+// ERC-20 calls on a configured token and a pair of CALL
+// operations otherwise (the amount is zero for contract calls
+// that carry no value). The method id of calls and the address
+// of deployed contracts are added to metadata.
+func (s *ConstructionAPIService) parseOps(
+	from string,
+	tx *transaction,
+	metadata *parseMetadata,
+) ([]*types.Operation, *types.Error) {
+	if len(tx.To) == 0 {
+		// Deployments create a contract at an address
+		// derived from the sender and nonce.
+		metadata.ContractAddress = crypto.CreateAddress(common.HexToAddress(from), tx.Nonce).Hex()
+		return createOps(from, tx.Value), nil
+	}
+
+	// Ensure valid to address
+	checkTo, ok := wemix.ChecksumAddress(tx.To)
+	if !ok {
+		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", tx.To))
+	}
+
+	if len(tx.Data) >= 4 { // nolint:gomnd
+		metadata.MethodID = tx.Data[:4]
+	}
+
+	// Calls of ERC-20 methods on a configured token
+	// contract are represented as token operations.
+	if token, ok := s.config.Token(checkTo); ok && tx.Value.Sign() == 0 {
+		if tokenOps := tokenOps(from, token, tx.Data); tokenOps != nil {
+			return tokenOps, nil
+		}
+	}
+
+	return transferOps(from, checkTo, tx.Value, wemix.Currency), nil
+}
+
+// decodeParsedTx decodes a signed transaction into the
+// fields of an unsigned transaction, recovering its sender.
+// Fee-delegated transactions also return their fee payer,
+// whose signature is verified.
+func decodeParsedTx(signedTx string) (*transaction, *common.Address, *types.Error) {
+	feeDelegatedTx, err := decodeFeeDelegatedTx(signedTx)
+	if err != nil {
+		return nil, nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	var t *ethTypes.Transaction
+	var feePayer *common.Address
+	if feeDelegatedTx != nil {
+		if err := feeDelegatedTx.VerifyFeePayer(); err != nil {
+			return nil, nil, wrapErr(ErrSignatureInvalid, err)
+		}
+
+		t = feeDelegatedTx.SenderTx
+		feePayer = &feeDelegatedTx.FeePayer
+	} else {
+		t, err = decodeSignedTx(signedTx)
+		if err != nil {
+			return nil, nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+		}
+	}
+
+	sender, err := ethTypes.Sender(ethTypes.NewLondonSigner(t.ChainId()), t)
+	if err != nil {
+		return nil, nil, wrapErr(ErrSignatureInvalid, err)
+	}
+
+	tx := &transaction{
+		From:     sender.Hex(),
+		Value:    t.Value(),
+		Data:     t.Data(),
+		Nonce:    t.Nonce(),
+		GasPrice: t.GasPrice(),
+		GasLimit: t.Gas(),
+		ChainID:  t.ChainId(),
+	}
+	if t.To() != nil {
+		tx.To = t.To().String()
+	}
+	if t.Type() == ethTypes.DynamicFeeTxType {
+		tx.MaxFeePerGas = t.GasFeeCap()
+		tx.MaxPriorityFeePerGas = t.GasTipCap()
+	}
+	if accessList := t.AccessList(); t.Type() == ethTypes.AccessListTxType || len(accessList) > 0 {
+		tx.AccessList = &accessList
+	}
+
+	return tx, feePayer, nil
+}
+
+// ConstructionSubmit implements the /construction/submit endpoint.
+func (s *ConstructionAPIService) ConstructionSubmit(
+	ctx context.Context,
+	request *types.ConstructionSubmitRequest,
+) (*types.TransactionIdentifierResponse, *types.Error) {
+	if s.config.Mode != configuration.Online {
+		return nil, ErrUnavailableOffline
+	}
+
+	if _, ok := decodePermit(request.SignedTransaction); ok {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			errors.New("permits are submitted by the spender as a call with their call_data"),
+		)
+	}
+
+	if _, ok := decodeSafeTransaction(request.SignedTransaction); ok {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			errors.New("safe transactions are submitted as a call to the safe with their call_data"),
+		)
+	}
+
+	// Fee-delegated transactions are sent as raw bytes, as
+	// *ethTypes.Transaction cannot hold their fee payer.
+	feeDelegatedTx, err := decodeFeeDelegatedTx(request.SignedTransaction)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	var signedTx *ethTypes.Transaction
+	var feePayer *common.Address
+	if feeDelegatedTx != nil {
+		if err := feeDelegatedTx.VerifyFeePayer(); err != nil {
+			return nil, wrapErr(ErrSignatureInvalid, err)
+		}
+
+		signedTx = feeDelegatedTx.SenderTx
+		feePayer = &feeDelegatedTx.FeePayer
+	} else {
+		signedTx, err = decodeSignedTx(request.SignedTransaction)
+		if err != nil {
+			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+		}
+	}
+
+	sender, senderErr := ethTypes.Sender(ethTypes.NewLondonSigner(signedTx.ChainId()), signedTx)
+	if s.config.SubmitPreflight {
+		if senderErr != nil {
+			return nil, wrapErr(ErrSignatureInvalid, senderErr)
+		}
+
+		if rErr := s.preflight(ctx, signedTx, sender, feePayer); rErr != nil {
+			if s.nonces != nil {
+				s.nonces.Release(sender, signedTx.Nonce())
+			}
+			return nil, rErr
+		}
+	}
+
+	hash := signedTx.Hash()
+	if feeDelegatedTx != nil {
+		raw, rawErr := feeDelegatedTx.MarshalBinary()
+		if rawErr != nil {
+			return nil, wrapErr(ErrUnableToParseIntermediateResult, rawErr)
+		}
+
+		hash = crypto.Keccak256Hash(raw)
+		err = s.client.SendRawTransaction(ctx, raw)
+	} else {
+		err = s.client.SendTransaction(ctx, signedTx)
+	}
+	if err != nil {
+		if s.nonces != nil && senderErr == nil {
+			s.nonces.Release(sender, signedTx.Nonce())
+		}
+		return nil, broadcastErr(err)
+	}
+
+	if s.nonces != nil && senderErr == nil {
+		s.nonces.Submitted(sender, signedTx.Nonce())
+	}
+
+	txIdentifier := &types.TransactionIdentifier{
+		Hash: hash.Hex(),
+	}
+	return &types.TransactionIdentifierResponse{
+		TransactionIdentifier: txIdentifier,
+	}, nil
+}
+
+// encodeSignedTx encodes tx as the go-ethereum JSON form or,
+// when raw is set, as 0x-prefixed raw transaction bytes.
+func encodeSignedTx(tx *ethTypes.Transaction, raw bool) (string, error) {
+	if raw {
+		b, err := tx.MarshalBinary()
+		if err != nil {
+			return "", err
+		}
+
+		return hexutil.Encode(b), nil
+	}
+
+	b, err := tx.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// decodeSignedTx decodes a signed transaction encoded either
+// as the go-ethereum JSON form or as 0x-prefixed raw transaction
+// bytes (legacy RLP or a typed envelope).
+func decodeSignedTx(signedTx string) (*ethTypes.Transaction, error) {
+	signedTx = strings.TrimSpace(signedTx)
+	tx := new(ethTypes.Transaction)
+	if !strings.HasPrefix(signedTx, "0x") && !strings.HasPrefix(signedTx, "0X") {
+		if err := tx.UnmarshalJSON([]byte(signedTx)); err != nil {
+			return nil, err
+		}
+
+		return tx, nil
+	}
+
+	b, err := hexutil.Decode(signedTx)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.UnmarshalBinary(b); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// decodeFeeDelegatedTx decodes signedTx if it holds the
+// 0x-prefixed raw bytes of a fee-delegated transaction, and
+// returns nil otherwise.
+func decodeFeeDelegatedTx(signedTx string) (*wemix.FeeDelegatedTx, error) {
+	signedTx = strings.TrimSpace(signedTx)
+	if !strings.HasPrefix(signedTx, "0x") && !strings.HasPrefix(signedTx, "0X") {
+		return nil, nil
+	}
+
+	b, err := hexutil.Decode(signedTx)
+	if err != nil || len(b) == 0 || b[0] != wemix.FeeDelegateDynamicFeeTxType {
+		return nil, nil
+	}
+
+	return wemix.DecodeFeeDelegatedTx(b)
+}
+
+// ethTransaction converts an unsigned transaction into an
+// *ethTypes.Transaction. Transactions with a fee cap are
+// dynamic-fee (EIP-1559) transactions, other transactions
+// with an access list are access-list (EIP-2930) transactions
+// and transactions without a recipient are contract
+// deployments.
+func ethTransaction(tx *transaction) *ethTypes.Transaction {
+	var to *common.Address
+	if len(tx.To) > 0 {
+		addr := common.HexToAddress(tx.To)
+		to = &addr
+	}
+
+	var accessList ethTypes.AccessList
+	if tx.AccessList != nil {
+		accessList = *tx.AccessList
+	}
+
+	if tx.MaxFeePerGas != nil {
+		return ethTypes.NewTx(&ethTypes.DynamicFeeTx{
+			ChainID:    tx.ChainID,
+			Nonce:      tx.Nonce,
+			GasTipCap:  tx.MaxPriorityFeePerGas,
+			GasFeeCap:  tx.MaxFeePerGas,
+			Gas:        tx.GasLimit,
+			To:         to,
+			Value:      tx.Value,
+			Data:       tx.Data,
+			AccessList: accessList,
+		})
+	}
+
+	if tx.AccessList != nil {
+		return ethTypes.NewTx(&ethTypes.AccessListTx{
+			ChainID:    tx.ChainID,
+			Nonce:      tx.Nonce,
+			GasPrice:   tx.GasPrice,
+			Gas:        tx.GasLimit,
+			To:         to,
+			Value:      tx.Value,
+			Data:       tx.Data,
+			AccessList: accessList,
+		})
+	}
+
+	return ethTypes.NewTx(&ethTypes.LegacyTx{
+		Nonce:    tx.Nonce,
+		GasPrice: tx.GasPrice,
+		Gas:      tx.GasLimit,
+		To:       to,
+		Value:    tx.Value,
+		Data:     tx.Data,
+	})
+}
+
+// feeOptions validates the fee settings requested in
+// /construction/preprocess metadata and copies the amounts
+// into options.
+func feeOptions(input *preprocessMetadata, output *options) error {
+	if len(input.Priority) > 0 {
+		if _, ok := feePriorities[input.Priority]; !ok {
+			return fmt.Errorf("%s is not a valid priority", input.Priority)
+		}
+	}
+
+	if input.FeeMultiplier < 0 {
+		return fmt.Errorf("fee_multiplier %f must be positive", input.FeeMultiplier)
+	}
+
+	gasPrice, err := parseOptionalBig(input.GasPrice)
+	if err != nil {
+		return err
+	}
+
+	if gasPrice != nil && (len(input.Priority) > 0 || input.FeeMultiplier > 0) {
+		return errors.New("gas_price cannot be combined with priority or fee_multiplier")
+	}
+
+	maxFee, err := parseOptionalBig(input.MaxFee)
+	if err != nil {
+		return err
+	}
+
+	output.GasPrice = gasPrice
+	output.MaxFee = maxFee
+	return nil
+}
+
+// suggestFees sets the gas price, and the fee caps of
+// dynamic-fee transactions, of metadata. Unless a gas price
+// is provided, fees are priced from eth_feeHistory for a
+// fee priority or from eth_gasPrice otherwise.
+func (s *ConstructionAPIService) suggestFees(
+	ctx context.Context,
+	input *options,
+	metadata *metadata,
+) *types.Error {
+	if input.GasPrice != nil && !input.DynamicFee {
+		metadata.GasPrice = input.GasPrice
+		return nil
+	}
+
+	var baseFee, gasTipCap *big.Int
+	if len(input.Priority) > 0 {
+		if s.config.Mode != configuration.Online {
+			return wrapErr(ErrUnavailableOffline, errors.New("fee priorities need the fee history"))
+		}
+
+		history, err := s.client.FeeHistory(ctx, feeHistoryBlocks, nil, feeHistoryPercentiles)
+		if err != nil {
+			return gwemixErr(err)
+		}
+
+		baseFee, gasTipCap = feeHistoryFees(history, feePriorities[input.Priority])
+	} else {
+		var gasPrice *big.Int
+		var rErr *types.Error
+		gasPrice, gasTipCap, rErr = s.gasPrice(ctx, input.DynamicFee)
+		if rErr != nil {
+			return rErr
+		}
+
+		// eth_gasPrice is the current base fee plus the suggested tip.
+		baseFee = new(big.Int).Sub(gasPrice, gasTipCap)
+		if baseFee.Sign() < 0 {
+			baseFee = big.NewInt(0)
+		}
+	}
+
+	if input.FeeMultiplier > 0 {
+		baseFee = scaleBig(baseFee, input.FeeMultiplier)
+		gasTipCap = scaleBig(gasTipCap, input.FeeMultiplier)
+	}
+
+	metadata.GasPrice = new(big.Int).Add(baseFee, gasTipCap)
+	if !input.DynamicFee {
+		return nil
+	}
+
+	// Leave room for the base fee to double before inclusion.
+	metadata.MaxPriorityFeePerGas = gasTipCap
+	metadata.MaxFeePerGas = new(big.Int).Add(gasTipCap, new(big.Int).Lsh(baseFee, 1))
+	if input.GasPrice != nil {
+		metadata.GasPrice = input.GasPrice
+		metadata.MaxFeePerGas = input.GasPrice
+		if gasTipCap.Cmp(input.GasPrice) > 0 {
+			metadata.MaxPriorityFeePerGas = input.GasPrice
+		}
+	}
+
+	return nil
+}
+
+// gasPrice returns the suggested gas price and, for dynamic-fee
+// transactions, the suggested tip from gwemix or, offline, from
+// the metadata bundle.
+func (s *ConstructionAPIService) gasPrice(
+	ctx context.Context,
+	dynamicFee bool,
+) (*big.Int, *big.Int, *types.Error) {
+	gasTipCap := big.NewInt(0)
+	if s.config.Mode != configuration.Online {
+		bundle := s.config.MetadataBundle
+		if dynamicFee {
+			gasTipCap = bundle.GasTipCap.ToInt()
+		}
+
+		return bundle.GasPrice.ToInt(), gasTipCap, nil
+	}
+
+	gasPrice, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, nil, gwemixErr(err)
+	}
+
+	if dynamicFee {
+		gasTipCap, err = s.client.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, nil, gwemixErr(err)
+		}
+	}
+
+	return gasPrice, gasTipCap, nil
+}
+
+// preflight checks that tx, sent by sender, can be included:
+// it must be for the configured chain, use an unused nonce,
+// be affordable by sender and its fee payer, if any, and not
+// revert when simulated.
+func (s *ConstructionAPIService) preflight(
+	ctx context.Context,
+	tx *ethTypes.Transaction,
+	sender common.Address,
+	feePayer *common.Address,
+) *types.Error {
+	if tx.ChainId().Cmp(s.config.Params.ChainID) != 0 {
+		return wrapErr(
+			ErrInvalidChainID,
+			fmt.Errorf("chain ID %s does not match %s", tx.ChainId(), s.config.Params.ChainID),
+		)
+	}
+
+	// Nonces above the pending nonce may belong to
+	// transactions constructed concurrently.
+	pendingNonce, err := s.client.PendingNonceAt(ctx, sender)
+	if err != nil {
+		return gwemixErr(err)
+	}
+	if tx.Nonce() < pendingNonce {
+		return wrapErr(
+			ErrNonceTooLow,
+			fmt.Errorf("nonce %d is below the pending nonce %d", tx.Nonce(), pendingNonce),
+		)
+	}
+
+	// The fee payer of a fee-delegated transaction pays its
+	// fee, and the sender only its value.
+	if feePayer == nil {
+		if rErr := s.checkBalance(ctx, sender, tx.Cost()); rErr != nil {
+			return rErr
+		}
+	} else {
+		fee := new(big.Int).Mul(tx.GasFeeCap(), new(big.Int).SetUint64(tx.Gas()))
+		if rErr := s.checkBalance(ctx, *feePayer, fee); rErr != nil {
+			return rErr
+		}
+		if rErr := s.checkBalance(ctx, sender, tx.Value()); rErr != nil {
+			return rErr
+		}
+	}
+
+	_, err = s.client.PendingCallContract(ctx, ethereum.CallMsg{
+		From:       sender,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	})
+	if err != nil {
+		return callErr(err)
+	}
+
+	return nil
+}
+
+// checkBalance checks that account holds at least
+// cost in the pending state.
+func (s *ConstructionAPIService) checkBalance(
+	ctx context.Context,
+	account common.Address,
+	cost *big.Int,
+) *types.Error {
+	balance, err := s.client.PendingBalanceAt(ctx, account)
+	if err != nil {
+		return gwemixErr(err)
+	}
+	if balance.Cmp(cost) < 0 {
+		return wrapErr(
+			ErrInsufficientFunds,
+			fmt.Errorf("balance %s of %s is below the cost %s", balance, account.Hex(), cost),
+		)
+	}
+
+	return nil
+}
+
+// nonce returns the nonce of the transaction to construct:
+// the nonce of the transaction it replaces, the pinned nonce,
+// or the next nonce of the sender.
+func (s *ConstructionAPIService) nonce(
+	ctx context.Context,
+	input *options,
+	replaced *ethTypes.Transaction,
+) (uint64, *types.Error) {
+	from := common.HexToAddress(input.From)
+	switch {
+	case replaced != nil:
+		return replaced.Nonce(), nil
+	case input.Nonce != nil:
+		return *input.Nonce, nil
+	case s.nonces != nil:
+		nonce, err := s.nonces.Reserve(ctx, s.client, from)
+		if err != nil {
+			return 0, gwemixErr(err)
+		}
+
+		return nonce, nil
+	}
+
+	nonce, err := s.client.PendingNonceAt(ctx, from)
+	if err != nil {
+		return 0, gwemixErr(err)
+	}
+
+	return nonce, nil
+}
+
+// replacementOptions validates the nonce and replacement
+// settings requested in /construction/preprocess metadata
+// and copies them into options.
+func replacementOptions(
+	intent *transferIntent,
+	input *preprocessMetadata,
+	output *options,
+) *types.Error {
+	nonce, err := parseOptionalBig(input.Nonce)
+	if err != nil {
+		return wrapErr(ErrInvalidInput, err)
+	}
+
+	if nonce != nil {
+		if !nonce.IsUint64() {
+			return wrapErr(ErrInvalidInput, fmt.Errorf("nonce %s is too large", nonce))
+		}
+
+		n := nonce.Uint64()
+		output.Nonce = &n
+	}
+
+	if len(input.ReplaceHash) == 0 {
+		if len(input.ReplaceMode) > 0 {
+			return wrapErr(ErrInvalidInput, errors.New("replace_mode requires replace_hash"))
+		}
+
+		return nil
+	}
+
+	if nonce != nil {
+		return wrapErr(ErrInvalidInput, errors.New("nonce cannot be combined with replace_hash"))
+	}
+
+	hash, err := hexutil.Decode(input.ReplaceHash)
+	if err != nil || len(hash) != common.HashLength {
+		return wrapErr(ErrInvalidInput, fmt.Errorf("%s is not a valid transaction hash", input.ReplaceHash))
+	}
+
+	mode := input.ReplaceMode
+	switch mode {
+	case "":
+		mode = speedUpReplacement
+	case speedUpReplacement:
+	case cancelReplacement:
+		if intent.Create ||
+			len(intent.TokenAddress) > 0 ||
+			intent.From != intent.To ||
+			intent.Value.Sign() != 0 ||
+			len(output.Data) > 0 {
+			return wrapErr(
+				ErrUnclearIntent,
+				errors.New("cancellations must be zero-value self-transfers"),
+			)
+		}
+	default:
+		return wrapErr(ErrInvalidInput, fmt.Errorf("%s is not a valid replace_mode", mode))
+	}
+
+	output.ReplaceHash = common.BytesToHash(hash).Hex()
+	output.ReplaceMode = mode
+	return nil
+}
+
+// pendingTransaction returns the transaction with hash
+// from the txpool, ensuring it was sent by from.
+func (s *ConstructionAPIService) pendingTransaction(
+	ctx context.Context,
+	hash string,
+	from string,
+) (*ethTypes.Transaction, *types.Error) {
+	tx, sender, err := s.client.PendingTransaction(ctx, common.HexToHash(hash))
+	if errors.Is(err, wemix.ErrTransactionNotPending) || errors.Is(err, ethereum.NotFound) {
+		return nil, wrapErr(ErrTransactionNotPending, err)
+	}
+	if err != nil {
+		return nil, gwemixErr(err)
+	}
+
+	if sender != common.HexToAddress(from) {
+		return nil, wrapErr(ErrInvalidInput, fmt.Errorf("%s was not sent by %s", hash, from))
+	}
+
+	return tx, nil
+}
+
+// sameCall returns whether tx makes the call described by msg.
+func sameCall(tx *ethTypes.Transaction, msg *ethereum.CallMsg) bool {
+	if (tx.To() == nil) != (msg.To == nil) {
+		return false
+	}
+	if tx.To() != nil && *tx.To() != *msg.To {
+		return false
+	}
+
+	value := msg.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	return tx.Value().Cmp(value) == 0 && bytes.Equal(tx.Data(), msg.Data)
+}
+
+// bumpFees raises the fees of metadata to at least the
+// minimum gwemix requires to replace the pending transaction
+// replaced: every fee must grow by replacementPriceBump percent.
+func bumpFees(metadata *metadata, replaced *ethTypes.Transaction) {
+	metadata.GasPrice = maxBig(metadata.GasPrice, bumpPrice(replaced.GasFeeCap()))
+	if metadata.MaxFeePerGas == nil {
+		return
+	}
+
+	metadata.MaxFeePerGas = maxBig(metadata.MaxFeePerGas, bumpPrice(replaced.GasFeeCap()))
+	metadata.MaxPriorityFeePerGas = maxBig(
+		metadata.MaxPriorityFeePerGas,
+		bumpPrice(replaced.GasTipCap()),
+	)
+}
+
+// bumpPrice returns the smallest price that replaces
+// a pending transaction paying price.
+func bumpPrice(price *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(price, big.NewInt(100+replacementPriceBump))
+	bumped.Div(bumped, big.NewInt(100)) // nolint:gomnd
+
+	return maxBig(bumped, new(big.Int).Add(price, big.NewInt(1)))
+}
+
+// maxBig returns the larger of a and b.
+func maxBig(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+
+	return b
+}
+
+// feeHistoryFees returns the next block's base fee and the
+// average priority fee paid at the percentile index of
+// feeHistoryPercentiles.
+func feeHistoryFees(history *wemix.FeeHistory, index int) (*big.Int, *big.Int) {
+	baseFee := big.NewInt(0)
+	if len(history.BaseFee) > 0 {
+		baseFee = history.BaseFee[len(history.BaseFee)-1]
+	}
+
+	gasTipCap := big.NewInt(0)
+	count := int64(0)
+	for _, reward := range history.Reward {
+		if len(reward) <= index || reward[index] == nil {
+			continue
+		}
+
+		gasTipCap.Add(gasTipCap, reward[index])
+		count++
+	}
+	if count > 0 {
+		gasTipCap.Div(gasTipCap, big.NewInt(count))
+	}
+
+	return baseFee, gasTipCap
+}
+
+// accessListGas returns the intrinsic gas charged
+// for accessList (EIP-2930).
+func accessListGas(accessList ethTypes.AccessList) uint64 {
+	gas := uint64(len(accessList)) * params.TxAccessListAddressGas
+	gas += uint64(accessList.StorageKeys()) * params.TxAccessListStorageKeyGas
+	return gas
+}
+
+// scaleBig multiplies i by f, rounding towards zero.
+func scaleBig(i *big.Int, f float64) *big.Int {
+	scaled, _ := new(big.Float).Mul(new(big.Float).SetInt(i), big.NewFloat(f)).Int(nil)
+	return scaled
+}
+
+// gasLimit applies the configured safety multiplier
+// to an eth_estimateGas result.
+func (s *ConstructionAPIService) gasLimit(estimatedGas uint64) uint64 {
+	if s.config.GasLimitMultiplier <= 1 {
+		return estimatedGas
+	}
+
+	return uint64(math.Ceil(float64(estimatedGas) * s.config.GasLimitMultiplier))
+}
+
+// contractCallData returns the calldata requested in
+// /construction/preprocess metadata, either provided raw
+// or encoded from a method signature and its arguments.
+func contractCallData(input *preprocessMetadata) ([]byte, error) {
+	if len(input.Bytecode) > 0 {
+		return nil, errors.New("bytecode is only supported for CREATE operations")
+	}
+
+	if len(input.MethodSignature) > 0 {
+		if len(input.Data) > 0 {
+			return nil, errors.New("data and method_signature cannot both be provided")
+		}
+
+		return wemix.ContractCallData(input.MethodSignature, input.MethodArgs)
+	}
+
+	if len(input.Data) > 0 {
+		return hexutil.Decode(input.Data)
+	}
+
+	return nil, nil
+}
+
+// deploymentData returns the contract creation code requested
+// in /construction/preprocess metadata: the bytecode followed
+// by the ABI-encoded constructor arguments.
+func deploymentData(input *preprocessMetadata) ([]byte, error) {
+	if len(input.Data) > 0 || len(input.MethodSignature) > 0 {
+		return nil, errors.New("contract deployments cannot carry call data")
+	}
+
+	if len(input.Bytecode) == 0 {
+		return nil, errors.New("contract deployments require bytecode")
+	}
+
+	bytecode, err := hexutil.Decode(input.Bytecode)
+	if err != nil {
+		return nil, err
+	}
+
+	return wemix.ConstructorCallData(bytecode, input.ConstructorTypes, input.ConstructorArgs)
+}
+
+// transferIntent is a transfer of WEMIX or of a configured
+// ERC-20 token, an ERC-20 allowance, or a contract deployment,
+// matched from *types.Operations.
+type transferIntent struct {
+	From         string // the signer
+	To           string // empty for contract deployments and allowances
+	Value        *big.Int
+	Currency     *types.Currency
+	TokenAddress string // empty for WEMIX transfers
+	Create       bool
+
+	// Method is the ERC-20 method of token intents,
+	// empty for transfer.
+	Method   string
+	Owner    string   // transfer_from: the account debited
+	Spender  string   // approve and permit
+	Deadline *big.Int // permit
+}
+
+// matchOperations matches either a contract deployment
+// (a single CREATE operation), an allowance (a single
+// APPROVE or PERMIT operation) or a transfer.
+func (s *ConstructionAPIService) matchOperations(
+	operations []*types.Operation,
+) (*transferIntent, *types.Error) {
+	if len(operations) == 1 {
+		switch operations[0].Type {
+		case wemix.CreateOpType:
+			return matchCreateOperation(operations)
+		case wemix.ApproveOpType, wemix.PermitOpType:
+			return s.matchAllowanceOperation(operations)
+		}
+	}
+
+	return s.matchTransferOperations(operations)
+}
+
+// matchAllowanceOperation matches an APPROVE or PERMIT
+// operation that lets a spender (in the operation metadata)
+// transfer amount of a configured token from the account.
+func (s *ConstructionAPIService) matchAllowanceOperation(
+	operations []*types.Operation,
+) (*transferIntent, *types.Error) {
+	opType := operations[0].Type
+	metadata := []*parser.MetadataDescription{
+		{
+			Key:       wemix.SpenderKey,
+			ValueKind: reflect.String,
+		},
+	}
+	if opType == wemix.PermitOpType {
+		metadata = append(metadata, &parser.MetadataDescription{
+			Key:       wemix.DeadlineKey,
+			ValueKind: reflect.String,
+		})
+	}
+
 	descriptions := &parser.Descriptions{
 		OperationDescriptions: []*parser.OperationDescription{
 			{
-				Type: wemix.CallOpType,
+				Type: opType,
 				Account: &parser.AccountDescription{
 					Exists: true,
 				},
 				Amount: &parser.AmountDescription{
-					Exists:   true,
-					Sign:     parser.NegativeAmountSign,
-					Currency: wemix.Currency,
-				},
-			},
-			{
-				Type: wemix.CallOpType,
-				Account: &parser.AccountDescription{
 					Exists: true,
+					Sign:   parser.PositiveOrZeroAmountSign,
 				},
-				Amount: &parser.AmountDescription{
-					Exists:   true,
-					Sign:     parser.PositiveAmountSign,
-					Currency: wemix.Currency,
-				},
+				Metadata: metadata,
 			},
 		},
 		ErrUnmatched: true,
 	}
 
-	matches, err := parser.MatchOperations(descriptions, request.Operations)
+	matches, err := parser.MatchOperations(descriptions, operations)
 	if err != nil {
 		return nil, wrapErr(ErrUnclearIntent, err)
 	}
 
-	fromOp, _ := matches[0].First()
-	fromAdd := fromOp.Account.Address
-	toOp, _ := matches[1].First()
-	toAdd := toOp.Account.Address
+	op, amount := matches[0].First()
+	ownerAdd := op.Account.Address
+	spenderAdd := op.Metadata[wemix.SpenderKey].(string)
+	if rErr := checkUint256(amount); rErr != nil {
+		return nil, rErr
+	}
 
-	// Ensure valid from address
-	checkFrom, ok := wemix.ChecksumAddress(fromAdd)
+	// Ensure valid owner address
+	checkOwner, ok := wemix.ChecksumAddress(ownerAdd)
 	if !ok {
-		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", fromAdd))
+		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", ownerAdd))
 	}
 
-	// Ensure valid to address
-	_, ok = wemix.ChecksumAddress(toAdd)
+	// Ensure valid spender address
+	checkSpender, ok := wemix.ChecksumAddress(spenderAdd)
 	if !ok {
-		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", toAdd))
+		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", spenderAdd))
 	}
 
-	preprocessOutput := &options{
-		From: checkFrom,
+	tokenAddress, rErr := s.tokenAddress(op.Amount.Currency)
+	if rErr != nil {
+		return nil, rErr
 	}
 
-	marshaled, err := marshalJSONMap(preprocessOutput)
-	if err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	intent := &transferIntent{
+		From:         checkOwner,
+		Value:        amount,
+		Currency:     op.Amount.Currency,
+		TokenAddress: tokenAddress,
+		Method:       approveMethod,
+		Spender:      checkSpender,
 	}
-
-	return &types.ConstructionPreprocessResponse{
-		Options: marshaled,
-	}, nil
-}
-
-// ConstructionMetadata implements the /construction/metadata endpoint.
-func (s *ConstructionAPIService) ConstructionMetadata(
-	ctx context.Context,
-	request *types.ConstructionMetadataRequest,
-) (*types.ConstructionMetadataResponse, *types.Error) {
-	if s.config.Mode != configuration.Online {
-		return nil, ErrUnavailableOffline
+	if opType == wemix.ApproveOpType {
+		return intent, nil
 	}
 
-	var input options
-	if err := unmarshalJSONMap(request.Options, &input); err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	deadline, err := parseOptionalBig(op.Metadata[wemix.DeadlineKey].(string))
+	if err != nil || deadline == nil || deadline.Sign() < 0 || deadline.BitLen() > uint256Bits {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			fmt.Errorf("%v is not a valid deadline", op.Metadata[wemix.DeadlineKey]),
+		)
 	}
 
-	nonce, err := s.client.PendingNonceAt(ctx, common.HexToAddress(input.From))
-	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+	intent.Method = permitMethod
+	intent.Deadline = deadline
+	return intent, nil
+}
+
+// matchCreateOperation matches a CREATE operation that
+// deploys a contract, optionally endowing it with WEMIX.
+func matchCreateOperation(operations []*types.Operation) (*transferIntent, *types.Error) {
+	descriptions := &parser.Descriptions{
+		OperationDescriptions: []*parser.OperationDescription{
+			{
+				Type: wemix.CreateOpType,
+				Account: &parser.AccountDescription{
+					Exists: true,
+				},
+				Amount: &parser.AmountDescription{
+					Exists:   true,
+					Sign:     parser.NegativeOrZeroAmountSign,
+					Currency: wemix.Currency,
+				},
+			},
+		},
+		ErrUnmatched: true,
 	}
-	gasPrice, err := s.client.SuggestGasPrice(ctx)
+
+	matches, err := parser.MatchOperations(descriptions, operations)
 	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+		return nil, wrapErr(ErrUnclearIntent, err)
 	}
 
-	metadata := &metadata{
-		Nonce:    nonce,
-		GasPrice: gasPrice,
+	fromOp, amount := matches[0].First()
+	fromAdd := fromOp.Account.Address
+	if rErr := checkUint256(amount); rErr != nil {
+		return nil, rErr
 	}
 
-	metadataMap, err := marshalJSONMap(metadata)
-	if err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	// Ensure valid from address
+	checkFrom, ok := wemix.ChecksumAddress(fromAdd)
+	if !ok {
+		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", fromAdd))
 	}
 
-	// Find suggested gas usage
-	suggestedFee := metadata.GasPrice.Int64() * wemix.TransferGasLimit
-
-	return &types.ConstructionMetadataResponse{
-		Metadata: metadataMap,
-		SuggestedFee: []*types.Amount{
-			{
-				Value:    strconv.FormatInt(suggestedFee, 10),
-				Currency: wemix.Currency,
-			},
-		},
+	return &transferIntent{
+		From:     checkFrom,
+		Value:    new(big.Int).Neg(amount),
+		Currency: wemix.Currency,
+		Create:   true,
 	}, nil
 }
 
-// ConstructionPayloads implements the /construction/payloads endpoint.
-func (s *ConstructionAPIService) ConstructionPayloads(
-	ctx context.Context,
-	request *types.ConstructionPayloadsRequest,
-) (*types.ConstructionPayloadsResponse, *types.Error) {
+// matchTransferOperations matches a pair of CALL operations
+// that move the same currency from one account to another,
+// debiting exactly the amount credited. The amount may be
+// zero for contract calls.
+func (s *ConstructionAPIService) matchTransferOperations(
+	operations []*types.Operation,
+) (*transferIntent, *types.Error) {
 	descriptions := &parser.Descriptions{
 		OperationDescriptions: []*parser.OperationDescription{
 			{
@@ -204,9 +2075,8 @@ func (s *ConstructionAPIService) ConstructionPayloads(
 					Exists: true,
 				},
 				Amount: &parser.AmountDescription{
-					Exists:   true,
-					Sign:     parser.NegativeAmountSign,
-					Currency: wemix.Currency,
+					Exists: true,
+					Sign:   parser.NegativeOrZeroAmountSign,
 				},
 			},
 			{
@@ -215,37 +2085,36 @@ func (s *ConstructionAPIService) ConstructionPayloads(
 					Exists: true,
 				},
 				Amount: &parser.AmountDescription{
-					Exists:   true,
-					Sign:     parser.PositiveAmountSign,
-					Currency: wemix.Currency,
+					Exists: true,
+					Sign:   parser.PositiveOrZeroAmountSign,
 				},
 			},
 		},
-		ErrUnmatched: true,
+		OppositeOrZeroAmounts: [][]int{{0, 1}},
+		ErrUnmatched:          true,
 	}
-	matches, err := parser.MatchOperations(descriptions, request.Operations)
+
+	matches, err := parser.MatchOperations(descriptions, operations)
 	if err != nil {
 		return nil, wrapErr(ErrUnclearIntent, err)
 	}
 
-	// Convert map to Metadata struct
-	var metadata metadata
-	if err := unmarshalJSONMap(request.Metadata, &metadata); err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
-	}
-
-	// Required Fields for constructing a real Wemix transaction
+	fromOp, _ := matches[0].First()
+	fromAdd := fromOp.Account.Address
 	toOp, amount := matches[1].First()
 	toAdd := toOp.Account.Address
-	nonce := metadata.Nonce
-	gasPrice := metadata.GasPrice
-	chainID := s.config.Params.ChainID
-	transferGasLimit := uint64(wemix.TransferGasLimit)
-	transferData := []byte{}
+	if rErr := checkUint256(amount); rErr != nil {
+		return nil, rErr
+	}
 
-	// Additional Fields for constructing custom Wemix tx struct
-	fromOp, _ := matches[0].First()
-	fromAdd := fromOp.Account.Address
+	// A spender in the metadata of the debited operation
+	// sends a transferFrom call that uses its allowance.
+	var spenderAdd string
+	if spender, ok := fromOp.Metadata[wemix.SpenderKey]; ok {
+		if spenderAdd, ok = spender.(string); !ok {
+			return nil, wrapErr(ErrUnclearIntent, fmt.Errorf("%v is not a valid spender", spender))
+		}
+	}
 
 	// Ensure valid from address
 	checkFrom, ok := wemix.ChecksumAddress(fromAdd)
@@ -259,158 +2128,126 @@ func (s *ConstructionAPIService) ConstructionPayloads(
 		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", toAdd))
 	}
 
-	tx := ethTypes.NewTransaction(
-		nonce,
-		common.HexToAddress(checkTo),
-		amount,
-		transferGasLimit,
-		gasPrice,
-		transferData,
-	)
+	currency := toOp.Amount.Currency
+	if types.Hash(fromOp.Amount.Currency) != types.Hash(currency) {
+		return nil, wrapErr(ErrUnclearIntent, errors.New("operations transfer different currencies"))
+	}
 
-	unsignedTx := &transaction{
+	intent := &transferIntent{
 		From:     checkFrom,
 		To:       checkTo,
 		Value:    amount,
-		Data:     tx.Data(),
-		Nonce:    tx.Nonce(),
-		GasPrice: gasPrice,
-		GasLimit: tx.Gas(),
-		ChainID:  chainID,
+		Currency: currency,
 	}
+	if types.Hash(currency) == types.Hash(wemix.Currency) {
+		if len(spenderAdd) > 0 {
+			return nil, wrapErr(ErrUnclearIntent, errors.New("only token transfers can use an allowance"))
+		}
 
-	// Construct SigningPayload
-	signer := ethTypes.NewEIP155Signer(chainID)
-	payload := &types.SigningPayload{
-		AccountIdentifier: &types.AccountIdentifier{Address: checkFrom},
-		Bytes:             signer.Hash(tx).Bytes(),
-		SignatureType:     types.EcdsaRecovery,
+		return intent, nil
 	}
 
-	unsignedTxJSON, err := json.Marshal(unsignedTx)
-	if err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	tokenAddress, rErr := s.tokenAddress(currency)
+	if rErr != nil {
+		return nil, rErr
 	}
+	intent.TokenAddress = tokenAddress
 
-	return &types.ConstructionPayloadsResponse{
-		UnsignedTransaction: string(unsignedTxJSON),
-		Payloads:            []*types.SigningPayload{payload},
-	}, nil
-}
+	if len(spenderAdd) > 0 {
+		// Ensure valid spender address
+		checkSpender, ok := wemix.ChecksumAddress(spenderAdd)
+		if !ok {
+			return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", spenderAdd))
+		}
 
-// ConstructionCombine implements the /construction/combine
-// endpoint.
-func (s *ConstructionAPIService) ConstructionCombine(
-	ctx context.Context,
-	request *types.ConstructionCombineRequest,
-) (*types.ConstructionCombineResponse, *types.Error) {
-	var unsignedTx transaction
-	if err := json.Unmarshal([]byte(request.UnsignedTransaction), &unsignedTx); err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+		intent.From = checkSpender
+		intent.Owner = checkFrom
+		intent.Method = transferFromMethod
 	}
 
-	ethTransaction := ethTypes.NewTransaction(
-		unsignedTx.Nonce,
-		common.HexToAddress(unsignedTx.To),
-		unsignedTx.Value,
-		unsignedTx.GasLimit,
-		unsignedTx.GasPrice,
-		unsignedTx.Data,
-	)
+	return intent, nil
+}
 
-	signer := ethTypes.NewEIP155Signer(unsignedTx.ChainID)
-	signedTx, err := ethTransaction.WithSignature(signer, request.Signatures[0].Bytes)
-	if err != nil {
-		return nil, wrapErr(ErrSignatureInvalid, err)
+// tokenAddress returns the contract address of currency,
+// which must be a configured token.
+func (s *ConstructionAPIService) tokenAddress(currency *types.Currency) (string, *types.Error) {
+	tokenAddress, ok := wemix.TokenAddress(currency)
+	if !ok {
+		return "", wrapErr(
+			ErrUnclearIntent,
+			fmt.Errorf("%s has no valid %s", currency.Symbol, wemix.ContractAddressKey),
+		)
 	}
 
-	signedTxJSON, err := signedTx.MarshalJSON()
-	if err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	token, ok := s.config.Token(tokenAddress)
+	if !ok || token.Symbol != currency.Symbol || token.Decimals != currency.Decimals {
+		return "", wrapErr(ErrUnclearIntent, fmt.Errorf("%s is not a supported token", tokenAddress))
 	}
 
-	return &types.ConstructionCombineResponse{
-		SignedTransaction: string(signedTxJSON),
-	}, nil
+	return tokenAddress, nil
 }
 
-// ConstructionHash implements the /construction/hash endpoint.
-func (s *ConstructionAPIService) ConstructionHash(
-	ctx context.Context,
-	request *types.ConstructionHashRequest,
-) (*types.TransactionIdentifierResponse, *types.Error) {
-	signedTx := ethTypes.Transaction{}
-	if err := signedTx.UnmarshalJSON([]byte(request.SignedTransaction)); err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+// checkUint256 ensures amount fits in a uint256, as
+// larger amounts cannot be encoded in a transaction.
+func checkUint256(amount *big.Int) *types.Error {
+	if amount.BitLen() > uint256Bits {
+		return wrapErr(ErrInvalidInput, fmt.Errorf("%s does not fit in a uint256", amount))
 	}
 
-	hash := signedTx.Hash().Hex()
-
-	return &types.TransactionIdentifierResponse{
-		TransactionIdentifier: &types.TransactionIdentifier{
-			Hash: hash,
-		},
-	}, nil
+	return nil
 }
 
-// ConstructionParse implements the /construction/parse endpoint.
-func (s *ConstructionAPIService) ConstructionParse(
-	ctx context.Context,
-	request *types.ConstructionParseRequest,
-) (*types.ConstructionParseResponse, *types.Error) {
-	var tx transaction
-	if !request.Signed {
-		err := json.Unmarshal([]byte(request.Transaction), &tx)
-		if err != nil {
-			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
-		}
-	} else {
-		t := new(ethTypes.Transaction)
-		err := t.UnmarshalJSON([]byte(request.Transaction))
-		if err != nil {
-			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
-		}
-
-		tx.To = t.To().String()
-		tx.Value = t.Value()
-		tx.Data = t.Data()
-		tx.Nonce = t.Nonce()
-		tx.GasPrice = t.GasPrice()
-		tx.GasLimit = t.Gas()
-		tx.ChainID = t.ChainId()
-
-		msg, err := t.AsMessage(ethTypes.NewEIP155Signer(t.ChainId()), nil)
-		if err != nil {
-			return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
-		}
+// tokenCallData returns the calldata of the ERC-20
+// method called by a token intent.
+func tokenCallData(method string, owner string, to string, spender string, value *big.Int) []byte {
+	switch method {
+	case approveMethod:
+		return wemix.Erc20ApproveData(common.HexToAddress(spender), value)
+	case transferFromMethod:
+		return wemix.Erc20TransferFromData(common.HexToAddress(owner), common.HexToAddress(to), value)
+	default:
+		return wemix.Erc20TransferData(common.HexToAddress(to), value)
+	}
+}
 
-		tx.From = msg.From().Hex()
+// tokenOps returns the operations of an ERC-20 call from
+// from with data on the contract of token, or nil if data
+// does not call a supported ERC-20 method.
+func tokenOps(from string, token *types.Currency, data []byte) []*types.Operation {
+	if recipient, amount, ok := wemix.ParseErc20TransferData(data); ok {
+		return transferOps(from, recipient.Hex(), amount, token)
 	}
 
-	// Ensure valid from address
-	checkFrom, ok := wemix.ChecksumAddress(tx.From)
-	if !ok {
-		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", tx.From))
+	if spender, amount, ok := wemix.ParseErc20ApproveData(data); ok {
+		return allowanceOps(wemix.ApproveOpType, from, spender.Hex(), amount, nil, token)
 	}
 
-	// Ensure valid to address
-	checkTo, ok := wemix.ChecksumAddress(tx.To)
-	if !ok {
-		return nil, wrapErr(ErrInvalidAddress, fmt.Errorf("%s is not a valid address", tx.To))
+	if owner, recipient, amount, ok := wemix.ParseErc20TransferFromData(data); ok {
+		ops := transferOps(owner.Hex(), recipient.Hex(), amount, token)
+		ops[0].Metadata = map[string]interface{}{
+			wemix.SpenderKey: from,
+		}
+		return ops
 	}
 
-	ops := []*types.Operation{
+	return nil
+}
+
+// transferOps returns the pair of CALL operations that
+// move amount of currency from one account to another.
+func transferOps(from string, to string, amount *big.Int, currency *types.Currency) []*types.Operation {
+	return []*types.Operation{
 		{
 			Type: wemix.CallOpType,
 			OperationIdentifier: &types.OperationIdentifier{
 				Index: 0,
 			},
 			Account: &types.AccountIdentifier{
-				Address: checkFrom,
+				Address: from,
 			},
 			Amount: &types.Amount{
-				Value:    new(big.Int).Neg(tx.Value).String(),
-				Currency: wemix.Currency,
+				Value:    new(big.Int).Neg(amount).String(),
+				Currency: currency,
 			},
 		},
 		{
@@ -424,68 +2261,68 @@ func (s *ConstructionAPIService) ConstructionParse(
 				},
 			},
 			Account: &types.AccountIdentifier{
-				Address: checkTo,
+				Address: to,
 			},
 			Amount: &types.Amount{
-				Value:    tx.Value.String(),
-				Currency: wemix.Currency,
+				Value:    amount.String(),
+				Currency: currency,
 			},
 		},
 	}
+}
 
-	metadata := &parseMetadata{
-		Nonce:    tx.Nonce,
-		GasPrice: tx.GasPrice,
-		ChainID:  tx.ChainID,
+// allowanceOps returns the APPROVE or PERMIT operation that
+// lets spender transfer amount of currency from owner. Only
+// permits have a deadline.
+func allowanceOps(
+	opType string,
+	owner string,
+	spender string,
+	amount *big.Int,
+	deadline *big.Int,
+	currency *types.Currency,
+) []*types.Operation {
+	metadata := map[string]interface{}{
+		wemix.SpenderKey: spender,
 	}
-	metaMap, err := marshalJSONMap(metadata)
-	if err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	if deadline != nil {
+		metadata[wemix.DeadlineKey] = deadline.String()
 	}
 
-	var resp *types.ConstructionParseResponse
-	if request.Signed {
-		resp = &types.ConstructionParseResponse{
-			Operations: ops,
-			AccountIdentifierSigners: []*types.AccountIdentifier{
-				{
-					Address: checkFrom,
-				},
+	return []*types.Operation{
+		{
+			Type: opType,
+			OperationIdentifier: &types.OperationIdentifier{
+				Index: 0,
 			},
-			Metadata: metaMap,
-		}
-	} else {
-		resp = &types.ConstructionParseResponse{
-			Operations:               ops,
-			AccountIdentifierSigners: []*types.AccountIdentifier{},
-			Metadata:                 metaMap,
-		}
+			Account: &types.AccountIdentifier{
+				Address: owner,
+			},
+			Amount: &types.Amount{
+				Value:    amount.String(),
+				Currency: currency,
+			},
+			Metadata: metadata,
+		},
 	}
-	return resp, nil
 }
 
-// ConstructionSubmit implements the /construction/submit endpoint.
-func (s *ConstructionAPIService) ConstructionSubmit(
-	ctx context.Context,
-	request *types.ConstructionSubmitRequest,
-) (*types.TransactionIdentifierResponse, *types.Error) {
-	if s.config.Mode != configuration.Online {
-		return nil, ErrUnavailableOffline
-	}
-
-	var signedTx ethTypes.Transaction
-	if err := signedTx.UnmarshalJSON([]byte(request.SignedTransaction)); err != nil {
-		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
-	}
-
-	if err := s.client.SendTransaction(ctx, &signedTx); err != nil {
-		return nil, wrapErr(ErrBroadcastFailed, err)
-	}
-
-	txIdentifier := &types.TransactionIdentifier{
-		Hash: signedTx.Hash().Hex(),
+// createOps returns the CREATE operation that deploys
+// a contract endowed with amount of WEMIX.
+func createOps(from string, amount *big.Int) []*types.Operation {
+	return []*types.Operation{
+		{
+			Type: wemix.CreateOpType,
+			OperationIdentifier: &types.OperationIdentifier{
+				Index: 0,
+			},
+			Account: &types.AccountIdentifier{
+				Address: from,
+			},
+			Amount: &types.Amount{
+				Value:    new(big.Int).Neg(amount).String(),
+				Currency: wemix.Currency,
+			},
+		},
 	}
-	return &types.TransactionIdentifierResponse{
-		TransactionIdentifier: txIdentifier,
-	}, nil
 }
@@ -15,20 +15,26 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/wemixarchive/rosetta-wemix/configuration"
 	mocks "github.com/wemixarchive/rosetta-wemix/mocks/services"
 	"github.com/wemixarchive/rosetta-wemix/wemix"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
@@ -52,6 +58,23 @@ func forceMarshalMap(t *testing.T, i interface{}) map[string]interface{} {
 
 	return m
 }
+func forceSign(t *testing.T, key *ecdsa.PrivateKey, payload *types.SigningPayload) *types.Signature {
+	signature, err := crypto.Sign(payload.Bytes, key)
+	if err != nil {
+		t.Fatalf("could not sign payload %x", payload.Bytes)
+	}
+
+	return &types.Signature{
+		SigningPayload: payload,
+		PublicKey: &types.PublicKey{
+			Bytes:     crypto.CompressPubkey(&key.PublicKey),
+			CurveType: types.Secp256k1,
+		},
+		SignatureType: types.EcdsaRecovery,
+		Bytes:         signature,
+	}
+}
+
 func log(t *testing.T, label string, o interface{}) {
 	tmp, _ := json.Marshal(o)
 	t.Log(label, string(tmp))
@@ -115,17 +138,18 @@ func TestConstructionService(t *testing.T) {
 	)
 	log(t, "preprocessResponse", preprocessResponse)
 	assert.Nil(t, err)
-	optionsRaw := `{"from":"0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"}`
+	optionsRaw := `{"from":"0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b","to":"0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d","value":"0x9864aac3510d02"}` // nolint
 	var options options
 	assert.NoError(t, json.Unmarshal([]byte(optionsRaw), &options))
 	log(t, "options", options)
 	assert.Equal(t, &types.ConstructionPreprocessResponse{
-		Options: forceMarshalMap(t, options),
+		Options: forceMarshalMap(t, &options),
 	}, preprocessResponse)
 
 	// Test Metadata
 	metadata := &metadata{
 		GasPrice: big.NewInt(80000000000),
+		GasLimit: 21000,
 		Nonce:    0,
 	}
 
@@ -144,9 +168,22 @@ func TestConstructionService(t *testing.T) {
 		uint64(0),
 		nil,
 	).Once()
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{
+			From:  common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"),
+			To:    &to,
+			Value: big.NewInt(42894881044106498),
+		},
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
 	metadataResponse, err := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
 		NetworkIdentifier: networkIdentifier,
-		Options:           forceMarshalMap(t, options),
+		Options:           forceMarshalMap(t, &options),
 	})
 	assert.Nil(t, err)
 	assert.Equal(t, &types.ConstructionMetadataResponse{
@@ -201,7 +238,7 @@ func TestConstructionService(t *testing.T) {
 	// Test Combine
 	// signaturesRaw := `[{"hex_bytes":"8c712c64bc65c4a88707fa93ecd090144dffb1bf133805a10a51d354c2f9f2b25a63cea6989f4c58372c41f31164036a6b25dce1d5c05e1d31c16c0590c176e801","signing_payload":{"address":"0xe3a5B4d7f79d64088C8d4ef153A7DDe2B2d47309","hex_bytes":"b682f3e39c512ff57471f482eab264551487320cbd3b34485f4779a89e5612d1","account_identifier":{"address":"0xe3a5B4d7f79d64088C8d4ef153A7DDe2B2d47309"},"signature_type":"ecdsa_recovery"},"public_key":{"hex_bytes":"03d3d3358e7f69cbe45bde38d7d6f24660c7eeeaee5c5590cfab985c8839b21fd5","curve_type":"secp256k1"},"signature_type":"ecdsa_recovery"}]` // nolint
 
-	signaturesRaw := `[{"hex_bytes":"5f22dc4b318c51f636beb17e0483ca8f36d7a43d8acdff63eaed921bff5dc2c20f51930067cb001dbb1ba675e652cbf93375b4646b31d0818aa94917f3e6fda600","signing_payload":{"address":"0xbe862AD9AbFe6f22BCb087716c7D89a26051f74C","hex_bytes":"996836219400142b587d5ad87b1f70d25a2497fd6ac431509ed90c48df8b2a9f","account_identifier":{"address":"0xbe862AD9AbFe6f22BCb087716c7D89a26051f74C"},"signature_type":"ecdsa_recovery"},"public_key":{"hex_bytes":"036d9038945ff8f4669201ba1e806c9a46a5034a578e4d52c03152198538039294","curve_type":"secp256k1"},"signature_type":"ecdsa_recovery"}]` // nolint
+	signaturesRaw := `[{"hex_bytes":"5f22dc4b318c51f636beb17e0483ca8f36d7a43d8acdff63eaed921bff5dc2c20f51930067cb001dbb1ba675e652cbf93375b4646b31d0818aa94917f3e6fda600","signing_payload":{"address":"0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b","hex_bytes":"996836219400142b587d5ad87b1f70d25a2497fd6ac431509ed90c48df8b2a9f","account_identifier":{"address":"0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"},"signature_type":"ecdsa_recovery"},"public_key":{"hex_bytes":"028f9c6eb669972b25cdc5a6a3e13bc5c8e7a83d5424037c61143b51368805990c","curve_type":"secp256k1"},"signature_type":"ecdsa_recovery"}]` // nolint
 	//tx: 0xaa26f7c0885128219e831a432ac58bc9ed79d26547ad65c27fd36e18c0ee232d
 	//signaturesRaw := `[{"hex_bytes":"f17333e48f62ce7798119b91a7c0d523d2eecf74db0a7ef29ecb54a461f648c8685390d22e29d518bfea5d61cd7a14cb98a14fb2f8a8c7d335c1c3cec1b46f8a1c","signing_payload":{"address":"0x1B892F4bf95b25375D7E83A7D2E2641A4bdf3bfB","hex_bytes":"b682f3e39c512ff57471f482eab264551487320cbd3b34485f4779a89e5612d1","account_identifier":{"address":"0xe3a5B4d7f79d64088C8d4ef153A7DDe2B2d47309"},"signature_type":"ecdsa_recovery"},"public_key":{"hex_bytes":"03d3d3358e7f69cbe45bde38d7d6f24660c7eeeaee5c5590cfab985c8839b21fd5","curve_type":"secp256k1"},"signature_type":"ecdsa_recovery"}]` // nolint
 	var signatures []*types.Signature
@@ -302,3 +339,3254 @@ func TestConstructionService(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestConstructionService_DeriveContractAddress(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Offline,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	ctx := context.Background()
+
+	// 0xbe862AD9AbFe6f22BCb087716c7D89a26051f74C
+	publicKey := &types.PublicKey{
+		Bytes:     forceHexDecode(t, "036d9038945ff8f4669201ba1e806c9a46a5034a578e4d52c03152198538039294"),
+		CurveType: types.Secp256k1,
+	}
+	zeroHash := "0x0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := map[string]struct {
+		metadata map[string]interface{}
+		address  string
+		deployer string
+		err      *types.Error
+	}{
+		"create": {
+			metadata: map[string]interface{}{
+				"derivation": "create",
+				"deployer":   "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0",
+				"nonce":      "1",
+			},
+			address:  "0x343c43a37d37dff08ae8c4a11544c718abb4fcf8",
+			deployer: "0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0",
+		},
+		"create from public key": {
+			metadata: map[string]interface{}{
+				"derivation": "create",
+				"nonce":      "0x0",
+			},
+			address:  crypto.CreateAddress(common.HexToAddress("0xbe862AD9AbFe6f22BCb087716c7D89a26051f74C"), 0).Hex(),
+			deployer: "0xbe862AD9AbFe6f22BCb087716c7D89a26051f74C",
+		},
+		"create2 with init code": {
+			metadata: map[string]interface{}{
+				"derivation": "create2",
+				"deployer":   "0xdeadbeef00000000000000000000000000000000",
+				"salt":       zeroHash,
+				"init_code":  "0x00",
+			},
+			address:  "0xB928f69Bb1D91Cd65274e3c79d8986362984fDA3",
+			deployer: "0xdeadbeef00000000000000000000000000000000",
+		},
+		"create2 with init code hash": {
+			metadata: map[string]interface{}{
+				"derivation":     "create2",
+				"deployer":       "0x0000000000000000000000000000000000000000",
+				"salt":           zeroHash,
+				"init_code_hash": crypto.Keccak256Hash([]byte{0}).Hex(),
+			},
+			address:  "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38",
+			deployer: "0x0000000000000000000000000000000000000000",
+		},
+		"create without nonce": {
+			metadata: map[string]interface{}{"derivation": "create"},
+			err:      ErrInvalidInput,
+		},
+		"create2 with short salt": {
+			metadata: map[string]interface{}{
+				"derivation": "create2",
+				"salt":       "0x01",
+				"init_code":  "0x00",
+			},
+			err: ErrInvalidInput,
+		},
+		"create2 without init code": {
+			metadata: map[string]interface{}{
+				"derivation": "create2",
+				"salt":       zeroHash,
+			},
+			err: ErrInvalidInput,
+		},
+		"invalid deployer": {
+			metadata: map[string]interface{}{
+				"derivation": "create",
+				"deployer":   "0x123",
+				"nonce":      "1",
+			},
+			err: ErrInvalidAddress,
+		},
+		"invalid derivation": {
+			metadata: map[string]interface{}{"derivation": "create3"},
+			err:      ErrInvalidInput,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			deriveResponse, rErr := servicer.ConstructionDerive(ctx, &types.ConstructionDeriveRequest{
+				NetworkIdentifier: networkIdentifier,
+				PublicKey:         publicKey,
+				Metadata:          test.metadata,
+			})
+			if test.err != nil {
+				assert.Nil(t, deriveResponse)
+				assert.Equal(t, test.err.Code, rErr.Code)
+				return
+			}
+
+			assert.Nil(t, rErr)
+			assert.Equal(t, &types.ConstructionDeriveResponse{
+				AccountIdentifier: &types.AccountIdentifier{Address: common.HexToAddress(test.address).Hex()},
+				Metadata: map[string]interface{}{
+					"derivation": test.metadata["derivation"],
+					"deployer":   common.HexToAddress(test.deployer).Hex(),
+				},
+			}, deriveResponse)
+		})
+	}
+}
+
+func TestConstructionService_Erc20Transfer(t *testing.T) {
+	networkIdentifier = &types.NetworkIdentifier{
+		Network:    wemix.TestnetNetwork,
+		Blockchain: wemix.Blockchain,
+	}
+
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+		Tokens:  []*types.Currency{token},
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tokenAddress := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	amount := big.NewInt(1000000000000000000)
+	ops := transferOps(from.Hex(), to.Hex(), amount, token)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+		},
+	)
+	assert.Nil(t, rErr)
+	options := &options{
+		From:         from.Hex(),
+		To:           to.Hex(),
+		TokenAddress: tokenAddress.Hex(),
+		Value:        amount,
+	}
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, options),
+	}, preprocessResponse)
+
+	// Test Metadata
+	data := wemix.Erc20TransferData(to, amount)
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(5), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &tokenAddress, Data: data},
+	).Return(
+		uint64(51000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	metadata := &metadata{
+		Nonce:    5,
+		GasPrice: big.NewInt(100000000000),
+		GasLimit: 51000,
+	}
+	assert.Equal(t, &types.ConstructionMetadataResponse{
+		Metadata: forceMarshalMap(t, metadata),
+		SuggestedFee: []*types.Amount{
+			{
+				Value:    "5100000000000000",
+				Currency: wemix.Currency,
+			},
+		},
+	}, metadataResponse)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+	assert.Len(t, payloadsResponse.Payloads, 1)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, tokenAddress.Hex(), unsignedTx.To)
+	assert.Zero(t, unsignedTx.Value.Sign())
+	assert.Equal(t, data, unsignedTx.Data)
+	assert.Equal(t, uint64(51000), unsignedTx.GasLimit)
+
+	// Test Parse Unsigned
+	parseMetadata := &parseMetadata{
+		Nonce:    5,
+		GasPrice: big.NewInt(100000000000),
+		ChainID:  big.NewInt(1112),
+		MethodID: wemix.TransferFnSelector,
+	}
+	parseUnsignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionParseResponse{
+		Operations:               ops,
+		AccountIdentifierSigners: []*types.AccountIdentifier{},
+		Metadata:                 forceMarshalMap(t, parseMetadata),
+	}, parseUnsignedResponse)
+
+	// Test Combine
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures: []*types.Signature{
+			forceSign(t, privateKey, payloadsResponse.Payloads[0]),
+		},
+	})
+	assert.Nil(t, rErr)
+
+	// Test Parse Signed
+	parseSignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionParseResponse{
+		Operations: ops,
+		AccountIdentifierSigners: []*types.AccountIdentifier{
+			{Address: from.Hex()},
+		},
+		Metadata: forceMarshalMap(t, parseMetadata),
+	}, parseSignedResponse)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_UnsupportedToken(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+	ops := transferOps(
+		"0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b",
+		"0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+		big.NewInt(1),
+		token,
+	)
+
+	resp, rErr := servicer.ConstructionPreprocess(context.Background(), &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, ErrUnclearIntent.Code, rErr.Code)
+}
+
+func TestConstructionService_InvalidTransfers(t *testing.T) {
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+		Tokens:  []*types.Currency{token},
+	}
+
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	from := "0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"
+	to := "0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d"
+
+	// Transfers debit exactly the amount they credit.
+	for name, debit := range map[string]string{
+		"smaller debit": "-1",
+		"larger debit":  "-3",
+		"zero debit":    "0",
+	} {
+		ops := transferOps(from, to, big.NewInt(2), wemix.Currency)
+		ops[0].Amount.Value = debit
+
+		resp, rErr := servicer.ConstructionPreprocess(context.Background(), &types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+		})
+		assert.Nil(t, resp, name)
+		assert.Equal(t, ErrUnclearIntent.Code, rErr.Code, name)
+	}
+
+	// Amounts, values and deadlines must fit in a uint256.
+	tooLarge := new(big.Int).Lsh(big.NewInt(1), 256)
+	for name, ops := range map[string][]*types.Operation{
+		"transfer":        transferOps(from, to, tooLarge, wemix.Currency),
+		"token transfer":  transferOps(from, to, tooLarge, token),
+		"create":          createOps(from, tooLarge),
+		"approve":         allowanceOps(wemix.ApproveOpType, from, to, tooLarge, nil, token),
+		"permit":          allowanceOps(wemix.PermitOpType, from, to, tooLarge, big.NewInt(1), token),
+		"permit deadline": allowanceOps(wemix.PermitOpType, from, to, big.NewInt(1), tooLarge, token),
+	} {
+		resp, rErr := servicer.ConstructionPreprocess(context.Background(), &types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+		})
+		assert.Nil(t, resp, name)
+		assert.Equal(t, ErrInvalidInput.Code, rErr.Code, name)
+	}
+}
+
+func TestConstructionService_Erc20Approve(t *testing.T) {
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+		Tokens:  []*types.Currency{token},
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+	spender := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tokenAddress := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	amount := big.NewInt(1000000000000000000)
+	ops := allowanceOps(wemix.ApproveOpType, owner.Hex(), spender.Hex(), amount, nil, token)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:         owner.Hex(),
+			TokenAddress: tokenAddress.Hex(),
+			Value:        amount,
+			Method:       approveMethod,
+			Spender:      spender.Hex(),
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	data := wemix.Erc20ApproveData(spender, amount)
+	mockClient.On("PendingNonceAt", ctx, owner).Return(uint64(5), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: owner, To: &tokenAddress, Data: data},
+	).Return(
+		uint64(46000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, owner.Hex(), unsignedTx.From)
+	assert.Equal(t, tokenAddress.Hex(), unsignedTx.To)
+	assert.Zero(t, unsignedTx.Value.Sign())
+	assert.Equal(t, data, unsignedTx.Data)
+
+	// Test Parse Signed
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures: []*types.Signature{
+			forceSign(t, privateKey, payloadsResponse.Payloads[0]),
+		},
+	})
+	assert.Nil(t, rErr)
+
+	parseSignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseSignedResponse.Operations)
+	assert.Equal(t, []*types.AccountIdentifier{{Address: owner.Hex()}}, parseSignedResponse.AccountIdentifierSigners)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_Erc20TransferFrom(t *testing.T) {
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+		Tokens:  []*types.Currency{token},
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	spender := crypto.PubkeyToAddress(privateKey.PublicKey)
+	owner := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tokenAddress := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	amount := big.NewInt(1000000000000000000)
+	ops := transferOps(owner.Hex(), to.Hex(), amount, token)
+	ops[0].Metadata = map[string]interface{}{wemix.SpenderKey: spender.Hex()}
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:         spender.Hex(),
+			To:           to.Hex(),
+			TokenAddress: tokenAddress.Hex(),
+			Value:        amount,
+			Method:       transferFromMethod,
+			Owner:        owner.Hex(),
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	data := wemix.Erc20TransferFromData(owner, to, amount)
+	mockClient.On("PendingNonceAt", ctx, spender).Return(uint64(2), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: spender, To: &tokenAddress, Data: data},
+	).Return(
+		uint64(60000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, spender.Hex(), payloadsResponse.Payloads[0].AccountIdentifier.Address)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, spender.Hex(), unsignedTx.From)
+	assert.Equal(t, tokenAddress.Hex(), unsignedTx.To)
+	assert.Equal(t, data, unsignedTx.Data)
+
+	// Test Parse Unsigned
+	parseUnsignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseUnsignedResponse.Operations)
+
+	// Allowances only apply to tokens.
+	wemixOps := transferOps(owner.Hex(), to.Hex(), amount, wemix.Currency)
+	wemixOps[0].Metadata = map[string]interface{}{wemix.SpenderKey: spender.Hex()}
+	_, rErr = servicer.ConstructionPreprocess(ctx, &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        wemixOps,
+	})
+	assert.Equal(t, ErrUnclearIntent.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_Erc20Permit(t *testing.T) {
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+		Tokens:  []*types.Currency{token},
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	owner := crypto.PubkeyToAddress(privateKey.PublicKey)
+	spender := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tokenAddress := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	amount := big.NewInt(1000000000000000000)
+	deadline := big.NewInt(1700000000)
+	ops := allowanceOps(wemix.PermitOpType, owner.Hex(), spender.Hex(), amount, deadline, token)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:         owner.Hex(),
+			TokenAddress: tokenAddress.Hex(),
+			Value:        amount,
+			Method:       permitMethod,
+			Spender:      spender.Hex(),
+			Deadline:     deadline,
+		}),
+	}, preprocessResponse)
+
+	// Permits are signed messages, so they have no fee payer.
+	_, rErr = servicer.ConstructionPreprocess(ctx, &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          map[string]interface{}{"fee_payer": spender.Hex()},
+	})
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+
+	// Test Metadata
+	domainSeparator := common.HexToHash("0x8b73c3c69bb8fe3d512ecc4cf759cc79239f7b179b0ffacaa9a75d522b39400f")
+	mockClient.On(
+		"PendingCallContract",
+		ctx,
+		ethereum.CallMsg{To: &tokenAddress, Data: wemix.Erc20NoncesData(owner)},
+	).Return(
+		common.LeftPadBytes([]byte{3}, 32),
+		nil,
+	).Once()
+	mockClient.On(
+		"PendingCallContract",
+		ctx,
+		ethereum.CallMsg{To: &tokenAddress, Data: wemix.DomainSeparatorFnSelector},
+	).Return(
+		domainSeparator.Bytes(),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, "0", metadataResponse.SuggestedFee[0].Value)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+	digest := wemix.TypedDataHash(
+		domainSeparator,
+		wemix.PermitStructHash(owner, spender, amount, big.NewInt(3), deadline),
+	)
+	assert.Equal(t, []*types.SigningPayload{
+		{
+			AccountIdentifier: &types.AccountIdentifier{Address: owner.Hex()},
+			Bytes:             digest.Bytes(),
+			SignatureType:     types.EcdsaRecovery,
+		},
+	}, payloadsResponse.Payloads)
+
+	// Test Parse Unsigned
+	parseUnsignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseUnsignedResponse.Operations)
+	assert.Empty(t, parseUnsignedResponse.AccountIdentifierSigners)
+
+	// Test Combine
+	signature := forceSign(t, privateKey, payloadsResponse.Payloads[0])
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures:          []*types.Signature{signature},
+	})
+	assert.Nil(t, rErr)
+
+	signedPermit, ok := decodePermit(combineResponse.SignedTransaction)
+	assert.True(t, ok)
+	assert.Equal(t, hexutil.Bytes(signature.Bytes), signedPermit.Signature)
+	assert.Equal(
+		t,
+		hexutil.Bytes(wemix.Erc20PermitData(owner, spender, amount, deadline, signature.Bytes)),
+		signedPermit.CallData,
+	)
+
+	// Test Parse Signed
+	parseSignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseSignedResponse.Operations)
+	assert.Equal(t, []*types.AccountIdentifier{{Address: owner.Hex()}}, parseSignedResponse.AccountIdentifierSigners)
+	assert.Equal(t, signedPermit.CallData.String(), parseSignedResponse.Metadata["call_data"])
+
+	// Test Hash
+	hashResponse, rErr := servicer.ConstructionHash(ctx, &types.ConstructionHashRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, digest.Hex(), hashResponse.TransactionIdentifier.Hash)
+
+	// Test Submit
+	_, rErr = servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+
+	// A signature by another account is rejected.
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	_, rErr = servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures:          []*types.Signature{forceSign(t, otherKey, payloadsResponse.Payloads[0])},
+	})
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_Safe(t *testing.T) {
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+		Tokens:  []*types.Currency{token},
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	owners := make([]common.Address, 3)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		assert.NoError(t, err)
+		keys[i] = key
+		owners[i] = crypto.PubkeyToAddress(key.PublicKey)
+	}
+
+	safe := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tokenAddress := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	amount := big.NewInt(1000000000000000000)
+	ops := transferOps(safe.Hex(), to.Hex(), amount, token)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata:          map[string]interface{}{"safe": true},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:         safe.Hex(),
+			To:           to.Hex(),
+			TokenAddress: tokenAddress.Hex(),
+			Value:        amount,
+			Safe:         true,
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	domainSeparator := wemix.SafeDomainSeparator(big.NewInt(1112), safe)
+	ownersResult := append(common.LeftPadBytes([]byte{0x20}, 32), common.LeftPadBytes([]byte{3}, 32)...)
+	for _, owner := range owners {
+		ownersResult = append(ownersResult, common.LeftPadBytes(owner.Bytes(), 32)...)
+	}
+	for selector, result := range map[string][]byte{
+		hexutil.Encode(wemix.SafeNonceFnSelector):           common.LeftPadBytes([]byte{7}, 32),
+		hexutil.Encode(wemix.SafeGetThresholdFnSelector):    common.LeftPadBytes([]byte{2}, 32),
+		hexutil.Encode(wemix.SafeDomainSeparatorFnSelector): domainSeparator.Bytes(),
+		hexutil.Encode(wemix.SafeGetOwnersFnSelector):       ownersResult,
+	} {
+		mockClient.On(
+			"PendingCallContract",
+			ctx,
+			ethereum.CallMsg{To: &safe, Data: forceHexDecode(t, selector[2:])},
+		).Return(
+			result,
+			nil,
+		).Once()
+	}
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, "0", metadataResponse.SuggestedFee[0].Value)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+	safeTx := wemix.NewSafeTx(tokenAddress, big.NewInt(0), wemix.Erc20TransferData(to, amount), big.NewInt(7))
+	safeTxHash := safeTx.Hash(domainSeparator)
+	assert.Len(t, payloadsResponse.Payloads, 3)
+	for i, payload := range payloadsResponse.Payloads {
+		assert.Equal(t, &types.SigningPayload{
+			AccountIdentifier: &types.AccountIdentifier{Address: owners[i].Hex()},
+			Bytes:             safeTxHash.Bytes(),
+			SignatureType:     types.EcdsaRecovery,
+		}, payload)
+	}
+
+	// Test Parse Unsigned
+	parseUnsignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseUnsignedResponse.Operations)
+	assert.Empty(t, parseUnsignedResponse.AccountIdentifierSigners)
+
+	// Test Combine
+	signatures := []*types.Signature{
+		forceSign(t, keys[2], payloadsResponse.Payloads[2]),
+		forceSign(t, keys[0], payloadsResponse.Payloads[0]),
+	}
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures:          signatures,
+	})
+	assert.Nil(t, rErr)
+
+	// Owner signatures are sorted by owner address,
+	// with v in {27, 28}.
+	sorted := []int{0, 1}
+	if bytes.Compare(owners[0].Bytes(), owners[2].Bytes()) < 0 {
+		sorted = []int{1, 0}
+	}
+	expectedSignatures := []byte{}
+	for _, i := range sorted {
+		sig := append([]byte{}, signatures[i].Bytes...)
+		sig[64] += 27
+		expectedSignatures = append(expectedSignatures, sig...)
+	}
+	signedSafeTx, ok := decodeSafeTransaction(combineResponse.SignedTransaction)
+	assert.True(t, ok)
+	assert.Equal(t, hexutil.Bytes(expectedSignatures), signedSafeTx.Signatures)
+	expectedCallData, err := wemix.SafeExecTransactionData(safeTx, expectedSignatures)
+	assert.NoError(t, err)
+	assert.Equal(t, hexutil.Bytes(expectedCallData), signedSafeTx.CallData)
+
+	// Test Parse Signed
+	parseSignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseSignedResponse.Operations)
+	assert.ElementsMatch(
+		t,
+		[]*types.AccountIdentifier{{Address: owners[0].Hex()}, {Address: owners[2].Hex()}},
+		parseSignedResponse.AccountIdentifierSigners,
+	)
+	assert.Equal(t, signedSafeTx.CallData.String(), parseSignedResponse.Metadata["call_data"])
+
+	// Call data that does not execute the signed
+	// Safe transaction is rejected.
+	tamperedSafeTx := *signedSafeTx
+	tamperedSafeTx.CallData, err = wemix.SafeExecTransactionData(
+		wemix.NewSafeTx(tokenAddress, big.NewInt(0), wemix.Erc20TransferData(owners[1], amount), big.NewInt(7)),
+		expectedSignatures,
+	)
+	assert.NoError(t, err)
+	tampered, err := encodeSafeTransaction(&tamperedSafeTx)
+	assert.NoError(t, err)
+	_, rErr = servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       tampered,
+	})
+	assert.Equal(t, ErrUnableToParseIntermediateResult.Code, rErr.Code)
+
+	// Test Hash
+	hashResponse, rErr := servicer.ConstructionHash(ctx, &types.ConstructionHashRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, safeTxHash.Hex(), hashResponse.TransactionIdentifier.Hash)
+
+	// Test Submit
+	_, rErr = servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+
+	// Fewer signatures than the threshold are rejected.
+	_, rErr = servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures:          signatures[:1],
+	})
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+
+	// Signatures by an owner for another owner are rejected.
+	_, rErr = servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures: []*types.Signature{
+			forceSign(t, keys[0], payloadsResponse.Payloads[0]),
+			forceSign(t, keys[0], payloadsResponse.Payloads[1]),
+		},
+	})
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+
+	// A domain separator of another Safe is rejected.
+	for selector, result := range map[string][]byte{
+		hexutil.Encode(wemix.SafeNonceFnSelector):           common.LeftPadBytes([]byte{7}, 32),
+		hexutil.Encode(wemix.SafeGetThresholdFnSelector):    common.LeftPadBytes([]byte{2}, 32),
+		hexutil.Encode(wemix.SafeDomainSeparatorFnSelector): wemix.SafeDomainSeparator(big.NewInt(1112), to).Bytes(),
+	} {
+		mockClient.On(
+			"PendingCallContract",
+			ctx,
+			ethereum.CallMsg{To: &safe, Data: forceHexDecode(t, selector[2:])},
+		).Return(
+			result,
+			nil,
+		).Once()
+	}
+	_, rErr = servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+
+	// Safe transactions only transfer.
+	_, rErr = servicer.ConstructionPreprocess(ctx, &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        transferOps(safe.Hex(), to.Hex(), amount, wemix.Currency),
+		Metadata:          map[string]interface{}{"safe": true, "data": "0x01"},
+	})
+	assert.Equal(t, ErrUnclearIntent.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_DynamicFee(t *testing.T) {
+	networkIdentifier = &types.NetworkIdentifier{
+		Network:    wemix.TestnetNetwork,
+		Blockchain: wemix.Blockchain,
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	ops := transferOps(from.Hex(), to.Hex(), big.NewInt(42894881044106498), wemix.Currency)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"dynamic_fee": true,
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:       from.Hex(),
+			To:         to.Hex(),
+			Value:      big.NewInt(42894881044106498),
+			DynamicFee: true,
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(3), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(101000000000), nil).Once()
+	mockClient.On("SuggestGasTipCap", ctx).Return(big.NewInt(1000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: big.NewInt(42894881044106498)},
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	metadata := &metadata{
+		Nonce:                3,
+		GasPrice:             big.NewInt(101000000000),
+		GasLimit:             21000,
+		MaxFeePerGas:         big.NewInt(201000000000),
+		MaxPriorityFeePerGas: big.NewInt(1000000000),
+	}
+	assert.Equal(t, &types.ConstructionMetadataResponse{
+		Metadata: forceMarshalMap(t, metadata),
+		SuggestedFee: []*types.Amount{
+			{
+				Value:    "4221000000000000",
+				Currency: wemix.Currency,
+			},
+		},
+	}, metadataResponse)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, metadata.MaxFeePerGas, unsignedTx.MaxFeePerGas)
+	assert.Equal(t, metadata.MaxPriorityFeePerGas, unsignedTx.MaxPriorityFeePerGas)
+
+	// Test Parse Unsigned
+	parseMetadata := &parseMetadata{
+		Type:                 ethTypes.DynamicFeeTxType,
+		Nonce:                3,
+		GasPrice:             metadata.MaxFeePerGas,
+		ChainID:              big.NewInt(1112),
+		MaxFeePerGas:         metadata.MaxFeePerGas,
+		MaxPriorityFeePerGas: metadata.MaxPriorityFeePerGas,
+	}
+	parseUnsignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionParseResponse{
+		Operations:               ops,
+		AccountIdentifierSigners: []*types.AccountIdentifier{},
+		Metadata:                 forceMarshalMap(t, parseMetadata),
+	}, parseUnsignedResponse)
+
+	// Test Combine
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures: []*types.Signature{
+			forceSign(t, privateKey, payloadsResponse.Payloads[0]),
+		},
+	})
+	assert.Nil(t, rErr)
+
+	signedTx := new(ethTypes.Transaction)
+	assert.NoError(t, signedTx.UnmarshalJSON([]byte(combineResponse.SignedTransaction)))
+	assert.Equal(t, uint8(ethTypes.DynamicFeeTxType), signedTx.Type())
+
+	// Test Parse Signed
+	parseSignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionParseResponse{
+		Operations: ops,
+		AccountIdentifierSigners: []*types.AccountIdentifier{
+			{Address: from.Hex()},
+		},
+		Metadata: forceMarshalMap(t, parseMetadata),
+	}, parseSignedResponse)
+
+	// Test Hash
+	hashResponse, rErr := servicer.ConstructionHash(ctx, &types.ConstructionHashRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, signedTx.Hash().Hex(), hashResponse.TransactionIdentifier.Hash)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_ContractCall(t *testing.T) {
+	networkIdentifier = &types.NetworkIdentifier{
+		Network:    wemix.TestnetNetwork,
+		Blockchain: wemix.Blockchain,
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	from := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	contract := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	value := big.NewInt(1000)
+	ops := transferOps(from.Hex(), contract.Hex(), value, wemix.Currency)
+	methodSignature := "stake(address,uint256,bool)"
+	methodArgs := []string{"0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1", "1000", "true"}
+	data := forceHexDecode(
+		t,
+		"995846bd"+
+			"0000000000000000000000008e81fcc2d4a3baa0ee9044e0d7e36f59c9bba9c1"+
+			"00000000000000000000000000000000000000000000000000000000000003e8"+
+			"0000000000000000000000000000000000000000000000000000000000000001",
+	)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"method_signature": methodSignature,
+				"method_args":      methodArgs,
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:            from.Hex(),
+			To:              contract.Hex(),
+			Value:           value,
+			Data:            data,
+			MethodSignature: methodSignature,
+			MethodArgs:      methodArgs,
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(0), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &contract, Value: value, Data: data},
+	).Return(
+		uint64(80000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, forceMarshalMap(t, &metadata{
+		Nonce:           0,
+		GasPrice:        big.NewInt(100000000000),
+		GasLimit:        80000,
+		Data:            data,
+		MethodSignature: methodSignature,
+		MethodArgs:      methodArgs,
+	}), metadataResponse.Metadata)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, contract.Hex(), unsignedTx.To)
+	assert.Equal(t, data, unsignedTx.Data)
+	assert.Equal(t, uint64(80000), unsignedTx.GasLimit)
+
+	// Test Parse Unsigned
+	parseUnsignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionParseResponse{
+		Operations:               ops,
+		AccountIdentifierSigners: []*types.AccountIdentifier{},
+		Metadata: forceMarshalMap(t, &parseMetadata{
+			Nonce:           0,
+			GasPrice:        big.NewInt(100000000000),
+			ChainID:         big.NewInt(1112),
+			MethodID:        data[:4],
+			MethodSignature: methodSignature,
+			MethodArgs:      methodArgs,
+		}),
+	}, parseUnsignedResponse)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_ContractCall_InvalidArgs(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	ops := transferOps(
+		"0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b",
+		"0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+		big.NewInt(0),
+		wemix.Currency,
+	)
+
+	resp, rErr := servicer.ConstructionPreprocess(context.Background(), &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata: map[string]interface{}{
+			"method_signature": "stake(address,uint256)",
+			"method_args":      []string{"not an address", "1"},
+		},
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+}
+
+func TestConstructionService_Payloads_MissingGasLimit(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	ctx := context.Background()
+	ops := transferOps(
+		"0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b",
+		"0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+		big.NewInt(1000),
+		wemix.Currency,
+	)
+
+	// Plain transfers default to the gas of a transfer.
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata: forceMarshalMap(t, &metadata{
+			Nonce:    0,
+			GasPrice: big.NewInt(100000000000),
+		}),
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, uint64(wemix.TransferGasLimit), unsignedTx.GasLimit)
+
+	// Contract calls need an estimated gas limit.
+	payloadsResponse, rErr = servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata: forceMarshalMap(t, &metadata{
+			Nonce:    0,
+			GasPrice: big.NewInt(100000000000),
+			Data:     wemix.MethodID("pause()"),
+		}),
+	})
+	assert.Nil(t, payloadsResponse)
+	assert.Equal(t, ErrUnableToParseIntermediateResult.Code, rErr.Code)
+}
+
+func TestConstructionService_ContractDeployment(t *testing.T) {
+	networkIdentifier = &types.NetworkIdentifier{
+		Network:    wemix.TestnetNetwork,
+		Blockchain: wemix.Blockchain,
+	}
+
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	value := big.NewInt(1000)
+	ops := createOps(from.Hex(), value)
+	data := forceHexDecode(
+		t,
+		"6080604052348015600f57600080fd5b50"+
+			"0000000000000000000000000000000000000000000000000000000000000007",
+	)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"bytecode":          "0x6080604052348015600f57600080fd5b50",
+				"constructor_types": []string{"uint256"},
+				"constructor_args":  []string{"7"},
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:   from.Hex(),
+			Value:  value,
+			Create: true,
+			Data:   data,
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(9), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, Value: value, Data: data},
+	).Return(
+		uint64(150000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, forceMarshalMap(t, &metadata{
+		Nonce:    9,
+		GasPrice: big.NewInt(100000000000),
+		GasLimit: 150000,
+		Data:     data,
+	}), metadataResponse.Metadata)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Empty(t, unsignedTx.To)
+	assert.Equal(t, data, unsignedTx.Data)
+	assert.Equal(t, uint64(150000), unsignedTx.GasLimit)
+
+	// Test Parse Unsigned
+	parseMetadata := &parseMetadata{
+		Nonce:           9,
+		GasPrice:        big.NewInt(100000000000),
+		ChainID:         big.NewInt(1112),
+		ContractAddress: crypto.CreateAddress(from, 9).Hex(),
+	}
+	parseUnsignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionParseResponse{
+		Operations:               ops,
+		AccountIdentifierSigners: []*types.AccountIdentifier{},
+		Metadata:                 forceMarshalMap(t, parseMetadata),
+	}, parseUnsignedResponse)
+
+	// Test Combine
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures: []*types.Signature{
+			forceSign(t, privateKey, payloadsResponse.Payloads[0]),
+		},
+	})
+	assert.Nil(t, rErr)
+
+	signedTx := new(ethTypes.Transaction)
+	assert.NoError(t, signedTx.UnmarshalJSON([]byte(combineResponse.SignedTransaction)))
+	assert.Nil(t, signedTx.To())
+
+	// Test Parse Signed
+	parseSignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionParseResponse{
+		Operations: ops,
+		AccountIdentifierSigners: []*types.AccountIdentifier{
+			{Address: from.Hex()},
+		},
+		Metadata: forceMarshalMap(t, parseMetadata),
+	}, parseSignedResponse)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_ContractDeployment_MissingBytecode(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	resp, rErr := servicer.ConstructionPreprocess(context.Background(), &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        createOps("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b", big.NewInt(0)),
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+}
+
+func TestConstructionService_AccessList(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	amount := big.NewInt(1000)
+	ops := transferOps(from.Hex(), to.Hex(), amount, wemix.Currency)
+	accessList := ethTypes.AccessList{
+		{
+			Address:     to,
+			StorageKeys: []common.Hash{common.HexToHash("0x01")},
+		},
+	}
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"create_access_list": true,
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:             from.Hex(),
+			To:               to.Hex(),
+			Value:            amount,
+			CreateAccessList: true,
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	mockClient.On(
+		"CreateAccessList",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: amount},
+	).Return(
+		accessList,
+		nil,
+	).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: amount, AccessList: accessList},
+	).Return(
+		uint64(25300),
+		nil,
+	).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil).Once()
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(1), nil).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionMetadataResponse{
+		Metadata: forceMarshalMap(t, &metadata{
+			Nonce:      1,
+			GasPrice:   big.NewInt(100000000000),
+			GasLimit:   25300,
+			AccessList: &accessList,
+		}),
+		SuggestedFee: []*types.Amount{
+			{
+				Value:    "2530000000000000",
+				Currency: wemix.Currency,
+			},
+		},
+	}, metadataResponse)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, &accessList, unsignedTx.AccessList)
+	assert.Equal(t, uint8(ethTypes.AccessListTxType), ethTransaction(&unsignedTx).Type())
+
+	// Test Combine
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures: []*types.Signature{
+			forceSign(t, privateKey, payloadsResponse.Payloads[0]),
+		},
+	})
+	assert.Nil(t, rErr)
+
+	signedTx, err := decodeSignedTx(combineResponse.SignedTransaction)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(ethTypes.AccessListTxType), signedTx.Type())
+	assert.Equal(t, accessList, signedTx.AccessList())
+
+	// Test Parse Signed
+	parseSignedResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseSignedResponse.Operations)
+	assert.Equal(t, forceMarshalMap(t, &parseMetadata{
+		Type:       ethTypes.AccessListTxType,
+		Nonce:      1,
+		GasPrice:   big.NewInt(100000000000),
+		ChainID:    big.NewInt(1112),
+		AccessList: &accessList,
+	}), parseSignedResponse.Metadata)
+
+	// A provided access list cannot also be generated.
+	_, rErr = servicer.ConstructionPreprocess(ctx, &types.ConstructionPreprocessRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata: map[string]interface{}{
+			"create_access_list": true,
+			"access_list":        forceMarshalMap(t, &struct{ L ethTypes.AccessList }{accessList})["L"],
+		},
+	})
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_GasLimitMultiplier(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:               configuration.Online,
+		Network:            networkIdentifier,
+		Params:             params.WemixTestnetChainConfig,
+		GasLimitMultiplier: 1.5,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	from := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	value := big.NewInt(1000)
+	ops := transferOps(from.Hex(), to.Hex(), value, wemix.Currency)
+
+	// A gas price high enough that the fee overflows int64.
+	gasPrice, ok := new(big.Int).SetString("1000000000000000", 10)
+	assert.True(t, ok)
+
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(1), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(gasPrice, nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: value},
+	).Return(
+		uint64(50001),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options: forceMarshalMap(t, &options{
+			From:  from.Hex(),
+			To:    to.Hex(),
+			Value: value,
+		}),
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionMetadataResponse{
+		Metadata: forceMarshalMap(t, &metadata{
+			Nonce:    1,
+			GasPrice: gasPrice,
+			GasLimit: 75002,
+		}),
+		SuggestedFee: []*types.Amount{
+			{
+				Value:    "75002000000000000000",
+				Currency: wemix.Currency,
+			},
+		},
+	}, metadataResponse)
+
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, uint64(75002), unsignedTx.GasLimit)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_FeePriority(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	from := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	value := big.NewInt(1000)
+	ops := transferOps(from.Hex(), to.Hex(), value, wemix.Currency)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"dynamic_fee":    true,
+				"priority":       "fast",
+				"fee_multiplier": 1.5,
+				"max_fee":        "10000000000000000",
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:          from.Hex(),
+			To:            to.Hex(),
+			Value:         value,
+			DynamicFee:    true,
+			Priority:      "fast",
+			FeeMultiplier: 1.5,
+			MaxFee:        big.NewInt(10000000000000000),
+		}),
+	}, preprocessResponse)
+
+	// Test Metadata
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(0), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: value},
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
+	mockClient.On(
+		"FeeHistory",
+		ctx,
+		uint64(feeHistoryBlocks),
+		(*big.Int)(nil),
+		feeHistoryPercentiles,
+	).Return(
+		&wemix.FeeHistory{
+			OldestBlock: big.NewInt(100),
+			Reward: [][]*big.Int{
+				{big.NewInt(1000000000), big.NewInt(2000000000), big.NewInt(3000000000)},
+				{big.NewInt(1000000000), big.NewInt(2000000000), big.NewInt(5000000000)},
+			},
+			BaseFee:      []*big.Int{big.NewInt(90000000000), big.NewInt(100000000000), big.NewInt(100000000000)},
+			GasUsedRatio: []float64{0.5, 0.5},
+		},
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionMetadataResponse{
+		Metadata: forceMarshalMap(t, &metadata{
+			Nonce:                0,
+			GasPrice:             big.NewInt(156000000000),
+			GasLimit:             21000,
+			MaxFeePerGas:         big.NewInt(306000000000),
+			MaxPriorityFeePerGas: big.NewInt(6000000000),
+		}),
+		SuggestedFee: []*types.Amount{
+			{
+				Value:    "6426000000000000",
+				Currency: wemix.Currency,
+			},
+		},
+	}, metadataResponse)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_FeeCap(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	from := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	value := big.NewInt(1000)
+	ops := transferOps(from.Hex(), to.Hex(), value, wemix.Currency)
+
+	// Test invalid fee settings
+	for _, input := range []map[string]interface{}{
+		{"priority": "urgent"},
+		{"fee_multiplier": -1},
+		{"gas_price": "not a number"},
+		{"gas_price": "100", "priority": "fast"},
+		{"max_fee": "-1"},
+	} {
+		preprocessResponse, rErr := servicer.ConstructionPreprocess(ctx, &types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata:          input,
+		})
+		assert.Nil(t, preprocessResponse)
+		assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+	}
+
+	// Test gas price override above the fee cap
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"gas_price": "0x174876e800",
+				"max_fee":   "2000000000000000",
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:     from.Hex(),
+			To:       to.Hex(),
+			Value:    value,
+			GasPrice: big.NewInt(100000000000),
+			MaxFee:   big.NewInt(2000000000000000),
+		}),
+	}, preprocessResponse)
+
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: value},
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, metadataResponse)
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_Nonce(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	from := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	value := big.NewInt(1000)
+	ops := transferOps(from.Hex(), to.Hex(), value, wemix.Currency)
+
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"nonce": "7",
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	nonce := uint64(7)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:  from.Hex(),
+			To:    to.Hex(),
+			Value: value,
+			Nonce: &nonce,
+		}),
+	}, preprocessResponse)
+
+	// The pinned nonce is used instead of the pending nonce.
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: value},
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, forceMarshalMap(t, &metadata{
+		Nonce:    7,
+		GasPrice: big.NewInt(100000000000),
+		GasLimit: 21000,
+	}), metadataResponse.Metadata)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_SpeedUp(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	from := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	value := big.NewInt(1000)
+	ops := transferOps(from.Hex(), to.Hex(), value, wemix.Currency)
+	pendingTx := ethTypes.NewTransaction(4, to, value, 21000, big.NewInt(100000000000), nil)
+
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"replace_hash": pendingTx.Hash().Hex(),
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:        from.Hex(),
+			To:          to.Hex(),
+			Value:       value,
+			ReplaceHash: pendingTx.Hash().Hex(),
+			ReplaceMode: speedUpReplacement,
+		}),
+	}, preprocessResponse)
+
+	// The replacement reuses the nonce and bumps the price
+	// above the suggestion.
+	mockClient.On("PendingTransaction", ctx, pendingTx.Hash()).Return(pendingTx, from, nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(80000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: value},
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionMetadataResponse{
+		Metadata: forceMarshalMap(t, &metadata{
+			Nonce:    4,
+			GasPrice: big.NewInt(110000000000),
+			GasLimit: 21000,
+		}),
+		SuggestedFee: []*types.Amount{
+			{
+				Value:    "2310000000000000",
+				Currency: wemix.Currency,
+			},
+		},
+	}, metadataResponse)
+
+	// A speed-up must keep the intent of the pending transaction.
+	otherOps := transferOps(from.Hex(), to.Hex(), big.NewInt(1), wemix.Currency)
+	preprocessResponse, rErr = servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        otherOps,
+			Metadata: map[string]interface{}{
+				"replace_hash": pendingTx.Hash().Hex(),
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	mockClient.On("PendingTransaction", ctx, pendingTx.Hash()).Return(pendingTx, from, nil).Once()
+	metadataResponse, rErr = servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, metadataResponse)
+	assert.Equal(t, ErrInvalidInput.Code, rErr.Code)
+
+	// Mined transactions cannot be replaced.
+	mockClient.On(
+		"PendingTransaction",
+		ctx,
+		pendingTx.Hash(),
+	).Return(
+		nil,
+		common.Address{},
+		wemix.ErrTransactionNotPending,
+	).Once()
+	metadataResponse, rErr = servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, metadataResponse)
+	assert.Equal(t, ErrTransactionNotPending.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_Cancel(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	from := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	pendingTx := ethTypes.NewTx(&ethTypes.DynamicFeeTx{
+		ChainID:   big.NewInt(1112),
+		Nonce:     4,
+		GasTipCap: big.NewInt(2000000000),
+		GasFeeCap: big.NewInt(200000000000),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1000),
+	})
+
+	// Cancellations must be zero-value self-transfers.
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        transferOps(from.Hex(), to.Hex(), big.NewInt(0), wemix.Currency),
+			Metadata: map[string]interface{}{
+				"replace_hash": pendingTx.Hash().Hex(),
+				"replace_mode": "cancel",
+			},
+		},
+	)
+	assert.Nil(t, preprocessResponse)
+	assert.Equal(t, ErrUnclearIntent.Code, rErr.Code)
+
+	ops := transferOps(from.Hex(), from.Hex(), big.NewInt(0), wemix.Currency)
+	preprocessResponse, rErr = servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"dynamic_fee":  true,
+				"replace_hash": pendingTx.Hash().Hex(),
+				"replace_mode": "cancel",
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+
+	mockClient.On("PendingTransaction", ctx, pendingTx.Hash()).Return(pendingTx, from, nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(101000000000), nil).Once()
+	mockClient.On("SuggestGasTipCap", ctx).Return(big.NewInt(1000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		mock.MatchedBy(func(msg ethereum.CallMsg) bool {
+			return msg.From == from && *msg.To == from && msg.Value.Sign() == 0
+		}),
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, forceMarshalMap(t, &metadata{
+		Nonce:                4,
+		GasPrice:             big.NewInt(220000000000),
+		GasLimit:             21000,
+		MaxFeePerGas:         big.NewInt(220000000000),
+		MaxPriorityFeePerGas: big.NewInt(2200000000),
+	}), metadataResponse.Metadata)
+
+	// Test Payloads
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+
+	var unsignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(payloadsResponse.UnsignedTransaction), &unsignedTx))
+	assert.Equal(t, from.Hex(), unsignedTx.To)
+	assert.Equal(t, uint64(4), unsignedTx.Nonce)
+	assert.Zero(t, unsignedTx.Value.Sign())
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_SubmitPreflight(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:            configuration.Online,
+		Network:         networkIdentifier,
+		Params:          params.WemixTestnetChainConfig,
+		SubmitPreflight: true,
+	}
+
+	ctx := context.Background()
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+
+	signedTx := func(chainID int64) string {
+		tx, err := ethTypes.SignNewTx(
+			privateKey,
+			ethTypes.NewLondonSigner(big.NewInt(chainID)),
+			&ethTypes.LegacyTx{
+				Nonce:    3,
+				GasPrice: big.NewInt(100000000000),
+				Gas:      50000,
+				To:       &to,
+				Value:    big.NewInt(1000),
+				Data:     []byte{0x01},
+			},
+		)
+		assert.NoError(t, err)
+
+		txJSON, err := tx.MarshalJSON()
+		assert.NoError(t, err)
+		return string(txJSON)
+	}
+	msg := ethereum.CallMsg{
+		From:  from,
+		To:    &to,
+		Gas:   50000,
+		Value: big.NewInt(1000),
+		Data:  []byte{0x01},
+	}
+	cost := big.NewInt(5000000000001000)
+
+	var tests = map[string]struct {
+		chainID int64
+		mock    func(*mocks.Client)
+
+		err           *types.Error
+		revertReason  string
+		sendsToGwemix bool
+	}{
+		"wrong chain": {
+			chainID: 1111,
+			mock:    func(*mocks.Client) {},
+			err:     ErrInvalidChainID,
+		},
+		"nonce too low": {
+			chainID: 1112,
+			mock: func(c *mocks.Client) {
+				c.On("PendingNonceAt", ctx, from).Return(uint64(4), nil).Once()
+			},
+			err: ErrNonceTooLow,
+		},
+		"insufficient funds": {
+			chainID: 1112,
+			mock: func(c *mocks.Client) {
+				c.On("PendingNonceAt", ctx, from).Return(uint64(3), nil).Once()
+				c.On("PendingBalanceAt", ctx, from).Return(new(big.Int).Sub(cost, big.NewInt(1)), nil).Once()
+			},
+			err: ErrInsufficientFunds,
+		},
+		"reverted": {
+			chainID: 1112,
+			mock: func(c *mocks.Client) {
+				c.On("PendingNonceAt", ctx, from).Return(uint64(3), nil).Once()
+				c.On("PendingBalanceAt", ctx, from).Return(cost, nil).Once()
+				c.On("PendingCallContract", ctx, msg).Return(
+					nil,
+					&wemix.RevertError{Reason: "not allowed"},
+				).Once()
+			},
+			err:          ErrTransactionReverted,
+			revertReason: "not allowed",
+		},
+		"valid": {
+			chainID: 1112,
+			mock: func(c *mocks.Client) {
+				c.On("PendingNonceAt", ctx, from).Return(uint64(1), nil).Once()
+				c.On("PendingBalanceAt", ctx, from).Return(cost, nil).Once()
+				c.On("PendingCallContract", ctx, msg).Return([]byte{}, nil).Once()
+				c.On("SendTransaction", ctx, mock.Anything).Return(nil).Once()
+			},
+			sendsToGwemix: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			servicer := NewConstructionAPIService(cfg, mockClient)
+			test.mock(mockClient)
+
+			resp, rErr := servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+				NetworkIdentifier: networkIdentifier,
+				SignedTransaction: signedTx(test.chainID),
+			})
+			if test.err != nil {
+				assert.Nil(t, resp)
+				assert.Equal(t, test.err.Code, rErr.Code)
+				if len(test.revertReason) > 0 {
+					assert.Equal(t, test.revertReason, rErr.Details["revert_reason"])
+				}
+			} else {
+				assert.Nil(t, rErr)
+				assert.NotNil(t, resp)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConstructionService_SubmitErrors(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+
+	ctx := context.Background()
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tx, err := ethTypes.SignNewTx(
+		privateKey,
+		ethTypes.NewLondonSigner(big.NewInt(1112)),
+		&ethTypes.LegacyTx{
+			Nonce:    3,
+			GasPrice: big.NewInt(100000000000),
+			Gas:      21000,
+			To:       &to,
+			Value:    big.NewInt(1000),
+		},
+	)
+	assert.NoError(t, err)
+	signedTx, err := tx.MarshalJSON()
+	assert.NoError(t, err)
+
+	var tests = map[string]struct {
+		sendErr error
+		err     *types.Error
+	}{
+		"nonce too low": {
+			sendErr: errors.New("nonce too low"),
+			err:     ErrNonceTooLow,
+		},
+		"insufficient funds": {
+			sendErr: errors.New("insufficient funds for gas * price + value"),
+			err:     ErrInsufficientFunds,
+		},
+		"replacement underpriced": {
+			sendErr: errors.New("replacement transaction underpriced"),
+			err:     ErrReplacementUnderpriced,
+		},
+		"underpriced": {
+			sendErr: errors.New("transaction underpriced"),
+			err:     ErrTransactionUnderpriced,
+		},
+		"already known": {
+			sendErr: errors.New("already known"),
+			err:     ErrTransactionAlreadyKnown,
+		},
+		"exceeds block gas limit": {
+			sendErr: errors.New("exceeds block gas limit"),
+			err:     ErrExceedsBlockGasLimit,
+		},
+		"intrinsic gas too low": {
+			sendErr: errors.New("intrinsic gas too low"),
+			err:     ErrIntrinsicGasTooLow,
+		},
+		"txpool full": {
+			sendErr: errors.New("txpool is full"),
+			err:     ErrTxPoolFull,
+		},
+		"timeout": {
+			sendErr: context.DeadlineExceeded,
+			err:     ErrGwemixUnavailable,
+		},
+		"unknown": {
+			sendErr: errors.New("boom"),
+			err:     ErrBroadcastFailed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			servicer := NewConstructionAPIService(cfg, mockClient)
+			mockClient.On("SendTransaction", ctx, mock.Anything).Return(test.sendErr).Once()
+
+			resp, rErr := servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+				NetworkIdentifier: networkIdentifier,
+				SignedTransaction: string(signedTx),
+			})
+			assert.Nil(t, resp)
+			assert.Equal(t, test.err.Code, rErr.Code)
+			assert.Equal(t, test.err.Retriable, rErr.Retriable)
+			assert.Equal(t, test.sendErr.Error(), rErr.Details["context"])
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+func TestConstructionService_CombineSignatures(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Offline,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	otherKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	unsignedTx := &transaction{
+		From:     from.Hex(),
+		To:       "0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+		Value:    big.NewInt(1000),
+		Data:     []byte{},
+		Nonce:    1,
+		GasPrice: big.NewInt(100000000000),
+		GasLimit: 21000,
+		ChainID:  big.NewInt(1112),
+	}
+	unsignedTxJSON, err := json.Marshal(unsignedTx)
+	assert.NoError(t, err)
+
+	payload := &types.SigningPayload{
+		AccountIdentifier: &types.AccountIdentifier{Address: from.Hex()},
+		Bytes:             ethTypes.NewLondonSigner(big.NewInt(1112)).Hash(ethTransaction(unsignedTx)).Bytes(),
+		SignatureType:     types.EcdsaRecovery,
+	}
+
+	var tests = map[string]struct {
+		signatures func() []*types.Signature
+		err        *types.Error
+	}{
+		"valid": {
+			signatures: func() []*types.Signature {
+				return []*types.Signature{forceSign(t, privateKey, payload)}
+			},
+		},
+		"valid without public key": {
+			signatures: func() []*types.Signature {
+				sig := forceSign(t, privateKey, payload)
+				sig.PublicKey = nil
+				return []*types.Signature{sig}
+			},
+		},
+		"no signatures": {
+			signatures: func() []*types.Signature { return nil },
+			err:        ErrSignatureInvalid,
+		},
+		"too many signatures": {
+			signatures: func() []*types.Signature {
+				sig := forceSign(t, privateKey, payload)
+				return []*types.Signature{sig, sig}
+			},
+			err: ErrSignatureInvalid,
+		},
+		"wrong signature type": {
+			signatures: func() []*types.Signature {
+				sig := forceSign(t, privateKey, payload)
+				sig.SignatureType = types.Ecdsa
+				sig.Bytes = sig.Bytes[:64]
+				return []*types.Signature{sig}
+			},
+			err: ErrSignatureInvalid,
+		},
+		"wrong payload": {
+			signatures: func() []*types.Signature {
+				other := *payload
+				other.Bytes = crypto.Keccak256([]byte("other"))
+				return []*types.Signature{forceSign(t, privateKey, &other)}
+			},
+			err: ErrSignatureInvalid,
+		},
+		"wrong payload account": {
+			signatures: func() []*types.Signature {
+				sig := forceSign(t, privateKey, payload)
+				sig.SigningPayload = &types.SigningPayload{
+					AccountIdentifier: &types.AccountIdentifier{
+						Address: crypto.PubkeyToAddress(otherKey.PublicKey).Hex(),
+					},
+					Bytes:         payload.Bytes,
+					SignatureType: types.EcdsaRecovery,
+				}
+				return []*types.Signature{sig}
+			},
+			err: ErrSignatureInvalid,
+		},
+		"wrong signer": {
+			signatures: func() []*types.Signature {
+				return []*types.Signature{forceSign(t, otherKey, payload)}
+			},
+			err: ErrSignatureInvalid,
+		},
+		"wrong public key": {
+			signatures: func() []*types.Signature {
+				sig := forceSign(t, privateKey, payload)
+				sig.PublicKey.Bytes = crypto.CompressPubkey(&otherKey.PublicKey)
+				return []*types.Signature{sig}
+			},
+			err: ErrSignatureInvalid,
+		},
+		"wrong curve": {
+			signatures: func() []*types.Signature {
+				sig := forceSign(t, privateKey, payload)
+				sig.PublicKey.CurveType = types.Edwards25519
+				return []*types.Signature{sig}
+			},
+			err: ErrSignatureInvalid,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+				NetworkIdentifier:   networkIdentifier,
+				UnsignedTransaction: string(unsignedTxJSON),
+				Signatures:          test.signatures(),
+			})
+			if test.err != nil {
+				assert.Nil(t, resp)
+				assert.Equal(t, test.err.Code, rErr.Code)
+				assert.NotEmpty(t, rErr.Details["context"])
+				return
+			}
+
+			assert.Nil(t, rErr)
+			signedTx := new(ethTypes.Transaction)
+			assert.NoError(t, signedTx.UnmarshalJSON([]byte(resp.SignedTransaction)))
+			sender, err := ethTypes.Sender(ethTypes.NewLondonSigner(big.NewInt(1112)), signedTx)
+			assert.NoError(t, err)
+			assert.Equal(t, from, sender)
+		})
+	}
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_RawSignedTx(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:        configuration.Online,
+		Network:     networkIdentifier,
+		Params:      params.WemixTestnetChainConfig,
+		RawSignedTx: true,
+	}
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+
+	for name, unsignedTx := range map[string]*transaction{
+		"legacy": {
+			From:     from.Hex(),
+			To:       "0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+			Value:    big.NewInt(1000),
+			Data:     []byte{},
+			Nonce:    1,
+			GasPrice: big.NewInt(100000000000),
+			GasLimit: 21000,
+			ChainID:  big.NewInt(1112),
+		},
+		"dynamic fee": {
+			From:                 from.Hex(),
+			To:                   "0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+			Value:                big.NewInt(1000),
+			Data:                 []byte{},
+			Nonce:                2,
+			GasPrice:             big.NewInt(101000000000),
+			GasLimit:             21000,
+			ChainID:              big.NewInt(1112),
+			MaxFeePerGas:         big.NewInt(101000000000),
+			MaxPriorityFeePerGas: big.NewInt(1000000000),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			unsignedTxJSON, err := json.Marshal(unsignedTx)
+			assert.NoError(t, err)
+
+			ethTx := ethTransaction(unsignedTx)
+			payload := &types.SigningPayload{
+				AccountIdentifier: &types.AccountIdentifier{Address: from.Hex()},
+				Bytes:             ethTypes.NewLondonSigner(big.NewInt(1112)).Hash(ethTx).Bytes(),
+				SignatureType:     types.EcdsaRecovery,
+			}
+
+			// Test Combine
+			combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+				NetworkIdentifier:   networkIdentifier,
+				UnsignedTransaction: string(unsignedTxJSON),
+				Signatures:          []*types.Signature{forceSign(t, privateKey, payload)},
+			})
+			assert.Nil(t, rErr)
+			rawTx := combineResponse.SignedTransaction
+
+			signedTx := new(ethTypes.Transaction)
+			assert.NoError(t, signedTx.UnmarshalBinary(forceHexDecode(t, rawTx[2:])))
+			assert.Equal(t, ethTx.Type(), signedTx.Type())
+			signedTxJSON, err := signedTx.MarshalJSON()
+			assert.NoError(t, err)
+
+			// Test Hash with both encodings
+			for _, encoded := range []string{rawTx, string(signedTxJSON)} {
+				hashResponse, rErr := servicer.ConstructionHash(ctx, &types.ConstructionHashRequest{
+					NetworkIdentifier: networkIdentifier,
+					SignedTransaction: encoded,
+				})
+				assert.Nil(t, rErr)
+				assert.Equal(t, signedTx.Hash().Hex(), hashResponse.TransactionIdentifier.Hash)
+			}
+
+			// Test Parse Signed
+			parseResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+				NetworkIdentifier: networkIdentifier,
+				Signed:            true,
+				Transaction:       rawTx,
+			})
+			assert.Nil(t, rErr)
+			assert.Equal(t, []*types.AccountIdentifier{{Address: from.Hex()}}, parseResponse.AccountIdentifierSigners)
+
+			// Test Submit
+			mockClient.On(
+				"SendTransaction",
+				ctx,
+				mock.MatchedBy(func(tx *ethTypes.Transaction) bool {
+					return tx.Hash() == signedTx.Hash()
+				}),
+			).Return(nil).Once()
+			submitResponse, rErr := servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+				NetworkIdentifier: networkIdentifier,
+				SignedTransaction: rawTx,
+			})
+			assert.Nil(t, rErr)
+			assert.Equal(t, signedTx.Hash().Hex(), submitResponse.TransactionIdentifier.Hash)
+		})
+	}
+
+	// Malformed raw bytes are rejected.
+	hashResponse, rErr := servicer.ConstructionHash(ctx, &types.ConstructionHashRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: "0x02f8",
+	})
+	assert.Nil(t, hashResponse)
+	assert.Equal(t, ErrUnableToParseIntermediateResult.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_ParseTransactionTypes(t *testing.T) {
+	token := &types.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			wemix.ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+		Tokens:  []*types.Currency{token},
+	}
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	feePayerKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tokenAddress := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	chainID := big.NewInt(1112)
+	amount := big.NewInt(1000)
+	callData := forceHexDecode(t, "60fe47b10000000000000000000000000000000000000000000000000000000000000010")
+
+	intents := map[string]struct {
+		to    *common.Address
+		value *big.Int
+		data  []byte
+		ops   []*types.Operation
+	}{
+		"native transfer": {
+			to:    &to,
+			value: amount,
+			ops:   transferOps(from.Hex(), to.Hex(), amount, wemix.Currency),
+		},
+		"erc20 transfer": {
+			to:    &tokenAddress,
+			value: big.NewInt(0),
+			data:  wemix.Erc20TransferData(to, amount),
+			ops:   transferOps(from.Hex(), to.Hex(), amount, token),
+		},
+		"contract call": {
+			to:    &to,
+			value: big.NewInt(0),
+			data:  callData,
+			ops:   transferOps(from.Hex(), to.Hex(), big.NewInt(0), wemix.Currency),
+		},
+		"contract deployment": {
+			value: amount,
+			data:  forceHexDecode(t, "6080604052"),
+			ops:   createOps(from.Hex(), amount),
+		},
+	}
+
+	accessList := ethTypes.AccessList{{Address: to, StorageKeys: []common.Hash{{1}}}}
+	txTypes := map[string]func(to *common.Address, value *big.Int, data []byte) ethTypes.TxData{
+		"legacy": func(to *common.Address, value *big.Int, data []byte) ethTypes.TxData {
+			return &ethTypes.LegacyTx{Nonce: 1, GasPrice: big.NewInt(100), Gas: 100000, To: to, Value: value, Data: data}
+		},
+		"access list": func(to *common.Address, value *big.Int, data []byte) ethTypes.TxData {
+			return &ethTypes.AccessListTx{
+				ChainID: chainID, Nonce: 1, GasPrice: big.NewInt(100), Gas: 100000,
+				To: to, Value: value, Data: data, AccessList: accessList,
+			}
+		},
+		"dynamic fee": func(to *common.Address, value *big.Int, data []byte) ethTypes.TxData {
+			return &ethTypes.DynamicFeeTx{
+				ChainID: chainID, Nonce: 1, GasTipCap: big.NewInt(1), GasFeeCap: big.NewInt(100), Gas: 100000,
+				To: to, Value: value, Data: data,
+			}
+		},
+	}
+
+	for typeName, txData := range txTypes {
+		for intentName, intent := range intents {
+			t.Run(typeName+" "+intentName, func(t *testing.T) {
+				signedTx, err := ethTypes.SignNewTx(
+					privateKey,
+					ethTypes.NewLondonSigner(chainID),
+					txData(intent.to, intent.value, intent.data),
+				)
+				assert.NoError(t, err)
+				rawTx, err := signedTx.MarshalBinary()
+				assert.NoError(t, err)
+
+				parseResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+					NetworkIdentifier: networkIdentifier,
+					Signed:            true,
+					Transaction:       hexutil.Encode(rawTx),
+				})
+				assert.Nil(t, rErr)
+				assert.Equal(t, intent.ops, parseResponse.Operations)
+				assert.Equal(t, []*types.AccountIdentifier{{Address: from.Hex()}}, parseResponse.AccountIdentifierSigners)
+				assert.Equal(t, hexutil.EncodeUint64(uint64(signedTx.Type())), parseResponse.Metadata["type"])
+				if intent.to == nil {
+					assert.Equal(
+						t,
+						crypto.CreateAddress(from, 1).Hex(),
+						parseResponse.Metadata["contract_address"],
+					)
+				}
+			})
+		}
+	}
+
+	// Fee-delegated transactions are signed by their
+	// sender and fee payer.
+	senderTx, err := ethTypes.SignNewTx(
+		privateKey,
+		ethTypes.NewLondonSigner(chainID),
+		txTypes["dynamic fee"](&tokenAddress, big.NewInt(0), wemix.Erc20TransferData(to, amount)),
+	)
+	assert.NoError(t, err)
+	feeDelegatedTx := &wemix.FeeDelegatedTx{SenderTx: senderTx, FeePayer: feePayer}
+	feePayerHash, err := feeDelegatedTx.FeePayerHash()
+	assert.NoError(t, err)
+	sig, err := crypto.Sign(feePayerHash.Bytes(), feePayerKey)
+	assert.NoError(t, err)
+	feeDelegatedTx.FR = new(big.Int).SetBytes(sig[:32])
+	feeDelegatedTx.FS = new(big.Int).SetBytes(sig[32:64])
+	feeDelegatedTx.FV = big.NewInt(int64(sig[64]))
+	rawTx, err := feeDelegatedTx.MarshalBinary()
+	assert.NoError(t, err)
+
+	parseResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       hexutil.Encode(rawTx),
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, transferOps(from.Hex(), to.Hex(), amount, token), parseResponse.Operations)
+	assert.Equal(
+		t,
+		[]*types.AccountIdentifier{{Address: from.Hex()}, {Address: feePayer.Hex()}},
+		parseResponse.AccountIdentifierSigners,
+	)
+	assert.Equal(t, "0x16", parseResponse.Metadata["type"])
+	assert.Equal(t, feePayer.Hex(), parseResponse.Metadata["fee_payer"])
+
+	hashResponse, rErr := servicer.ConstructionHash(ctx, &types.ConstructionHashRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: hexutil.Encode(rawTx),
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, crypto.Keccak256Hash(rawTx).Hex(), hashResponse.TransactionIdentifier.Hash)
+
+	// A fee payer signature over another fee payer is rejected.
+	feeDelegatedTx.FeePayer = to
+	rawTx, err = feeDelegatedTx.MarshalBinary()
+	assert.NoError(t, err)
+	parseResponse, rErr = servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       hexutil.Encode(rawTx),
+	})
+	assert.Nil(t, parseResponse)
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+}
+
+func TestConstructionService_OfflineMetadata(t *testing.T) {
+	bundle, err := configuration.LoadMetadataBundle("../configuration/testdata/metadata_bundle.json")
+	assert.NoError(t, err)
+	bundle.Timestamp = time.Now().Unix()
+	cfg := &configuration.Configuration{
+		Mode:                 configuration.Offline,
+		Network:              networkIdentifier,
+		Params:               params.WemixTestnetChainConfig,
+		MetadataBundle:       bundle,
+		MetadataBundleMaxAge: time.Hour,
+	}
+	mockClient := &mocks.Client{}
+	ctx := context.Background()
+
+	from := "0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"
+	to := "0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d"
+	pinnedNonce := uint64(9)
+	accessList := ethTypes.AccessList{
+		{
+			Address:     common.HexToAddress(to),
+			StorageKeys: []common.Hash{common.HexToHash("0x01")},
+		},
+	}
+
+	var tests = map[string]struct {
+		options *options
+
+		metadata     *metadata
+		suggestedFee string
+		err          *types.Error
+	}{
+		"transfer": {
+			options: &options{From: from, To: to, Value: big.NewInt(1000)},
+			metadata: &metadata{
+				Nonce:    7,
+				GasPrice: big.NewInt(101000000000),
+				GasLimit: 21000,
+			},
+			suggestedFee: "2121000000000000",
+		},
+		"dynamic fee with pinned nonce": {
+			options: &options{
+				From:       from,
+				To:         to,
+				Value:      big.NewInt(1000),
+				DynamicFee: true,
+				Nonce:      &pinnedNonce,
+			},
+			metadata: &metadata{
+				Nonce:                9,
+				GasPrice:             big.NewInt(101000000000),
+				GasLimit:             21000,
+				MaxFeePerGas:         big.NewInt(201000000000),
+				MaxPriorityFeePerGas: big.NewInt(1000000000),
+			},
+			suggestedFee: "4221000000000000",
+		},
+		"contract call": {
+			options: &options{From: from, To: to, Value: big.NewInt(0), Data: []byte{0x01}},
+			metadata: &metadata{
+				Nonce:    7,
+				GasPrice: big.NewInt(101000000000),
+				GasLimit: 100000,
+				Data:     []byte{0x01},
+			},
+			suggestedFee: "10100000000000000",
+		},
+		"access list": {
+			options: &options{From: from, To: to, Value: big.NewInt(1000), AccessList: &accessList},
+			metadata: &metadata{
+				Nonce:      7,
+				GasPrice:   big.NewInt(101000000000),
+				GasLimit:   25300,
+				AccessList: &accessList,
+			},
+			suggestedFee: "2555300000000000",
+		},
+		"generated access list": {
+			options: &options{From: from, To: to, Value: big.NewInt(1000), CreateAccessList: true},
+			err:     ErrUnavailableOffline,
+		},
+		"unknown account": {
+			options: &options{From: to, To: from, Value: big.NewInt(1000)},
+			err:     ErrUnavailableOffline,
+		},
+		"fee priority": {
+			options: &options{From: from, To: to, Value: big.NewInt(1000), Priority: fastPriority},
+			err:     ErrUnavailableOffline,
+		},
+		"replacement": {
+			options: &options{
+				From:        from,
+				To:          to,
+				Value:       big.NewInt(1000),
+				ReplaceHash: "0x6e8d525fa1271b71f47e4f42bc2982ed7aecdfebfb56bc0d3d65cbf5521c9a3d",
+				ReplaceMode: cancelReplacement,
+			},
+			err: ErrUnavailableOffline,
+		},
+		"max fee exceeded": {
+			options: &options{From: from, To: to, Value: big.NewInt(1000), MaxFee: big.NewInt(1)},
+			err:     ErrInvalidInput,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			servicer := NewConstructionAPIService(cfg, mockClient)
+			resp, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+				NetworkIdentifier: networkIdentifier,
+				Options:           forceMarshalMap(t, test.options),
+			})
+			if test.err != nil {
+				assert.Nil(t, resp)
+				assert.Equal(t, test.err.Code, rErr.Code)
+				return
+			}
+
+			assert.Nil(t, rErr)
+			assert.Equal(t, &types.ConstructionMetadataResponse{
+				Metadata: forceMarshalMap(t, test.metadata),
+				SuggestedFee: []*types.Amount{
+					{
+						Value:    test.suggestedFee,
+						Currency: wemix.Currency,
+					},
+				},
+			}, resp)
+		})
+	}
+
+	nonceOf := func(servicer *ConstructionAPIService, nonce *uint64) uint64 {
+		resp, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+			NetworkIdentifier: networkIdentifier,
+			Options: forceMarshalMap(t, &options{
+				From:  from,
+				To:    to,
+				Value: big.NewInt(1000),
+				Nonce: nonce,
+			}),
+		})
+		assert.Nil(t, rErr)
+
+		var output metadata
+		assert.NoError(t, unmarshalJSONMap(resp.Metadata, &output))
+		return output.Nonce
+	}
+
+	t.Run("nonces are not reused", func(t *testing.T) {
+		servicer := NewConstructionAPIService(cfg, mockClient)
+		assert.Equal(t, uint64(7), nonceOf(servicer, nil))
+		assert.Equal(t, uint64(8), nonceOf(servicer, nil))
+
+		// Nonces after a pinned nonce are handed out next,
+		// and pinning an older nonce does not go back.
+		newer, older := uint64(12), uint64(7)
+		assert.Equal(t, uint64(12), nonceOf(servicer, &newer))
+		assert.Equal(t, uint64(7), nonceOf(servicer, &older))
+		assert.Equal(t, uint64(13), nonceOf(servicer, nil))
+	})
+
+	t.Run("stale bundle", func(t *testing.T) {
+		servicer := NewConstructionAPIService(cfg, mockClient)
+		bundle.Timestamp = time.Now().Add(-2 * time.Hour).Unix()
+		defer func() { bundle.Timestamp = time.Now().Unix() }()
+
+		resp, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+			NetworkIdentifier: networkIdentifier,
+			Options:           forceMarshalMap(t, &options{From: from, To: to, Value: big.NewInt(1000)}),
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrUnavailableOffline.Code, rErr.Code)
+		assert.Contains(t, rErr.Details["context"], "metadata bundle is 2h")
+	})
+
+	// Without a bundle, metadata is unavailable offline.
+	cfg.MetadataBundle = nil
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	resp, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           forceMarshalMap(t, &options{From: from, To: to, Value: big.NewInt(1000)}),
+	})
+	assert.Nil(t, resp)
+	assert.Equal(t, ErrUnavailableOffline, rErr)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_FeeDelegated(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	feePayerKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	assert.NoError(t, err)
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	ops := transferOps(from.Hex(), to.Hex(), big.NewInt(1000), wemix.Currency)
+
+	// Test Preprocess
+	preprocessResponse, rErr := servicer.ConstructionPreprocess(
+		ctx,
+		&types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata: map[string]interface{}{
+				"fee_payer": "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23",
+			},
+		},
+	)
+	assert.Nil(t, rErr)
+	assert.Equal(t, &types.ConstructionPreprocessResponse{
+		Options: forceMarshalMap(t, &options{
+			From:       from.Hex(),
+			To:         to.Hex(),
+			Value:      big.NewInt(1000),
+			DynamicFee: true,
+			FeePayer:   feePayer.Hex(),
+		}),
+	}, preprocessResponse)
+
+	for name, metadata := range map[string]map[string]interface{}{
+		"invalid fee payer": {"fee_payer": "0x123"},
+		"safe fee payer":    {"fee_payer": feePayer.Hex(), "safe": true},
+	} {
+		_, rErr := servicer.ConstructionPreprocess(ctx, &types.ConstructionPreprocessRequest{
+			NetworkIdentifier: networkIdentifier,
+			Operations:        ops,
+			Metadata:          metadata,
+		})
+		assert.NotNil(t, rErr, name)
+	}
+
+	// Test Metadata
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(3), nil).Once()
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(101000000000), nil).Once()
+	mockClient.On("SuggestGasTipCap", ctx).Return(big.NewInt(1000000000), nil).Once()
+	mockClient.On(
+		"EstimateGas",
+		ctx,
+		ethereum.CallMsg{From: from, To: &to, Value: big.NewInt(1000)},
+	).Return(
+		uint64(21000),
+		nil,
+	).Once()
+	metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+		NetworkIdentifier: networkIdentifier,
+		Options:           preprocessResponse.Options,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, forceMarshalMap(t, &metadata{
+		Nonce:                3,
+		GasPrice:             big.NewInt(101000000000),
+		GasLimit:             21000,
+		MaxFeePerGas:         big.NewInt(201000000000),
+		MaxPriorityFeePerGas: big.NewInt(1000000000),
+		FeePayer:             feePayer.Hex(),
+	}), metadataResponse.Metadata)
+
+	// Test Payloads: the fee payer signs over the signed
+	// transaction of the sender, so only the sender signs
+	// the payloads.
+	payloadsResponse, rErr := servicer.ConstructionPayloads(ctx, &types.ConstructionPayloadsRequest{
+		NetworkIdentifier: networkIdentifier,
+		Operations:        ops,
+		Metadata:          metadataResponse.Metadata,
+	})
+	assert.Nil(t, rErr)
+	assert.Len(t, payloadsResponse.Payloads, 1)
+	senderPayload := payloadsResponse.Payloads[0]
+	assert.Equal(t, from.Hex(), senderPayload.AccountIdentifier.Address)
+
+	// Test Parse Unsigned
+	parseResponse, rErr := servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            false,
+		Transaction:       payloadsResponse.UnsignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, "0x16", parseResponse.Metadata["type"])
+	assert.Equal(t, feePayer.Hex(), parseResponse.Metadata["fee_payer"])
+	assert.Empty(t, parseResponse.AccountIdentifierSigners)
+
+	// A sender signature by another account is rejected.
+	_, rErr = servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures:          []*types.Signature{forceSign(t, feePayerKey, senderPayload)},
+	})
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+
+	// Test Combine: the sender signature yields
+	// the payload of the fee payer.
+	senderSignature := forceSign(t, privateKey, senderPayload)
+	combineResponse, rErr := servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: payloadsResponse.UnsignedTransaction,
+		Signatures:          []*types.Signature{senderSignature},
+	})
+	assert.Nil(t, rErr)
+
+	var senderSignedTx transaction
+	assert.NoError(t, json.Unmarshal([]byte(combineResponse.SignedTransaction), &senderSignedTx))
+	assert.Equal(t, senderSignature.Bytes, senderSignedTx.SenderSignature)
+	feePayerPayload := senderSignedTx.FeePayerPayload
+	assert.Equal(t, feePayer.Hex(), feePayerPayload.AccountIdentifier.Address)
+
+	senderTx, err := ethTypes.SignNewTx(
+		privateKey,
+		ethTypes.NewLondonSigner(big.NewInt(1112)),
+		&ethTypes.DynamicFeeTx{
+			ChainID:   big.NewInt(1112),
+			Nonce:     3,
+			GasTipCap: big.NewInt(1000000000),
+			GasFeeCap: big.NewInt(201000000000),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(1000),
+		},
+	)
+	assert.NoError(t, err)
+	expectedTx := &wemix.FeeDelegatedTx{SenderTx: senderTx, FeePayer: feePayer}
+	feePayerHash, err := expectedTx.FeePayerHash()
+	assert.NoError(t, err)
+	assert.Equal(t, feePayerHash.Bytes(), feePayerPayload.Bytes)
+
+	// A fee payer signature by another account is rejected.
+	_, rErr = servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: combineResponse.SignedTransaction,
+		Signatures:          []*types.Signature{forceSign(t, privateKey, feePayerPayload)},
+	})
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+
+	// Test Combine: the fee payer signature
+	// yields the raw transaction.
+	feePayerSignature := forceSign(t, feePayerKey, feePayerPayload)
+	combineResponse, rErr = servicer.ConstructionCombine(ctx, &types.ConstructionCombineRequest{
+		NetworkIdentifier:   networkIdentifier,
+		UnsignedTransaction: combineResponse.SignedTransaction,
+		Signatures:          []*types.Signature{feePayerSignature},
+	})
+	assert.Nil(t, rErr)
+
+	expectedTx.FR = new(big.Int).SetBytes(feePayerSignature.Bytes[:32])
+	expectedTx.FS = new(big.Int).SetBytes(feePayerSignature.Bytes[32:64])
+	expectedTx.FV = big.NewInt(int64(feePayerSignature.Bytes[64]))
+	expectedRaw, err := expectedTx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, hexutil.Encode(expectedRaw), combineResponse.SignedTransaction)
+
+	// Test Parse Signed
+	parseResponse, rErr = servicer.ConstructionParse(ctx, &types.ConstructionParseRequest{
+		NetworkIdentifier: networkIdentifier,
+		Signed:            true,
+		Transaction:       combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, ops, parseResponse.Operations)
+	assert.Equal(t, []*types.AccountIdentifier{
+		{Address: from.Hex()},
+		{Address: feePayer.Hex()},
+	}, parseResponse.AccountIdentifierSigners)
+
+	// Test Hash
+	hashResponse, rErr := servicer.ConstructionHash(ctx, &types.ConstructionHashRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, crypto.Keccak256Hash(expectedRaw).Hex(), hashResponse.TransactionIdentifier.Hash)
+
+	// Test Submit
+	mockClient.On("SendRawTransaction", ctx, expectedRaw).Return(errors.New("connection refused")).Once()
+	_, rErr = servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Equal(t, ErrBroadcastFailed.Code, rErr.Code)
+
+	mockClient.On("SendRawTransaction", ctx, expectedRaw).Return(nil).Once()
+	submitResponse, rErr := servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: combineResponse.SignedTransaction,
+	})
+	assert.Nil(t, rErr)
+	assert.Equal(t, hashResponse, submitResponse)
+
+	// A fee payer signature over another fee payer is rejected.
+	expectedTx.FeePayer = to
+	rawTx, err := expectedTx.MarshalBinary()
+	assert.NoError(t, err)
+	_, rErr = servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: hexutil.Encode(rawTx),
+	})
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_SubmitFeeDelegated(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:            configuration.Online,
+		Network:         networkIdentifier,
+		Params:          params.WemixTestnetChainConfig,
+		SubmitPreflight: true,
+	}
+
+	ctx := context.Background()
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	feePayerKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	assert.NoError(t, err)
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+
+	senderTx, err := ethTypes.SignNewTx(
+		privateKey,
+		ethTypes.NewLondonSigner(big.NewInt(1112)),
+		&ethTypes.DynamicFeeTx{
+			ChainID:   big.NewInt(1112),
+			Nonce:     3,
+			GasTipCap: big.NewInt(100000000000),
+			GasFeeCap: big.NewInt(101000000000),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(1000),
+		},
+	)
+	assert.NoError(t, err)
+	feeDelegatedTx := &wemix.FeeDelegatedTx{SenderTx: senderTx, FeePayer: feePayer}
+	feePayerHash, err := feeDelegatedTx.FeePayerHash()
+	assert.NoError(t, err)
+	sig, err := crypto.Sign(feePayerHash.Bytes(), feePayerKey)
+	assert.NoError(t, err)
+	feeDelegatedTx.FR = new(big.Int).SetBytes(sig[:32])
+	feeDelegatedTx.FS = new(big.Int).SetBytes(sig[32:64])
+	feeDelegatedTx.FV = big.NewInt(int64(sig[64]))
+	rawTx, err := feeDelegatedTx.MarshalBinary()
+	assert.NoError(t, err)
+	fee := big.NewInt(2121000000000000)
+
+	var tests = map[string]struct {
+		mock func(*mocks.Client)
+
+		err *types.Error
+	}{
+		"fee payer pays the fee": {
+			mock: func(c *mocks.Client) {
+				c.On("PendingBalanceAt", ctx, feePayer).Return(fee, nil).Once()
+				c.On("PendingBalanceAt", ctx, from).Return(big.NewInt(1000), nil).Once()
+				c.On("PendingCallContract", ctx, mock.Anything).Return([]byte{}, nil).Once()
+				c.On("SendRawTransaction", ctx, rawTx).Return(nil).Once()
+			},
+		},
+		"fee payer cannot pay": {
+			mock: func(c *mocks.Client) {
+				c.On("PendingBalanceAt", ctx, feePayer).Return(new(big.Int).Sub(fee, big.NewInt(1)), nil).Once()
+			},
+			err: ErrInsufficientFunds,
+		},
+		"sender cannot pay the value": {
+			mock: func(c *mocks.Client) {
+				c.On("PendingBalanceAt", ctx, feePayer).Return(fee, nil).Once()
+				c.On("PendingBalanceAt", ctx, from).Return(big.NewInt(999), nil).Once()
+			},
+			err: ErrInsufficientFunds,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockClient := &mocks.Client{}
+			mockClient.On("PendingNonceAt", ctx, from).Return(uint64(3), nil).Once()
+			test.mock(mockClient)
+			servicer := NewConstructionAPIService(cfg, mockClient)
+
+			resp, rErr := servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+				NetworkIdentifier: networkIdentifier,
+				SignedTransaction: hexutil.Encode(rawTx),
+			})
+			if test.err != nil {
+				assert.Nil(t, resp)
+				assert.Equal(t, test.err.Code, rErr.Code)
+			} else {
+				assert.Nil(t, rErr)
+				assert.Equal(t, crypto.Keccak256Hash(rawTx).Hex(), resp.TransactionIdentifier.Hash)
+			}
+
+			mockClient.AssertExpectations(t)
+		})
+	}
+
+	// A fee payer signature over another fee payer is rejected.
+	feeDelegatedTx.FeePayer = to
+	rawTx, err = feeDelegatedTx.MarshalBinary()
+	assert.NoError(t, err)
+	servicer := NewConstructionAPIService(cfg, &mocks.Client{})
+	_, rErr := servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: hexutil.Encode(rawTx),
+	})
+	assert.Equal(t, ErrSignatureInvalid.Code, rErr.Code)
+}
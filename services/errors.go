@@ -15,7 +15,15 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/wemixarchive/rosetta-wemix/wemix"
+
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
 )
 
 var (
@@ -37,6 +45,20 @@ var (
 		ErrInvalidAddress,
 		ErrGwemixNotReady,
 		ErrInvalidInput,
+		ErrTransactionNotPending,
+		ErrInvalidChainID,
+		ErrNonceTooLow,
+		ErrInsufficientFunds,
+		ErrTransactionReverted,
+		ErrTransactionAlreadyKnown,
+		ErrReplacementUnderpriced,
+		ErrTransactionUnderpriced,
+		ErrExceedsBlockGasLimit,
+		ErrIntrinsicGasTooLow,
+		ErrTxPoolFull,
+		ErrNotFound,
+		ErrGwemixUnavailable,
+		ErrBatchNotSubmitted,
 	}
 
 	// ErrUnimplemented is returned when an endpoint
@@ -152,8 +174,134 @@ var (
 		Code:    14, //nolint
 		Message: "invalid input",
 	}
+
+	// ErrTransactionNotPending is returned when a transaction
+	// to replace or look up in the mempool is not waiting in
+	// the txpool.
+	ErrTransactionNotPending = &types.Error{
+		Code:    15, //nolint
+		Message: "Transaction not pending",
+	}
+
+	// ErrInvalidChainID is returned when a signed
+	// transaction is not for the configured chain.
+	ErrInvalidChainID = &types.Error{
+		Code:    16, //nolint
+		Message: "Invalid chain ID",
+	}
+
+	// ErrNonceTooLow is returned when the nonce of a
+	// signed transaction was already used.
+	ErrNonceTooLow = &types.Error{
+		Code:    17, //nolint
+		Message: "Nonce too low",
+	}
+
+	// ErrInsufficientFunds is returned when the sender
+	// cannot pay for the value and gas of a transaction.
+	ErrInsufficientFunds = &types.Error{
+		Code:    18, //nolint
+		Message: "Insufficient funds",
+	}
+
+	// ErrTransactionReverted is returned when the
+	// simulation of a transaction reverts.
+	ErrTransactionReverted = &types.Error{
+		Code:    19, //nolint
+		Message: "Transaction reverted",
+	}
+
+	// ErrTransactionAlreadyKnown is returned when the
+	// submitted transaction is already in the txpool.
+	// Submitting it again will not succeed, but it may
+	// still be included in a block.
+	ErrTransactionAlreadyKnown = &types.Error{
+		Code:    20, //nolint
+		Message: "Transaction already known",
+	}
+
+	// ErrReplacementUnderpriced is returned when a transaction
+	// replacing a pending one does not bump its fees enough.
+	ErrReplacementUnderpriced = &types.Error{
+		Code:    21, //nolint
+		Message: "Replacement transaction underpriced",
+	}
+
+	// ErrTransactionUnderpriced is returned when the fees
+	// of a transaction are below the minimum accepted
+	// by the txpool.
+	ErrTransactionUnderpriced = &types.Error{
+		Code:    22, //nolint
+		Message: "Transaction underpriced",
+	}
+
+	// ErrExceedsBlockGasLimit is returned when the gas
+	// limit of a transaction exceeds the block gas limit.
+	ErrExceedsBlockGasLimit = &types.Error{
+		Code:    23, //nolint
+		Message: "Exceeds block gas limit",
+	}
+
+	// ErrIntrinsicGasTooLow is returned when the gas limit
+	// of a transaction does not cover its intrinsic gas.
+	ErrIntrinsicGasTooLow = &types.Error{
+		Code:    24, //nolint
+		Message: "Intrinsic gas too low",
+	}
+
+	// ErrTxPoolFull is returned when the txpool has no
+	// room for the transaction. Submitting it again may
+	// succeed once the txpool drains.
+	ErrTxPoolFull = &types.Error{
+		Code:      25, //nolint
+		Message:   "Txpool is full",
+		Retriable: true,
+	}
+
+	// ErrNotFound is returned when the requested block
+	// or transaction is not known to gwemix.
+	ErrNotFound = &types.Error{
+		Code:    26, //nolint
+		Message: "Not found",
+	}
+
+	// ErrGwemixUnavailable is returned when gwemix
+	// cannot be reached or does not answer in time.
+	ErrGwemixUnavailable = &types.Error{
+		Code:      27, //nolint
+		Message:   "gwemix unavailable",
+		Retriable: true,
+	}
+
+	// ErrBatchNotSubmitted is returned for the transactions
+	// of a batch that follow one that could not be submitted,
+	// as their nonces would wait on its missing nonce.
+	ErrBatchNotSubmitted = &types.Error{
+		Code:    28, //nolint
+		Message: "Transaction not submitted after an earlier batch failure",
+	}
 )
 
+// broadcastErrors maps the messages gwemix returns when
+// it rejects a transaction to the types.Error describing
+// the rejection. Messages are matched in order, so a
+// message must come before any message it contains.
+var broadcastErrors = []struct {
+	message string
+	err     *types.Error
+}{
+	{"nonce too low", ErrNonceTooLow},
+	{"insufficient funds", ErrInsufficientFunds},
+	{"replacement transaction underpriced", ErrReplacementUnderpriced},
+	{"transaction underpriced", ErrTransactionUnderpriced},
+	{"already known", ErrTransactionAlreadyKnown},
+	{"known transaction", ErrTransactionAlreadyKnown},
+	{"exceeds block gas limit", ErrExceedsBlockGasLimit},
+	{"intrinsic gas too low", ErrIntrinsicGasTooLow},
+	{"txpool is full", ErrTxPoolFull},
+	{"invalid sender", ErrSignatureInvalid},
+}
+
 // wrapErr adds details to the types.Error provided. We use a function
 // to do this so that we don't accidentially overrwrite the standard
 // errors.
@@ -171,3 +319,62 @@ func wrapErr(rErr *types.Error, err error) *types.Error {
 
 	return newErr
 }
+
+// broadcastErr converts an error returned when submitting
+// a transaction into the types.Error describing why gwemix
+// rejected it, falling back to ErrBroadcastFailed.
+func broadcastErr(err error) *types.Error {
+	if rErr := unavailableErr(err); rErr != nil {
+		return rErr
+	}
+
+	message := err.Error()
+	for _, b := range broadcastErrors {
+		if strings.Contains(message, b.message) {
+			return wrapErr(b.err, err)
+		}
+	}
+
+	return wrapErr(ErrBroadcastFailed, err)
+}
+
+// gwemixErr converts an error returned by gwemix into a
+// types.Error, falling back to ErrGwemix.
+func gwemixErr(err error) *types.Error {
+	if errors.Is(err, ethereum.NotFound) {
+		return wrapErr(ErrNotFound, err)
+	}
+
+	if rErr := unavailableErr(err); rErr != nil {
+		return rErr
+	}
+
+	return wrapErr(ErrGwemix, err)
+}
+
+// callErr converts an error returned by gwemix for a
+// simulated call into a types.Error, returning
+// ErrTransactionReverted with the revert reason if
+// the call reverted.
+func callErr(err error) *types.Error {
+	var revertErr *wemix.RevertError
+	if errors.As(err, &revertErr) {
+		rErr := wrapErr(ErrTransactionReverted, err)
+		rErr.Details["revert_reason"] = revertErr.Reason
+		return rErr
+	}
+
+	return gwemixErr(err)
+}
+
+// unavailableErr returns ErrGwemixUnavailable if err is
+// caused by a connection failure or timeout, and nil
+// otherwise.
+func unavailableErr(err error) *types.Error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return wrapErr(ErrGwemixUnavailable, err)
+	}
+
+	return nil
+}
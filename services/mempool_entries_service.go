@@ -0,0 +1,194 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+	"github.com/wemixarchive/rosetta-wemix/wemix"
+
+	"github.com/coinbase/rosetta-sdk-go/asserter"
+	"github.com/coinbase/rosetta-sdk-go/server"
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// MempoolEntriesRequest is the request of the /mempool/entries
+// extension endpoint. If AccountIdentifier is set, only the
+// transactions sent by the account are returned.
+type MempoolEntriesRequest struct {
+	NetworkIdentifier *types.NetworkIdentifier `json:"network_identifier"`
+	AccountIdentifier *types.AccountIdentifier `json:"account_identifier,omitempty"`
+}
+
+// MempoolEntriesResponse is the response of the /mempool/entries
+// extension endpoint. Its metadata holds the number of
+// "pending" and "queued" transactions in the whole txpool.
+type MempoolEntriesResponse struct {
+	Entries  []*MempoolEntry        `json:"entries"`
+	Metadata map[string]interface{} `json:"metadata"`
+}
+
+// MempoolEntry is a transaction in the txpool. Its metadata
+// holds the "pool" (pending or queued) it waits in, and its
+// "sender", "nonce" and "gas_price".
+type MempoolEntry struct {
+	TransactionIdentifier *types.TransactionIdentifier `json:"transaction_identifier"`
+	Metadata              map[string]interface{}       `json:"metadata"`
+}
+
+// MempoolEntriesAPIService lists the transactions
+// in the txpool with their metadata.
+type MempoolEntriesAPIService struct {
+	config *configuration.Configuration
+	client Client
+}
+
+// NewMempoolEntriesAPIService creates a new instance
+// of a MempoolEntriesAPIService.
+func NewMempoolEntriesAPIService(
+	cfg *configuration.Configuration,
+	client Client,
+) *MempoolEntriesAPIService {
+	return &MempoolEntriesAPIService{
+		config: cfg,
+		client: client,
+	}
+}
+
+// MempoolEntries implements the /mempool/entries endpoint. The
+// entries and the txpool summary are read with separate calls,
+// so the summary may count transactions added or removed in
+// between.
+func (s *MempoolEntriesAPIService) MempoolEntries(
+	ctx context.Context,
+	request *MempoolEntriesRequest,
+) (*MempoolEntriesResponse, *types.Error) {
+	if s.config.Mode != configuration.Online {
+		return nil, ErrUnavailableOffline
+	}
+
+	var account *common.Address
+	if request.AccountIdentifier != nil {
+		address, ok := wemix.ChecksumAddress(request.AccountIdentifier.Address)
+		if !ok {
+			return nil, wrapErr(
+				ErrInvalidAddress,
+				fmt.Errorf("%s is not a valid address", request.AccountIdentifier.Address),
+			)
+		}
+
+		checked := common.HexToAddress(address)
+		account = &checked
+	}
+
+	entries, err := s.client.MempoolEntries(ctx, account)
+	if err != nil {
+		return nil, gwemixErr(err)
+	}
+
+	status, err := s.client.GetTxPoolStatus(ctx)
+	if err != nil {
+		return nil, gwemixErr(err)
+	}
+
+	response := &MempoolEntriesResponse{
+		Entries: make([]*MempoolEntry, len(entries)),
+		Metadata: map[string]interface{}{
+			wemix.PendingPool: status.Pending,
+			wemix.QueuedPool:  status.Queued,
+		},
+	}
+	for i, entry := range entries {
+		response.Entries[i] = &MempoolEntry{
+			TransactionIdentifier: &types.TransactionIdentifier{
+				Hash: entry.Hash.Hex(),
+			},
+			Metadata: map[string]interface{}{
+				"pool":      entry.Pool,
+				"sender":    entry.Sender.Hex(),
+				"nonce":     hexutil.EncodeUint64(entry.Nonce),
+				"gas_price": hexutil.EncodeBig(entry.GasPrice),
+			},
+		}
+	}
+
+	return response, nil
+}
+
+// MempoolEntriesAPIController serves the
+// /mempool/entries extension endpoint.
+type MempoolEntriesAPIController struct {
+	service  *MempoolEntriesAPIService
+	asserter *asserter.Asserter
+}
+
+// NewMempoolEntriesAPIController creates a server.Router
+// for the /mempool/entries extension endpoint.
+func NewMempoolEntriesAPIController(
+	service *MempoolEntriesAPIService,
+	asserter *asserter.Asserter,
+) server.Router {
+	return &MempoolEntriesAPIController{
+		service:  service,
+		asserter: asserter,
+	}
+}
+
+// Routes returns all of the api route for the MempoolEntriesAPIController
+func (c *MempoolEntriesAPIController) Routes() server.Routes {
+	return server.Routes{
+		{
+			Name:        "MempoolEntries",
+			Method:      http.MethodPost,
+			Pattern:     "/mempool/entries",
+			HandlerFunc: c.MempoolEntries,
+		},
+	}
+}
+
+// MempoolEntries - List the transactions in the txpool
+func (c *MempoolEntriesAPIController) MempoolEntries(w http.ResponseWriter, r *http.Request) {
+	request := &MempoolEntriesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	if err := c.asserter.ValidSupportedNetwork(request.NetworkIdentifier); err != nil {
+		server.EncodeJSONResponse(&types.Error{
+			Message: err.Error(),
+		}, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	result, serviceErr := c.service.MempoolEntries(r.Context(), request)
+	if serviceErr != nil {
+		server.EncodeJSONResponse(serviceErr, http.StatusInternalServerError, w)
+
+		return
+	}
+
+	server.EncodeJSONResponse(result, http.StatusOK, w)
+}
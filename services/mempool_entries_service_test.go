@@ -0,0 +1,134 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+	mocks "github.com/wemixarchive/rosetta-wemix/mocks/services"
+	"github.com/wemixarchive/rosetta-wemix/wemix"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMempoolEntriesService_Offline(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode: configuration.Offline,
+	}
+	mockClient := &mocks.Client{}
+	servicer := NewMempoolEntriesAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	resp, err := servicer.MempoolEntries(ctx, &MempoolEntriesRequest{})
+	assert.Nil(t, resp)
+	assert.Equal(t, ErrUnavailableOffline.Code, err.Code)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestMempoolEntriesService_Online(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode: configuration.Online,
+	}
+	mockClient := &mocks.Client{}
+	servicer := NewMempoolEntriesAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	sender := common.HexToAddress("0x4DE23f3f0Fb3318287378AdbdE030cf61714b2f3")
+	entries := []*wemix.MempoolEntry{
+		{
+			Hash:     common.HexToHash("0x994024ef9f05d1cb25d01572642c1f550c78d214a52c306bb100d22c025b59d4"),
+			Pool:     wemix.PendingPool,
+			Sender:   sender,
+			Nonce:    3,
+			GasPrice: big.NewInt(40000000000),
+		},
+		{
+			Hash:     common.HexToHash("0xda591f0b15423aedb52f6b0e778b1fbc6757547d69277e2ba1aa7093583d1efb"),
+			Pool:     wemix.QueuedPool,
+			Sender:   sender,
+			Nonce:    5,
+			GasPrice: big.NewInt(40000000000),
+		},
+	}
+	status := &wemix.TxPoolStatus{Pending: 10, Queued: 6}
+	expected := &MempoolEntriesResponse{
+		Entries: []*MempoolEntry{
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: "0x994024ef9f05d1cb25d01572642c1f550c78d214a52c306bb100d22c025b59d4",
+				},
+				Metadata: map[string]interface{}{
+					"pool":      "pending",
+					"sender":    sender.Hex(),
+					"nonce":     "0x3",
+					"gas_price": "0x9502f9000",
+				},
+			},
+			{
+				TransactionIdentifier: &types.TransactionIdentifier{
+					Hash: "0xda591f0b15423aedb52f6b0e778b1fbc6757547d69277e2ba1aa7093583d1efb",
+				},
+				Metadata: map[string]interface{}{
+					"pool":      "queued",
+					"sender":    sender.Hex(),
+					"nonce":     "0x5",
+					"gas_price": "0x9502f9000",
+				},
+			},
+		},
+		Metadata: map[string]interface{}{
+			"pending": uint64(10),
+			"queued":  uint64(6),
+		},
+	}
+
+	t.Run("all entries", func(t *testing.T) {
+		mockClient.On("MempoolEntries", ctx, (*common.Address)(nil)).Return(entries, nil).Once()
+		mockClient.On("GetTxPoolStatus", ctx).Return(status, nil).Once()
+
+		resp, err := servicer.MempoolEntries(ctx, &MempoolEntriesRequest{})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, resp)
+	})
+
+	t.Run("account entries", func(t *testing.T) {
+		mockClient.On("MempoolEntries", ctx, &sender).Return(entries, nil).Once()
+		mockClient.On("GetTxPoolStatus", ctx).Return(status, nil).Once()
+
+		resp, err := servicer.MempoolEntries(ctx, &MempoolEntriesRequest{
+			AccountIdentifier: &types.AccountIdentifier{
+				Address: "0x4de23f3f0fb3318287378adbde030cf61714b2f3",
+			},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, resp)
+	})
+
+	t.Run("invalid account", func(t *testing.T) {
+		resp, err := servicer.MempoolEntries(ctx, &MempoolEntriesRequest{
+			AccountIdentifier: &types.AccountIdentifier{Address: "0x1234"},
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrInvalidAddress.Code, err.Code)
+	})
+
+	mockClient.AssertExpectations(t)
+}
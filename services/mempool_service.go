@@ -16,10 +16,16 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/coinbase/rosetta-sdk-go/server"
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/wemixarchive/rosetta-wemix/configuration"
+	"github.com/wemixarchive/rosetta-wemix/wemix"
 )
 
 // MempoolAPIService implements the server.MempoolAPIServicer interface.
@@ -50,7 +56,7 @@ func (s *MempoolAPIService) Mempool(
 
 	response, err := s.client.GetMempool(ctx)
 	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+		return nil, gwemixErr(err)
 	}
 
 	return response, nil
@@ -61,5 +67,24 @@ func (s *MempoolAPIService) MempoolTransaction(
 	ctx context.Context,
 	request *types.MempoolTransactionRequest,
 ) (*types.MempoolTransactionResponse, *types.Error) {
-	return nil, wrapErr(ErrUnimplemented, nil)
+	if s.config.Mode != configuration.Online {
+		return nil, ErrUnavailableOffline
+	}
+
+	hash := request.TransactionIdentifier.Hash
+	if b, err := hexutil.Decode(hash); err != nil || len(b) != common.HashLength {
+		return nil, wrapErr(ErrInvalidInput, fmt.Errorf("%s is not a transaction hash", hash))
+	}
+
+	tx, err := s.client.MempoolTransaction(ctx, common.HexToHash(hash))
+	if errors.Is(err, wemix.ErrTransactionNotPending) || errors.Is(err, ethereum.NotFound) {
+		return nil, wrapErr(ErrTransactionNotPending, err)
+	}
+	if err != nil {
+		return nil, gwemixErr(err)
+	}
+
+	return &types.MempoolTransactionResponse{
+		Transaction: tx,
+	}, nil
 }
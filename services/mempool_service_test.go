@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/wemixarchive/rosetta-wemix/configuration"
 	mocks "github.com/wemixarchive/rosetta-wemix/mocks/services"
 
@@ -40,8 +42,8 @@ func TestMempoolService_Offline(t *testing.T) {
 
 	memTransaction, err := servicer.MempoolTransaction(ctx, nil)
 	assert.Nil(t, memTransaction)
-	assert.Equal(t, ErrUnimplemented.Code, err.Code)
-	assert.Equal(t, ErrUnimplemented.Message, err.Message)
+	assert.Equal(t, ErrUnavailableOffline.Code, err.Code)
+	assert.Equal(t, ErrUnavailableOffline.Message, err.Message)
 
 	mockClient.AssertExpectations(t)
 }
@@ -74,5 +76,45 @@ func TestMempoolService_Online(t *testing.T) {
 		assert.Equal(t, mempool, actualMempool)
 	})
 
+	t.Run("mempool transaction", func(t *testing.T) {
+		hash := "0xb89dbf00e5c1a6ec89a4d42879969e8ea843a6814a783fb5c2bbf712ea1ef071"
+		tx := &types.Transaction{
+			TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+			Operations:            []*types.Operation{},
+		}
+		mockClient.
+			On("MempoolTransaction", ctx, common.HexToHash(hash)).
+			Return(tx, nil).
+			Once()
+
+		resp, err := servicer.MempoolTransaction(ctx, &types.MempoolTransactionRequest{
+			TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, &types.MempoolTransactionResponse{Transaction: tx}, resp)
+	})
+
+	t.Run("mempool transaction not pending", func(t *testing.T) {
+		hash := "0xb89dbf00e5c1a6ec89a4d42879969e8ea843a6814a783fb5c2bbf712ea1ef072"
+		mockClient.
+			On("MempoolTransaction", ctx, common.HexToHash(hash)).
+			Return(nil, ethereum.NotFound).
+			Once()
+
+		resp, err := servicer.MempoolTransaction(ctx, &types.MempoolTransactionRequest{
+			TransactionIdentifier: &types.TransactionIdentifier{Hash: hash},
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrTransactionNotPending.Code, err.Code)
+	})
+
+	t.Run("invalid mempool transaction hash", func(t *testing.T) {
+		resp, err := servicer.MempoolTransaction(ctx, &types.MempoolTransactionRequest{
+			TransactionIdentifier: &types.TransactionIdentifier{Hash: "0x1234"},
+		})
+		assert.Nil(t, resp)
+		assert.Equal(t, ErrInvalidInput.Code, err.Code)
+	})
+
 	mockClient.AssertExpectations(t)
 }
@@ -0,0 +1,113 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// NewMetadataBundle exports the construction metadata of
+// accounts from gwemix, so that an offline instance can
+// construct transactions from them. When gasLimit is not
+// zero, it is the gas limit of contract calls offline.
+func NewMetadataBundle(
+	ctx context.Context,
+	client Client,
+	cfg *configuration.Configuration,
+	accounts []common.Address,
+	gasLimit uint64,
+) (*configuration.MetadataBundle, error) {
+	gasPrice, err := client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get gas price", err)
+	}
+
+	gasTipCap, err := client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get gas tip cap", err)
+	}
+
+	bundle := &configuration.MetadataBundle{
+		Version:   configuration.MetadataBundleVersion,
+		Network:   cfg.Network,
+		ChainID:   (*hexutil.Big)(cfg.Params.ChainID),
+		Timestamp: time.Now().Unix(),
+		GasPrice:  (*hexutil.Big)(gasPrice),
+		GasTipCap: (*hexutil.Big)(gasTipCap),
+	}
+
+	for _, account := range accounts {
+		nonce, err := client.PendingNonceAt(ctx, account)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to get nonce of %s", err, account.Hex())
+		}
+
+		bundle.Accounts = append(bundle.Accounts, &configuration.BundleAccount{
+			Address:  account,
+			Nonce:    hexutil.Uint64(nonce),
+			GasLimit: hexutil.Uint64(gasLimit),
+		})
+	}
+
+	if err := bundle.Seal(); err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// bundleNonces hands out the nonces of the accounts of a
+// metadata bundle. The bundle holds the pending nonce at
+// export time, so each nonce handed out offline is counted
+// to not reuse it in the next transaction.
+type bundleNonces struct {
+	mu      sync.Mutex
+	pending map[common.Address]uint64
+}
+
+func newBundleNonces() *bundleNonces {
+	return &bundleNonces{pending: map[common.Address]uint64{}}
+}
+
+// next returns the next nonce of account, or pinned if it
+// is set. Nonces after a pinned nonce are handed out next.
+func (n *bundleNonces) next(account *configuration.BundleAccount, pinned *uint64) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	nonce, ok := n.pending[account.Address]
+	if !ok {
+		nonce = uint64(account.Nonce)
+	}
+
+	if pinned != nil {
+		if *pinned >= nonce {
+			n.pending[account.Address] = *pinned + 1
+		}
+
+		return *pinned
+	}
+
+	n.pending[account.Address] = nonce + 1
+	return nonce
+}
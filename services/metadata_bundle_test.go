@@ -0,0 +1,80 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+	mocks "github.com/wemixarchive/rosetta-wemix/mocks/services"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMetadataBundle(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:    configuration.Online,
+		Network: networkIdentifier,
+		Params:  params.WemixTestnetChainConfig,
+	}
+	ctx := context.Background()
+	accounts := []common.Address{
+		common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"),
+		common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d"),
+	}
+
+	t.Run("exported", func(t *testing.T) {
+		mockClient := &mocks.Client{}
+		mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(101000000000), nil).Once()
+		mockClient.On("SuggestGasTipCap", ctx).Return(big.NewInt(1000000000), nil).Once()
+		mockClient.On("PendingNonceAt", ctx, accounts[0]).Return(uint64(7), nil).Once()
+		mockClient.On("PendingNonceAt", ctx, accounts[1]).Return(uint64(0), nil).Once()
+
+		bundle, err := NewMetadataBundle(ctx, mockClient, cfg, accounts, 100000)
+		assert.NoError(t, err)
+		assert.NoError(t, bundle.Verify())
+		assert.Equal(t, networkIdentifier, bundle.Network)
+		assert.Equal(t, big.NewInt(1112), bundle.ChainID.ToInt())
+		assert.Equal(t, big.NewInt(101000000000), bundle.GasPrice.ToInt())
+		assert.Equal(t, big.NewInt(1000000000), bundle.GasTipCap.ToInt())
+		assert.NotZero(t, bundle.Timestamp)
+		assert.Len(t, bundle.Accounts, 2)
+
+		account, ok := bundle.Account(accounts[0])
+		assert.True(t, ok)
+		assert.Equal(t, uint64(7), uint64(account.Nonce))
+		assert.Equal(t, uint64(100000), uint64(account.GasLimit))
+
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("gwemix error", func(t *testing.T) {
+		mockClient := &mocks.Client{}
+		mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(101000000000), nil).Once()
+		mockClient.On("SuggestGasTipCap", ctx).Return(big.NewInt(1000000000), nil).Once()
+		mockClient.On("PendingNonceAt", ctx, accounts[0]).Return(uint64(0), errors.New("boom")).Once()
+
+		bundle, err := NewMetadataBundle(ctx, mockClient, cfg, accounts, 0)
+		assert.Nil(t, bundle)
+		assert.Contains(t, err.Error(), "unable to get nonce of")
+
+		mockClient.AssertExpectations(t)
+	})
+}
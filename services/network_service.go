@@ -82,7 +82,7 @@ func (s *NetworkAPIService) NetworkStatus(
 
 	currentBlock, currentTime, syncStatus, peers, err := s.client.Status(ctx)
 	if err != nil {
-		return nil, wrapErr(ErrGwemix, err)
+		return nil, gwemixErr(err)
 	}
 
 	return &types.NetworkStatusResponse{
@@ -0,0 +1,252 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// nonceReservation is a nonce handed out by the nonceManager.
+type nonceReservation struct {
+	expires   time.Time
+	submitted bool
+}
+
+// accountNonces tracks the nonces reserved for one sender.
+type accountNonces struct {
+	// synced is false until the pending nonce is first
+	// applied and after a gap appears.
+	synced bool
+
+	// base is the highest pending nonce seen at a resync
+	// and next is one above the highest nonce handed out.
+	base uint64
+	next uint64
+
+	reserved map[uint64]*nonceReservation
+}
+
+// nonceManager allocates nonces per sender so that many
+// transactions from one account can be constructed
+// concurrently. Nonces are reserved in /construction/metadata
+// and released when submission fails or when the reservation
+// times out. A released nonce below the highest reservation
+// leaves a gap, which is resolved by resyncing with the
+// pending nonce of the sender.
+type nonceManager struct {
+	timeout time.Duration
+	now     func() time.Time
+
+	mu       sync.Mutex
+	accounts map[common.Address]*accountNonces
+}
+
+// newNonceManager creates a nonceManager whose
+// reservations expire after timeout.
+func newNonceManager(timeout time.Duration) *nonceManager {
+	return &nonceManager{
+		timeout:  timeout,
+		now:      time.Now,
+		accounts: map[common.Address]*accountNonces{},
+	}
+}
+
+// Reserve returns the lowest nonce of account that is
+// neither reserved nor used by a pending transaction. The
+// pending nonce is fetched before locking, so a slow node
+// does not hold up the reservations of other senders.
+func (m *nonceManager) Reserve(
+	ctx context.Context,
+	client Client,
+	account common.Address,
+) (uint64, error) {
+	pending, err := client.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a := m.account(account, pending)
+	nonce := a.base
+	for ; nonce < a.next; nonce++ {
+		if _, ok := a.reserved[nonce]; !ok {
+			break
+		}
+	}
+	if nonce == a.next {
+		a.next++
+	}
+
+	a.reserved[nonce] = &nonceReservation{expires: m.now().Add(m.timeout)}
+	return nonce, nil
+}
+
+// ReserveRange reserves n consecutive nonces of account and
+// returns the first, which is the lowest nonce starting n
+// nonces that are neither reserved nor pending.
+func (m *nonceManager) ReserveRange(
+	ctx context.Context,
+	client Client,
+	account common.Address,
+	n uint64,
+) (uint64, error) {
+	pending, err := client.PendingNonceAt(ctx, account)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a := m.account(account, pending)
+
+	start := a.base
+	for nonce := start; nonce < start+n; nonce++ {
+		if _, ok := a.reserved[nonce]; ok {
+			start = nonce + 1
+		}
+	}
+
+	expires := m.now().Add(m.timeout)
+	for nonce := start; nonce < start+n; nonce++ {
+		a.reserved[nonce] = &nonceReservation{expires: expires}
+	}
+	if start+n > a.next {
+		a.next = start + n
+	}
+
+	return start, nil
+}
+
+// Submitted marks the reservation of nonce as broadcast, so
+// it is kept until the pending nonce of account passes it.
+func (m *nonceManager) Submitted(account common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.accounts[account]
+	if !ok {
+		return
+	}
+
+	if r, ok := a.reserved[nonce]; ok {
+		r.submitted = true
+	}
+}
+
+// Release returns nonce of account to the pool, for
+// example when its transaction could not be submitted.
+func (m *nonceManager) Release(account common.Address, nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.accounts[account]
+	if !ok {
+		return
+	}
+
+	a.release(nonce)
+}
+
+// account returns the nonces of account, after expiring its
+// reservations and resyncing it with its pending nonce if
+// needed: after a gap appears, or when transactions sent
+// outside of the nonceManager moved the pending nonce past
+// the base. The caller must hold m.mu.
+func (m *nonceManager) account(account common.Address, pending uint64) *accountNonces {
+	a, ok := m.accounts[account]
+	if !ok {
+		a = &accountNonces{reserved: map[uint64]*nonceReservation{}}
+		m.accounts[account] = a
+	}
+
+	m.expire(a)
+	if !a.synced || pending > a.base {
+		a.resync(pending)
+	}
+
+	return a
+}
+
+// expire releases the reservations of a that timed out.
+func (m *nonceManager) expire(a *accountNonces) {
+	now := m.now()
+	for nonce, r := range a.reserved {
+		if !now.After(r.expires) {
+			continue
+		}
+
+		// A broadcast transaction may have been dropped,
+		// so let the pending nonce decide.
+		if r.submitted {
+			delete(a.reserved, nonce)
+			a.synced = false
+			continue
+		}
+
+		a.release(nonce)
+	}
+}
+
+// release frees nonce. Freeing the highest nonce handed
+// out shrinks the range; freeing any other leaves a gap.
+func (a *accountNonces) release(nonce uint64) {
+	if _, ok := a.reserved[nonce]; !ok {
+		return
+	}
+
+	delete(a.reserved, nonce)
+	if nonce+1 != a.next {
+		a.synced = false
+		return
+	}
+
+	for a.next > a.base {
+		if _, ok := a.reserved[a.next-1]; ok {
+			break
+		}
+		a.next--
+	}
+}
+
+// resync drops the reservations below the pending nonce,
+// which are now used by pending or mined transactions.
+// As the pending nonce is fetched before m.mu is held, one
+// below the base is stale and does not lower the base.
+func (a *accountNonces) resync(pending uint64) {
+	if pending > a.base {
+		a.base = pending
+	}
+
+	a.next = a.base
+	for nonce := range a.reserved {
+		if nonce < a.base {
+			delete(a.reserved, nonce)
+			continue
+		}
+
+		if nonce >= a.next {
+			a.next = nonce + 1
+		}
+	}
+
+	a.synced = true
+}
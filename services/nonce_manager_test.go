@@ -0,0 +1,343 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/wemixarchive/rosetta-wemix/configuration"
+	mocks "github.com/wemixarchive/rosetta-wemix/mocks/services"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	ethTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var nonceAccount = common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+
+func TestNonceManager_Concurrent(t *testing.T) {
+	mockClient := &mocks.Client{}
+	m := newNonceManager(time.Minute)
+	ctx := context.Background()
+
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(5), nil).Times(10)
+
+	var mu sync.Mutex
+	var nonces []uint64
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+			assert.NoError(t, err)
+
+			mu.Lock()
+			nonces = append(nonces, nonce)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	assert.Equal(t, []uint64{5, 6, 7, 8, 9, 10, 11, 12, 13, 14}, nonces)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestNonceManager_Release(t *testing.T) {
+	mockClient := &mocks.Client{}
+	m := newNonceManager(time.Minute)
+	ctx := context.Background()
+
+	reserve := func(expected uint64) {
+		nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, nonce)
+	}
+
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(0), nil).Times(4)
+	reserve(0)
+	reserve(1)
+	reserve(2)
+
+	// Releasing the highest nonce does not need a resync.
+	m.Release(nonceAccount, 2)
+	reserve(2)
+
+	// Releasing a lower nonce leaves a gap, which is
+	// filled after resyncing.
+	m.Submitted(nonceAccount, 0)
+	m.Release(nonceAccount, 1)
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(1), nil).Times(3)
+	reserve(1)
+	reserve(3)
+
+	// Unknown nonces and accounts are ignored.
+	m.Release(nonceAccount, 100)
+	m.Release(common.Address{}, 0)
+	reserve(4)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestNonceManager_Expire(t *testing.T) {
+	mockClient := &mocks.Client{}
+	m := newNonceManager(time.Minute)
+	ctx := context.Background()
+
+	now := time.Unix(1600000000, 0)
+	m.now = func() time.Time { return now }
+
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(3), nil).Twice()
+	nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+
+	// The expired reservation is handed out again.
+	now = now.Add(2 * time.Minute)
+	nonce, err = m.Reserve(ctx, mockClient, nonceAccount)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+
+	// Expired broadcast transactions are resynced.
+	m.Submitted(nonceAccount, 3)
+	now = now.Add(2 * time.Minute)
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(4), nil).Once()
+	nonce, err = m.Reserve(ctx, mockClient, nonceAccount)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), nonce)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestConstructionService_NonceManager(t *testing.T) {
+	cfg := &configuration.Configuration{
+		Mode:                    configuration.Online,
+		Network:                 networkIdentifier,
+		Params:                  params.WemixTestnetChainConfig,
+		NonceManager:            true,
+		NonceReservationTimeout: time.Minute,
+	}
+
+	mockClient := &mocks.Client{}
+	servicer := NewConstructionAPIService(cfg, mockClient)
+	ctx := context.Background()
+
+	privateKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	opts := forceMarshalMap(t, &options{
+		From:  from.Hex(),
+		To:    to.Hex(),
+		Value: big.NewInt(1000),
+	})
+
+	mockClient.On("PendingNonceAt", ctx, from).Return(uint64(2), nil).Times(3)
+	mockClient.On("SuggestGasPrice", ctx).Return(big.NewInt(100000000000), nil)
+	mockClient.On("EstimateGas", ctx, mock.Anything).Return(uint64(21000), nil)
+
+	metadataNonce := func() uint64 {
+		metadataResponse, rErr := servicer.ConstructionMetadata(ctx, &types.ConstructionMetadataRequest{
+			NetworkIdentifier: networkIdentifier,
+			Options:           opts,
+		})
+		assert.Nil(t, rErr)
+
+		var m metadata
+		assert.NoError(t, unmarshalJSONMap(metadataResponse.Metadata, &m))
+		return m.Nonce
+	}
+
+	// Concurrent constructions get consecutive nonces.
+	assert.Equal(t, uint64(2), metadataNonce())
+	assert.Equal(t, uint64(3), metadataNonce())
+
+	// A failed submission releases its nonce.
+	signedTx, err := ethTypes.SignNewTx(
+		privateKey,
+		ethTypes.NewLondonSigner(big.NewInt(1112)),
+		&ethTypes.LegacyTx{
+			Nonce:    3,
+			GasPrice: big.NewInt(100000000000),
+			Gas:      21000,
+			To:       &to,
+			Value:    big.NewInt(1000),
+		},
+	)
+	assert.NoError(t, err)
+	signedTxJSON, err := signedTx.MarshalJSON()
+	assert.NoError(t, err)
+
+	mockClient.On("SendTransaction", ctx, mock.Anything).Return(errors.New("boom")).Once()
+	submitResponse, rErr := servicer.ConstructionSubmit(ctx, &types.ConstructionSubmitRequest{
+		NetworkIdentifier: networkIdentifier,
+		SignedTransaction: string(signedTxJSON),
+	})
+	assert.Nil(t, submitResponse)
+	assert.Equal(t, ErrBroadcastFailed.Code, rErr.Code)
+	assert.Equal(t, uint64(3), metadataNonce())
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestNonceManager_ReserveRange(t *testing.T) {
+	mockClient := &mocks.Client{}
+	m := newNonceManager(time.Minute)
+	ctx := context.Background()
+
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(2), nil).Times(4)
+	start, err := m.ReserveRange(ctx, mockClient, nonceAccount, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), start)
+
+	nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), nonce)
+
+	// A gap too small for the range is skipped.
+	m.Release(nonceAccount, 3)
+	start, err = m.ReserveRange(ctx, mockClient, nonceAccount, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), start)
+
+	// The gap is still handed out to single reservations.
+	nonce, err = m.Reserve(ctx, mockClient, nonceAccount)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestNonceManager_StalePendingNonce(t *testing.T) {
+	mockClient := &mocks.Client{}
+	m := newNonceManager(time.Minute)
+	ctx := context.Background()
+
+	reserve := func(expected uint64) {
+		nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, nonce)
+	}
+
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(0), nil).Twice()
+	reserve(0)
+	reserve(1)
+	m.Submitted(nonceAccount, 0)
+
+	// This pending nonce is fetched before nonce 0
+	// is pending, and applied after.
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(0), nil).Run(
+		func(mock.Arguments) {
+			close(started)
+			<-unblock
+		},
+	).Once()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		// Nonce 0 is pending, so the gap at 1 is
+		// handed out instead.
+		reserve(1)
+	}()
+	<-started
+
+	// Nonce 0 is pending, and releasing nonce 1
+	// leaves a gap to resync.
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(1), nil).Once()
+	reserve(2)
+	m.Release(nonceAccount, 1)
+
+	close(unblock)
+	<-done
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestNonceManager_ExternalTransactions(t *testing.T) {
+	mockClient := &mocks.Client{}
+	m := newNonceManager(time.Minute)
+	ctx := context.Background()
+
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(0), nil).Twice()
+	for expected := uint64(0); expected < 2; expected++ {
+		nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, nonce)
+	}
+
+	// Transactions sent outside of Rosetta used
+	// nonces 0 to 4, so the next nonce is 5.
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(5), nil).Once()
+	nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), nonce)
+
+	mockClient.AssertExpectations(t)
+}
+
+func TestNonceManager_SlowNode(t *testing.T) {
+	mockClient := &mocks.Client{}
+	m := newNonceManager(time.Minute)
+	ctx := context.Background()
+	other := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	mockClient.On("PendingNonceAt", ctx, nonceAccount).Return(uint64(0), nil).Run(
+		func(mock.Arguments) {
+			close(started)
+			<-unblock
+		},
+	).Once()
+	mockClient.On("PendingNonceAt", ctx, other).Return(uint64(7), nil).Once()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		nonce, err := m.Reserve(ctx, mockClient, nonceAccount)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(0), nonce)
+	}()
+	<-started
+
+	// The reservation of other does not wait for the
+	// pending nonce of nonceAccount.
+	nonce, err := m.Reserve(ctx, mockClient, other)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), nonce)
+
+	close(unblock)
+	<-done
+
+	mockClient.AssertExpectations(t)
+}
@@ -0,0 +1,257 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/wemixarchive/rosetta-wemix/wemix"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// permit is an EIP-2612 permit. It is not a transaction:
+// the owner signs its EIP-712 typed-data hash and the spender
+// submits the signed permit by calling permit on the token.
+type permit struct {
+	Token           common.Address `json:"token"`
+	Owner           common.Address `json:"owner"`
+	Spender         common.Address `json:"spender"`
+	Value           *hexutil.Big   `json:"value"`
+	Nonce           *hexutil.Big   `json:"nonce"`
+	Deadline        *hexutil.Big   `json:"deadline"`
+	DomainSeparator common.Hash    `json:"domain_separator"`
+
+	// Signature and CallData are set by
+	// /construction/combine.
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+	CallData  hexutil.Bytes `json:"call_data,omitempty"`
+}
+
+// permitEnvelope tells permits apart from transactions
+// in the construction API.
+type permitEnvelope struct {
+	Permit *permit `json:"permit"`
+}
+
+// decodePermit decodes a permit constructed by
+// /construction/payloads or /construction/combine. It
+// returns !ok for anything else.
+func decodePermit(s string) (*permit, bool) {
+	var envelope permitEnvelope
+	if err := json.Unmarshal([]byte(s), &envelope); err != nil || envelope.Permit == nil {
+		return nil, false
+	}
+
+	p := envelope.Permit
+	if p.Value == nil || p.Nonce == nil || p.Deadline == nil {
+		return nil, false
+	}
+
+	return p, true
+}
+
+// encodePermit encodes p in a permitEnvelope.
+func encodePermit(p *permit) (string, error) {
+	b, err := json.Marshal(&permitEnvelope{Permit: p})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// hash returns the EIP-712 typed-data hash
+// signed by the owner.
+func (p *permit) hash() common.Hash {
+	return wemix.TypedDataHash(
+		p.DomainSeparator,
+		wemix.PermitStructHash(
+			p.Owner,
+			p.Spender,
+			p.Value.ToInt(),
+			p.Nonce.ToInt(),
+			p.Deadline.ToInt(),
+		),
+	)
+}
+
+// permitMetadata returns the token nonce of the owner and
+// the token domain separator a permit is signed over.
+// Permits cost no fee.
+func (s *ConstructionAPIService) permitMetadata(
+	ctx context.Context,
+	input *options,
+) (*types.ConstructionMetadataResponse, *types.Error) {
+	token := common.HexToAddress(input.TokenAddress)
+	nonce, rErr := s.permitCall(ctx, token, wemix.Erc20NoncesData(common.HexToAddress(input.From)))
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	domainSeparator, rErr := s.permitCall(ctx, token, wemix.DomainSeparatorFnSelector)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	return metadataResponse(&metadata{
+		GasPrice:        big.NewInt(0),
+		PermitNonce:     new(big.Int).SetBytes(nonce),
+		DomainSeparator: domainSeparator,
+	}, big.NewInt(0))
+}
+
+// permitCall calls a read-only EIP-2612 method of token
+// that returns a single word.
+func (s *ConstructionAPIService) permitCall(
+	ctx context.Context,
+	token common.Address,
+	data []byte,
+) ([]byte, *types.Error) {
+	result, err := s.client.PendingCallContract(ctx, ethereum.CallMsg{
+		To:   &token,
+		Data: data,
+	})
+	var revertErr *wemix.RevertError
+	if errors.As(err, &revertErr) || (err == nil && len(result) != common.HashLength) {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			fmt.Errorf("%s does not support EIP-2612 permits", token.Hex()),
+		)
+	}
+	if err != nil {
+		return nil, gwemixErr(err)
+	}
+
+	return result, nil
+}
+
+// permitPayloads returns the unsigned permit of intent and
+// the payload of its typed-data hash, signed by the owner.
+func permitPayloads(
+	intent *transferIntent,
+	metadata *metadata,
+) (*types.ConstructionPayloadsResponse, *types.Error) {
+	if metadata.PermitNonce == nil || len(metadata.DomainSeparator) != common.HashLength {
+		return nil, wrapErr(
+			ErrUnableToParseIntermediateResult,
+			errors.New("permit nonce or domain separator is missing"),
+		)
+	}
+
+	p := &permit{
+		Token:           common.HexToAddress(intent.TokenAddress),
+		Owner:           common.HexToAddress(intent.From),
+		Spender:         common.HexToAddress(intent.Spender),
+		Value:           (*hexutil.Big)(intent.Value),
+		Nonce:           (*hexutil.Big)(metadata.PermitNonce),
+		Deadline:        (*hexutil.Big)(intent.Deadline),
+		DomainSeparator: common.BytesToHash(metadata.DomainSeparator),
+	}
+
+	unsignedPermit, err := encodePermit(p)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionPayloadsResponse{
+		UnsignedTransaction: unsignedPermit,
+		Payloads: []*types.SigningPayload{
+			{
+				AccountIdentifier: &types.AccountIdentifier{Address: intent.From},
+				Bytes:             p.hash().Bytes(),
+				SignatureType:     types.EcdsaRecovery,
+			},
+		},
+	}, nil
+}
+
+// combinePermit adds the signature of the owner to p,
+// along with the permit calldata the spender submits.
+func combinePermit(
+	p *permit,
+	signatures []*types.Signature,
+) (*types.ConstructionCombineResponse, *types.Error) {
+	if rErr := verifySignatures(p.Owner.Hex(), p.hash().Bytes(), signatures); rErr != nil {
+		return nil, rErr
+	}
+
+	p.Signature = signatures[0].Bytes
+	p.CallData = wemix.Erc20PermitData(
+		p.Owner,
+		p.Spender,
+		p.Value.ToInt(),
+		p.Deadline.ToInt(),
+		p.Signature,
+	)
+
+	signedPermit, err := encodePermit(p)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionCombineResponse{
+		SignedTransaction: signedPermit,
+	}, nil
+}
+
+// parsePermit returns the PERMIT operation of p.
+func (s *ConstructionAPIService) parsePermit(
+	p *permit,
+	signed bool,
+) (*types.ConstructionParseResponse, *types.Error) {
+	token, ok := s.config.Token(p.Token.Hex())
+	if !ok {
+		return nil, wrapErr(ErrUnclearIntent, fmt.Errorf("%s is not a supported token", p.Token.Hex()))
+	}
+
+	if signed != (len(p.Signature) > 0) {
+		return nil, wrapErr(
+			ErrUnableToParseIntermediateResult,
+			fmt.Errorf("permit signature does not match signed %t", signed),
+		)
+	}
+
+	metadata := map[string]interface{}{
+		"permit_nonce":     p.Nonce.String(),
+		"domain_separator": p.DomainSeparator.Hex(),
+	}
+
+	signers := []*types.AccountIdentifier{}
+	if signed {
+		signers = append(signers, &types.AccountIdentifier{Address: p.Owner.Hex()})
+		metadata["call_data"] = p.CallData.String()
+	}
+
+	return &types.ConstructionParseResponse{
+		Operations: allowanceOps(
+			wemix.PermitOpType,
+			p.Owner.Hex(),
+			p.Spender.Hex(),
+			p.Value.ToInt(),
+			p.Deadline.ToInt(),
+			token,
+		),
+		AccountIdentifierSigners: signers,
+		Metadata:                 metadata,
+	}, nil
+}
@@ -54,12 +54,24 @@ func NewBlockchainRouter(
 		asserter,
 	)
 
+	batchAPIService := NewBatchAPIService(config, client, constructionAPIService)
+	batchAPIController := NewBatchAPIController(
+		batchAPIService,
+		asserter,
+	)
+
 	mempoolAPIService := NewMempoolAPIService(config, client)
 	mempoolAPIController := server.NewMempoolAPIController(
 		mempoolAPIService,
 		asserter,
 	)
 
+	mempoolEntriesAPIService := NewMempoolEntriesAPIService(config, client)
+	mempoolEntriesAPIController := NewMempoolEntriesAPIController(
+		mempoolEntriesAPIService,
+		asserter,
+	)
+
 	callAPIService := NewCallAPIService(config, client)
 	callAPIController := server.NewCallAPIController(
 		callAPIService,
@@ -71,7 +83,9 @@ func NewBlockchainRouter(
 		accountAPIController,
 		blockAPIController,
 		constructionAPIController,
+		batchAPIController,
 		mempoolAPIController,
+		mempoolEntriesAPIController,
 		callAPIController,
 	)
 }
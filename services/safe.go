@@ -0,0 +1,431 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/wemixarchive/rosetta-wemix/wemix"
+
+	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// safeTransaction is a transfer from a Safe multisig. It is
+// not a transaction: its owners sign the Safe transaction
+// hash and anyone submits the signed Safe transaction by
+// calling execTransaction on the Safe.
+type safeTransaction struct {
+	Safe            common.Address   `json:"safe"`
+	To              common.Address   `json:"to"`
+	Value           *hexutil.Big     `json:"value"`
+	Data            hexutil.Bytes    `json:"data"`
+	Nonce           *hexutil.Big     `json:"nonce"`
+	Owners          []common.Address `json:"owners"`
+	Threshold       hexutil.Uint64   `json:"threshold"`
+	DomainSeparator common.Hash      `json:"domain_separator"`
+
+	// Signatures and CallData are set by
+	// /construction/combine.
+	Signatures hexutil.Bytes `json:"signatures,omitempty"`
+	CallData   hexutil.Bytes `json:"call_data,omitempty"`
+}
+
+// safeEnvelope tells Safe transactions apart from
+// transactions in the construction API.
+type safeEnvelope struct {
+	SafeTransaction *safeTransaction `json:"safe_transaction"`
+}
+
+// decodeSafeTransaction decodes a Safe transaction
+// constructed by /construction/payloads or
+// /construction/combine. It returns !ok for anything else.
+func decodeSafeTransaction(s string) (*safeTransaction, bool) {
+	var envelope safeEnvelope
+	if err := json.Unmarshal([]byte(s), &envelope); err != nil || envelope.SafeTransaction == nil {
+		return nil, false
+	}
+
+	st := envelope.SafeTransaction
+	if st.Value == nil || st.Nonce == nil || len(st.Owners) == 0 {
+		return nil, false
+	}
+
+	return st, true
+}
+
+// encodeSafeTransaction encodes st in a safeEnvelope.
+func encodeSafeTransaction(st *safeTransaction) (string, error) {
+	b, err := json.Marshal(&safeEnvelope{SafeTransaction: st})
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+// safeTx returns the Safe transaction executed by st.
+func (st *safeTransaction) safeTx() *wemix.SafeTx {
+	return wemix.NewSafeTx(st.To, st.Value.ToInt(), st.Data, st.Nonce.ToInt())
+}
+
+// hash returns the Safe transaction hash
+// signed by the owners.
+func (st *safeTransaction) hash() common.Hash {
+	return st.safeTx().Hash(st.DomainSeparator)
+}
+
+// signers returns the owners whose signatures
+// are held by st, in signature order.
+func (st *safeTransaction) signers() ([]common.Address, error) {
+	if len(st.Signatures)%wemix.SafeSignatureLength != 0 {
+		return nil, fmt.Errorf("signatures length %d is invalid", len(st.Signatures))
+	}
+
+	hash := st.hash()
+	signers := []common.Address{}
+	for i := 0; i < len(st.Signatures); i += wemix.SafeSignatureLength {
+		sig := append([]byte{}, st.Signatures[i:i+wemix.SafeSignatureLength]...)
+		sig[crypto.RecoveryIDOffset] -= 27 // nolint:gomnd
+
+		pubkey, err := crypto.SigToPub(hash.Bytes(), sig)
+		if err != nil {
+			return nil, err
+		}
+
+		signers = append(signers, crypto.PubkeyToAddress(*pubkey))
+	}
+
+	return signers, nil
+}
+
+// safeOptions checks that a Safe transaction is a plain
+// transfer, as only transfers are parsed back from it.
+func safeOptions(intent *transferIntent, input *preprocessMetadata) *types.Error {
+	if intent.Create || len(intent.Method) > 0 {
+		return wrapErr(ErrUnclearIntent, errors.New("safe transactions only transfer"))
+	}
+
+	if len(input.Data) > 0 || len(input.MethodSignature) > 0 {
+		return wrapErr(ErrUnclearIntent, errors.New("safe transactions cannot carry call data"))
+	}
+
+	if len(input.Nonce) > 0 || len(input.ReplaceHash) > 0 ||
+		input.AccessList != nil || input.CreateAccessList {
+		return wrapErr(
+			ErrInvalidInput,
+			errors.New("safe transactions are signed messages, not transactions"),
+		)
+	}
+
+	return nil
+}
+
+// safeMetadata returns the nonce, owners, threshold and
+// domain separator of the Safe a transfer is sent from.
+// Safe transactions cost their owners no fee.
+func (s *ConstructionAPIService) safeMetadata(
+	ctx context.Context,
+	input *options,
+) (*types.ConstructionMetadataResponse, *types.Error) {
+	safe := common.HexToAddress(input.From)
+	nonce, rErr := s.safeCall(ctx, safe, wemix.SafeNonceFnSelector)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	threshold, rErr := s.safeCall(ctx, safe, wemix.SafeGetThresholdFnSelector)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	domainSeparator, rErr := s.safeCall(ctx, safe, wemix.SafeDomainSeparatorFnSelector)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	// The owners sign in the domain of this Safe on this
+	// chain, so that their signatures cannot be replayed
+	// on another Safe.
+	expectedDomain := wemix.SafeDomainSeparator(s.config.Params.ChainID, safe)
+	if !bytes.Equal(domainSeparator[:common.HashLength], expectedDomain.Bytes()) {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			fmt.Errorf("%s is not a Safe v1.3.0 on chain %s", safe.Hex(), s.config.Params.ChainID),
+		)
+	}
+
+	ownersResult, rErr := s.safeCall(ctx, safe, wemix.SafeGetOwnersFnSelector)
+	if rErr != nil {
+		return nil, rErr
+	}
+
+	owners, err := wemix.ParseSafeOwners(ownersResult)
+	if err != nil {
+		return nil, wrapErr(ErrInvalidInput, fmt.Errorf("%w: %s is not a Safe", err, safe.Hex()))
+	}
+
+	safeThreshold := new(big.Int).SetBytes(threshold)
+	if safeThreshold.Sign() == 0 || safeThreshold.Cmp(big.NewInt(int64(len(owners)))) > 0 {
+		return nil, wrapErr(
+			ErrInvalidInput,
+			fmt.Errorf("%s has threshold %s for %d owners", safe.Hex(), safeThreshold, len(owners)),
+		)
+	}
+
+	safeOwners := make([]string, len(owners))
+	for i, owner := range owners {
+		safeOwners[i] = owner.Hex()
+	}
+
+	return metadataResponse(&metadata{
+		GasPrice:        big.NewInt(0),
+		SafeNonce:       new(big.Int).SetBytes(nonce),
+		SafeOwners:      safeOwners,
+		SafeThreshold:   safeThreshold.Uint64(),
+		DomainSeparator: domainSeparator[:common.HashLength],
+	}, big.NewInt(0))
+}
+
+// safeCall calls a read-only method of safe, whose
+// result is at least a word.
+func (s *ConstructionAPIService) safeCall(
+	ctx context.Context,
+	safe common.Address,
+	data []byte,
+) ([]byte, *types.Error) {
+	result, err := s.client.PendingCallContract(ctx, ethereum.CallMsg{
+		To:   &safe,
+		Data: data,
+	})
+	var revertErr *wemix.RevertError
+	if errors.As(err, &revertErr) || (err == nil && len(result) < common.HashLength) {
+		return nil, wrapErr(ErrInvalidInput, fmt.Errorf("%s is not a Safe", safe.Hex()))
+	}
+	if err != nil {
+		return nil, gwemixErr(err)
+	}
+
+	return result, nil
+}
+
+// safePayloads returns the unsigned Safe transaction of
+// intent and a payload of its hash for each owner.
+func safePayloads(
+	intent *transferIntent,
+	metadata *metadata,
+) (*types.ConstructionPayloadsResponse, *types.Error) {
+	if metadata.SafeNonce == nil || len(metadata.SafeOwners) == 0 ||
+		len(metadata.DomainSeparator) != common.HashLength {
+		return nil, wrapErr(
+			ErrUnableToParseIntermediateResult,
+			errors.New("safe nonce, owners or domain separator is missing"),
+		)
+	}
+
+	st := &safeTransaction{
+		Safe:            common.HexToAddress(intent.From),
+		To:              common.HexToAddress(intent.To),
+		Value:           (*hexutil.Big)(intent.Value),
+		Data:            []byte{},
+		Nonce:           (*hexutil.Big)(metadata.SafeNonce),
+		Threshold:       hexutil.Uint64(metadata.SafeThreshold),
+		DomainSeparator: common.BytesToHash(metadata.DomainSeparator),
+	}
+	for _, owner := range metadata.SafeOwners {
+		st.Owners = append(st.Owners, common.HexToAddress(owner))
+	}
+
+	// ERC-20 transfers are calls to the token contract
+	// that carry no native value.
+	if len(intent.TokenAddress) > 0 {
+		st.To = common.HexToAddress(intent.TokenAddress)
+		st.Value = (*hexutil.Big)(big.NewInt(0))
+		st.Data = wemix.Erc20TransferData(common.HexToAddress(intent.To), intent.Value)
+	}
+
+	unsignedTx, err := encodeSafeTransaction(st)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	hash := st.hash()
+	payloads := make([]*types.SigningPayload, len(st.Owners))
+	for i, owner := range st.Owners {
+		payloads[i] = &types.SigningPayload{
+			AccountIdentifier: &types.AccountIdentifier{Address: owner.Hex()},
+			Bytes:             hash.Bytes(),
+			SignatureType:     types.EcdsaRecovery,
+		}
+	}
+
+	return &types.ConstructionPayloadsResponse{
+		UnsignedTransaction: unsignedTx,
+		Payloads:            payloads,
+	}, nil
+}
+
+// combineSafeTransaction adds the signatures of at least a
+// threshold of owners to st, sorted by owner address as the
+// Safe requires, along with the execTransaction call data
+// anyone may submit.
+func combineSafeTransaction(
+	st *safeTransaction,
+	signatures []*types.Signature,
+) (*types.ConstructionCombineResponse, *types.Error) {
+	if uint64(len(signatures)) < uint64(st.Threshold) {
+		return nil, wrapErr(
+			ErrSignatureInvalid,
+			fmt.Errorf("expected at least %d signatures but got %d", st.Threshold, len(signatures)),
+		)
+	}
+
+	owners := map[common.Address]bool{}
+	for _, owner := range st.Owners {
+		owners[owner] = true
+	}
+
+	hash := st.hash()
+	signed := map[common.Address][]byte{}
+	signers := []common.Address{}
+	for _, sig := range signatures {
+		if sig.SigningPayload == nil || sig.SigningPayload.AccountIdentifier == nil {
+			return nil, wrapErr(ErrSignatureInvalid, errors.New("signing payload has no owner"))
+		}
+
+		owner := common.HexToAddress(sig.SigningPayload.AccountIdentifier.Address)
+		if !owners[owner] {
+			return nil, wrapErr(ErrSignatureInvalid, fmt.Errorf("%s is not an owner", owner.Hex()))
+		}
+		if _, ok := signed[owner]; ok {
+			return nil, wrapErr(ErrSignatureInvalid, fmt.Errorf("%s signed twice", owner.Hex()))
+		}
+		if rErr := verifySignature(owner.Hex(), hash.Bytes(), sig); rErr != nil {
+			return nil, rErr
+		}
+
+		// The Safe expects the signature v in {27, 28}.
+		safeSig := append([]byte{}, sig.Bytes...)
+		safeSig[crypto.RecoveryIDOffset] += 27 // nolint:gomnd
+		signed[owner] = safeSig
+		signers = append(signers, owner)
+	}
+
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i].Bytes(), signers[j].Bytes()) < 0
+	})
+
+	st.Signatures = []byte{}
+	for _, signer := range signers {
+		st.Signatures = append(st.Signatures, signed[signer]...)
+	}
+
+	callData, err := wemix.SafeExecTransactionData(st.safeTx(), st.Signatures)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+	st.CallData = callData
+
+	signedTx, err := encodeSafeTransaction(st)
+	if err != nil {
+		return nil, wrapErr(ErrUnableToParseIntermediateResult, err)
+	}
+
+	return &types.ConstructionCombineResponse{
+		SignedTransaction: signedTx,
+	}, nil
+}
+
+// parseSafeTransaction returns the CALL operations of the
+// transfer st executes from the Safe. Once signed, they are
+// decoded from the execTransaction call data that is
+// submitted, which must match the signed Safe transaction.
+func (s *ConstructionAPIService) parseSafeTransaction(
+	st *safeTransaction,
+	signed bool,
+) (*types.ConstructionParseResponse, *types.Error) {
+	if signed != (len(st.Signatures) > 0) {
+		return nil, wrapErr(
+			ErrUnableToParseIntermediateResult,
+			fmt.Errorf("safe signatures do not match signed %t", signed),
+		)
+	}
+
+	tx := st.safeTx()
+	if signed {
+		executed, signatures, ok := wemix.ParseSafeExecTransactionData(st.CallData)
+		if ok {
+			// The nonce is not part of the call data.
+			executed.Nonce = tx.Nonce
+		}
+		if !ok || executed.StructHash() != tx.StructHash() || !bytes.Equal(signatures, st.Signatures) {
+			return nil, wrapErr(
+				ErrUnableToParseIntermediateResult,
+				errors.New("call data does not match the safe transaction"),
+			)
+		}
+
+		tx = executed
+	}
+
+	ops := transferOps(st.Safe.Hex(), tx.To.Hex(), tx.Value, wemix.Currency)
+	if len(tx.Data) > 0 {
+		token, ok := s.config.Token(tx.To.Hex())
+		if !ok || tx.Value.Sign() != 0 {
+			return nil, wrapErr(ErrUnclearIntent, fmt.Errorf("%s is not a supported token", tx.To.Hex()))
+		}
+
+		to, amount, ok := wemix.ParseErc20TransferData(tx.Data)
+		if !ok {
+			return nil, wrapErr(ErrUnclearIntent, errors.New("safe transaction is not a transfer"))
+		}
+
+		ops = transferOps(st.Safe.Hex(), to.Hex(), amount, token)
+	}
+
+	metadata := map[string]interface{}{
+		"safe_nonce":       st.Nonce.String(),
+		"safe_threshold":   st.Threshold.String(),
+		"domain_separator": st.DomainSeparator.Hex(),
+	}
+
+	signers := []*types.AccountIdentifier{}
+	if signed {
+		owners, err := st.signers()
+		if err != nil {
+			return nil, wrapErr(ErrSignatureInvalid, err)
+		}
+
+		for _, owner := range owners {
+			signers = append(signers, &types.AccountIdentifier{Address: owner.Hex()})
+		}
+		metadata["call_data"] = st.CallData.String()
+	}
+
+	return &types.ConstructionParseResponse{
+		Operations:               ops,
+		AccountIdentifierSigners: signers,
+		Metadata:                 metadata,
+	}, nil
+}
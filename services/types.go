@@ -20,9 +20,12 @@ import (
 	"math/big"
 
 	"github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	ethTypes "github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/wemixarchive/rosetta-wemix/wemix"
 )
 
 // Client is used by the services to get block
@@ -51,16 +54,47 @@ type Client interface {
 		context.Context,
 		*types.AccountIdentifier,
 		*types.PartialBlockIdentifier,
+		[]*types.Currency,
 	) (*types.AccountBalanceResponse, error)
 
 	PendingNonceAt(context.Context, common.Address) (uint64, error)
 
 	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+
+	CreateAccessList(ctx context.Context, msg ethereum.CallMsg) (ethTypes.AccessList, error)
+
+	PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error)
+
+	PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error)
+
+	PendingTransaction(
+		ctx context.Context,
+		hash common.Hash,
+	) (*ethTypes.Transaction, common.Address, error)
+
+	FeeHistory(
+		ctx context.Context,
+		blockCount uint64,
+		lastBlock *big.Int,
+		rewardPercentiles []float64,
+	) (*wemix.FeeHistory, error)
+
 	SendTransaction(ctx context.Context, tx *ethTypes.Transaction) error
 
+	SendRawTransaction(ctx context.Context, raw []byte) error
+
 	GetMempool(ctx context.Context) (*types.MempoolResponse, error)
 
+	MempoolEntries(ctx context.Context, account *common.Address) ([]*wemix.MempoolEntry, error)
+
+	GetTxPoolStatus(ctx context.Context) (*wemix.TxPoolStatus, error)
+
+	MempoolTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, error)
+
 	Call(
 		ctx context.Context,
 		request *types.CallRequest,
@@ -68,23 +102,317 @@ type Client interface {
 }
 
 type options struct {
-	From string `json:"from"`
+	From         string   `json:"from"`
+	To           string   `json:"to,omitempty"`
+	TokenAddress string   `json:"token_address,omitempty"`
+	Value        *big.Int `json:"value,omitempty"`
+	DynamicFee   bool     `json:"dynamic_fee,omitempty"`
+	Create       bool     `json:"create,omitempty"`
+
+	Method   string   `json:"method,omitempty"`
+	Owner    string   `json:"owner,omitempty"`
+	Spender  string   `json:"spender,omitempty"`
+	Deadline *big.Int `json:"deadline,omitempty"`
+
+	Priority      string   `json:"priority,omitempty"`
+	FeeMultiplier float64  `json:"fee_multiplier,omitempty"`
+	GasPrice      *big.Int `json:"gas_price,omitempty"`
+	MaxFee        *big.Int `json:"max_fee,omitempty"`
+
+	Nonce       *uint64 `json:"nonce,omitempty"`
+	ReplaceHash string  `json:"replace_hash,omitempty"`
+	ReplaceMode string  `json:"replace_mode,omitempty"`
+
+	Data            []byte   `json:"data,omitempty"`
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	AccessList       *ethTypes.AccessList `json:"access_list,omitempty"`
+	CreateAccessList bool                 `json:"create_access_list,omitempty"`
+
+	Safe     bool   `json:"safe,omitempty"`
+	FeePayer string `json:"fee_payer,omitempty"`
+}
+
+type optionsWire struct {
+	From         string `json:"from"`
+	To           string `json:"to,omitempty"`
+	TokenAddress string `json:"token_address,omitempty"`
+	Value        string `json:"value,omitempty"`
+	DynamicFee   bool   `json:"dynamic_fee,omitempty"`
+	Create       bool   `json:"create,omitempty"`
+
+	Method   string `json:"method,omitempty"`
+	Owner    string `json:"owner,omitempty"`
+	Spender  string `json:"spender,omitempty"`
+	Deadline string `json:"deadline,omitempty"`
+
+	Priority      string  `json:"priority,omitempty"`
+	FeeMultiplier float64 `json:"fee_multiplier,omitempty"`
+	GasPrice      string  `json:"gas_price,omitempty"`
+	MaxFee        string  `json:"max_fee,omitempty"`
+
+	Nonce       string `json:"nonce,omitempty"`
+	ReplaceHash string `json:"replace_hash,omitempty"`
+	ReplaceMode string `json:"replace_mode,omitempty"`
+
+	Data            string   `json:"data,omitempty"`
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	AccessList       *ethTypes.AccessList `json:"access_list,omitempty"`
+	CreateAccessList bool                 `json:"create_access_list,omitempty"`
+
+	Safe     bool   `json:"safe,omitempty"`
+	FeePayer string `json:"fee_payer,omitempty"`
+}
+
+func (o *options) MarshalJSON() ([]byte, error) {
+	ow := &optionsWire{
+		From:         o.From,
+		To:           o.To,
+		TokenAddress: o.TokenAddress,
+		Value:        encodeOptionalBig(o.Value),
+		DynamicFee:   o.DynamicFee,
+		Create:       o.Create,
+		FeePayer:     o.FeePayer,
+
+		Method:   o.Method,
+		Owner:    o.Owner,
+		Spender:  o.Spender,
+		Deadline: encodeOptionalBig(o.Deadline),
+
+		Priority:      o.Priority,
+		FeeMultiplier: o.FeeMultiplier,
+		GasPrice:      encodeOptionalBig(o.GasPrice),
+		MaxFee:        encodeOptionalBig(o.MaxFee),
+
+		ReplaceHash: o.ReplaceHash,
+		ReplaceMode: o.ReplaceMode,
+
+		MethodSignature: o.MethodSignature,
+		MethodArgs:      o.MethodArgs,
+
+		AccessList:       o.AccessList,
+		CreateAccessList: o.CreateAccessList,
+
+		Safe: o.Safe,
+	}
+	if o.Nonce != nil {
+		ow.Nonce = hexutil.EncodeUint64(*o.Nonce)
+	}
+	if len(o.Data) > 0 {
+		ow.Data = hexutil.Encode(o.Data)
+	}
+
+	return json.Marshal(ow)
+}
+
+func (o *options) UnmarshalJSON(data []byte) error {
+	var ow optionsWire
+	if err := json.Unmarshal(data, &ow); err != nil {
+		return err
+	}
+
+	value, err := decodeOptionalBig(ow.Value)
+	if err != nil {
+		return err
+	}
+
+	deadline, err := decodeOptionalBig(ow.Deadline)
+	if err != nil {
+		return err
+	}
+
+	gasPrice, err := decodeOptionalBig(ow.GasPrice)
+	if err != nil {
+		return err
+	}
+
+	maxFee, err := decodeOptionalBig(ow.MaxFee)
+	if err != nil {
+		return err
+	}
+
+	if len(ow.Nonce) > 0 {
+		nonce, err := hexutil.DecodeUint64(ow.Nonce)
+		if err != nil {
+			return err
+		}
+		o.Nonce = &nonce
+	}
+
+	if len(ow.Data) > 0 {
+		data, err := hexutil.Decode(ow.Data)
+		if err != nil {
+			return err
+		}
+		o.Data = data
+	}
+
+	o.From = ow.From
+	o.To = ow.To
+	o.TokenAddress = ow.TokenAddress
+	o.Value = value
+	o.DynamicFee = ow.DynamicFee
+	o.Create = ow.Create
+	o.Method = ow.Method
+	o.Owner = ow.Owner
+	o.Spender = ow.Spender
+	o.Deadline = deadline
+	o.Priority = ow.Priority
+	o.FeeMultiplier = ow.FeeMultiplier
+	o.GasPrice = gasPrice
+	o.MaxFee = maxFee
+	o.ReplaceHash = ow.ReplaceHash
+	o.ReplaceMode = ow.ReplaceMode
+	o.MethodSignature = ow.MethodSignature
+	o.MethodArgs = ow.MethodArgs
+	o.AccessList = ow.AccessList
+	o.CreateAccessList = ow.CreateAccessList
+	o.Safe = ow.Safe
+	o.FeePayer = ow.FeePayer
+	return nil
+}
+
+// deriveMetadata is the caller-provided metadata of a
+// /construction/derive request. Contract addresses are derived
+// with CREATE from the deployer and nonce, or with CREATE2 from
+// the deployer, salt and hash of the init code (or the init
+// code itself). The deployer defaults to the address of the
+// public key.
+type deriveMetadata struct {
+	Derivation   string `json:"derivation,omitempty"`
+	Deployer     string `json:"deployer,omitempty"`
+	Nonce        string `json:"nonce,omitempty"`
+	Salt         string `json:"salt,omitempty"`
+	InitCodeHash string `json:"init_code_hash,omitempty"`
+	InitCode     string `json:"init_code,omitempty"`
+}
+
+// preprocessMetadata is the caller-provided metadata
+// of a /construction/preprocess request.
+type preprocessMetadata struct {
+	DynamicFee bool `json:"dynamic_fee,omitempty"`
+
+	// Fees are picked from the fee history percentile of
+	// priority (or eth_gasPrice) and scaled by fee_multiplier,
+	// unless gas_price is provided. Amounts are decimal or
+	// 0x-prefixed hex strings of wei.
+	Priority      string  `json:"priority,omitempty"`
+	FeeMultiplier float64 `json:"fee_multiplier,omitempty"`
+	GasPrice      string  `json:"gas_price,omitempty"`
+	MaxFee        string  `json:"max_fee,omitempty"`
+
+	// The nonce is taken from the pending state unless it is
+	// pinned, or unless a pending transaction is replaced
+	// with a speed_up (same intent) or cancel (zero-value
+	// self-transfer).
+	Nonce       string `json:"nonce,omitempty"`
+	ReplaceHash string `json:"replace_hash,omitempty"`
+	ReplaceMode string `json:"replace_mode,omitempty"`
+
+	// Contract calls provide either raw calldata or
+	// a method signature with its arguments.
+	Data            string   `json:"data,omitempty"`
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	// Contract deployments provide creation bytecode
+	// and the constructor argument types and values.
+	Bytecode         string   `json:"bytecode,omitempty"`
+	ConstructorTypes []string `json:"constructor_types,omitempty"`
+	ConstructorArgs  []string `json:"constructor_args,omitempty"`
+
+	// An access list, either provided or generated with
+	// eth_createAccessList, makes legacy-fee transactions
+	// access-list (EIP-2930) transactions.
+	AccessList       *ethTypes.AccessList `json:"access_list,omitempty"`
+	CreateAccessList bool                 `json:"create_access_list,omitempty"`
+
+	// Transfers from a Safe multisig are Safe transactions
+	// signed by its owners and executed with execTransaction.
+	Safe bool `json:"safe,omitempty"`
+
+	// Transactions with a fee payer are fee-delegated
+	// transactions, whose fee is paid by the fee payer.
+	FeePayer string `json:"fee_payer,omitempty"`
 }
 
 type metadata struct {
-	Nonce    uint64   `json:"nonce"`
-	GasPrice *big.Int `json:"gas_price"`
+	Nonce                uint64   `json:"nonce"`
+	GasPrice             *big.Int `json:"gas_price"`
+	GasLimit             uint64   `json:"gas_limit"`
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"`
+
+	Data            []byte   `json:"data,omitempty"`
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	// Permits are signed over the token nonce of
+	// the owner and the token domain separator.
+	PermitNonce     *big.Int `json:"permit_nonce,omitempty"`
+	DomainSeparator []byte   `json:"domain_separator,omitempty"`
+
+	// Safe transactions are signed over the Safe nonce and
+	// domain separator by its owners, of which a threshold
+	// must sign.
+	SafeNonce     *big.Int `json:"safe_nonce,omitempty"`
+	SafeOwners    []string `json:"safe_owners,omitempty"`
+	SafeThreshold uint64   `json:"safe_threshold,omitempty"`
+
+	AccessList *ethTypes.AccessList `json:"access_list,omitempty"`
+
+	FeePayer string `json:"fee_payer,omitempty"`
 }
 
 type metadataWire struct {
-	Nonce    string `json:"nonce"`
-	GasPrice string `json:"gas_price"`
+	Nonce                string `json:"nonce"`
+	GasPrice             string `json:"gas_price"`
+	GasLimit             string `json:"gas_limit"`
+	MaxFeePerGas         string `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas,omitempty"`
+
+	Data            string   `json:"data,omitempty"`
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	PermitNonce     string `json:"permit_nonce,omitempty"`
+	DomainSeparator string `json:"domain_separator,omitempty"`
+
+	SafeNonce     string   `json:"safe_nonce,omitempty"`
+	SafeOwners    []string `json:"safe_owners,omitempty"`
+	SafeThreshold string   `json:"safe_threshold,omitempty"`
+
+	AccessList *ethTypes.AccessList `json:"access_list,omitempty"`
+
+	FeePayer string `json:"fee_payer,omitempty"`
 }
 
 func (m *metadata) MarshalJSON() ([]byte, error) {
 	mw := &metadataWire{
-		Nonce:    hexutil.Uint64(m.Nonce).String(),
-		GasPrice: hexutil.EncodeBig(m.GasPrice),
+		Nonce:                hexutil.Uint64(m.Nonce).String(),
+		GasPrice:             hexutil.EncodeBig(m.GasPrice),
+		GasLimit:             hexutil.Uint64(m.GasLimit).String(),
+		MaxFeePerGas:         encodeOptionalBig(m.MaxFeePerGas),
+		MaxPriorityFeePerGas: encodeOptionalBig(m.MaxPriorityFeePerGas),
+		MethodSignature:      m.MethodSignature,
+		MethodArgs:           m.MethodArgs,
+		PermitNonce:          encodeOptionalBig(m.PermitNonce),
+		AccessList:           m.AccessList,
+		SafeNonce:            encodeOptionalBig(m.SafeNonce),
+		SafeOwners:           m.SafeOwners,
+		FeePayer:             m.FeePayer,
+	}
+	if len(m.Data) > 0 {
+		mw.Data = hexutil.Encode(m.Data)
+	}
+	if len(m.DomainSeparator) > 0 {
+		mw.DomainSeparator = hexutil.Encode(m.DomainSeparator)
+	}
+	if m.SafeThreshold > 0 {
+		mw.SafeThreshold = hexutil.EncodeUint64(m.SafeThreshold)
 	}
 
 	return json.Marshal(mw)
@@ -106,28 +434,125 @@ func (m *metadata) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	gasLimit, err := hexutil.DecodeUint64(mw.GasLimit)
+	if err != nil {
+		return err
+	}
+
+	maxFeePerGas, err := decodeOptionalBig(mw.MaxFeePerGas)
+	if err != nil {
+		return err
+	}
+
+	maxPriorityFeePerGas, err := decodeOptionalBig(mw.MaxPriorityFeePerGas)
+	if err != nil {
+		return err
+	}
+
+	permitNonce, err := decodeOptionalBig(mw.PermitNonce)
+	if err != nil {
+		return err
+	}
+
+	safeNonce, err := decodeOptionalBig(mw.SafeNonce)
+	if err != nil {
+		return err
+	}
+
+	if len(mw.SafeThreshold) > 0 {
+		safeThreshold, err := hexutil.DecodeUint64(mw.SafeThreshold)
+		if err != nil {
+			return err
+		}
+		m.SafeThreshold = safeThreshold
+	}
+
+	if len(mw.Data) > 0 {
+		data, err := hexutil.Decode(mw.Data)
+		if err != nil {
+			return err
+		}
+		m.Data = data
+	}
+
+	if len(mw.DomainSeparator) > 0 {
+		domainSeparator, err := hexutil.Decode(mw.DomainSeparator)
+		if err != nil {
+			return err
+		}
+		m.DomainSeparator = domainSeparator
+	}
+
 	m.GasPrice = gasPrice
+	m.GasLimit = gasLimit
 	m.Nonce = nonce
+	m.MaxFeePerGas = maxFeePerGas
+	m.MaxPriorityFeePerGas = maxPriorityFeePerGas
+	m.MethodSignature = mw.MethodSignature
+	m.MethodArgs = mw.MethodArgs
+	m.PermitNonce = permitNonce
+	m.AccessList = mw.AccessList
+	m.SafeNonce = safeNonce
+	m.SafeOwners = mw.SafeOwners
+	m.FeePayer = mw.FeePayer
 	return nil
 }
 
 type parseMetadata struct {
-	Nonce    uint64   `json:"nonce"`
-	GasPrice *big.Int `json:"gas_price"`
-	ChainID  *big.Int `json:"chain_id"`
+	Type                 uint8    `json:"type"`
+	Nonce                uint64   `json:"nonce"`
+	GasPrice             *big.Int `json:"gas_price"`
+	ChainID              *big.Int `json:"chain_id"`
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"`
+
+	AccessList *ethTypes.AccessList `json:"access_list,omitempty"`
+
+	// FeePayer pays the fee of fee-delegated transactions.
+	FeePayer string `json:"fee_payer,omitempty"`
+
+	MethodID        []byte   `json:"method_id,omitempty"`
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	ContractAddress string `json:"contract_address,omitempty"`
 }
 
 type parseMetadataWire struct {
-	Nonce    string `json:"nonce"`
-	GasPrice string `json:"gas_price"`
-	ChainID  string `json:"chain_id"`
+	Type                 string `json:"type"`
+	Nonce                string `json:"nonce"`
+	GasPrice             string `json:"gas_price"`
+	ChainID              string `json:"chain_id"`
+	MaxFeePerGas         string `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas,omitempty"`
+
+	AccessList *ethTypes.AccessList `json:"access_list,omitempty"`
+
+	FeePayer string `json:"fee_payer,omitempty"`
+
+	MethodID        string   `json:"method_id,omitempty"`
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	ContractAddress string `json:"contract_address,omitempty"`
 }
 
 func (p *parseMetadata) MarshalJSON() ([]byte, error) {
 	pmw := &parseMetadataWire{
-		Nonce:    hexutil.Uint64(p.Nonce).String(),
-		GasPrice: hexutil.EncodeBig(p.GasPrice),
-		ChainID:  hexutil.EncodeBig(p.ChainID),
+		Type:                 hexutil.EncodeUint64(uint64(p.Type)),
+		Nonce:                hexutil.Uint64(p.Nonce).String(),
+		GasPrice:             hexutil.EncodeBig(p.GasPrice),
+		ChainID:              hexutil.EncodeBig(p.ChainID),
+		MaxFeePerGas:         encodeOptionalBig(p.MaxFeePerGas),
+		MaxPriorityFeePerGas: encodeOptionalBig(p.MaxPriorityFeePerGas),
+		AccessList:           p.AccessList,
+		FeePayer:             p.FeePayer,
+		MethodSignature:      p.MethodSignature,
+		MethodArgs:           p.MethodArgs,
+		ContractAddress:      p.ContractAddress,
+	}
+	if len(p.MethodID) > 0 {
+		pmw.MethodID = hexutil.Encode(p.MethodID)
 	}
 
 	return json.Marshal(pmw)
@@ -142,6 +567,25 @@ type transaction struct {
 	GasPrice *big.Int `json:"gas_price"`
 	GasLimit uint64   `json:"gas"`
 	ChainID  *big.Int `json:"chain_id"`
+
+	MaxFeePerGas         *big.Int `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *big.Int `json:"max_priority_fee_per_gas,omitempty"`
+
+	// Transactions with an access list are access-list
+	// (EIP-2930) or dynamic-fee transactions.
+	AccessList *ethTypes.AccessList `json:"access_list,omitempty"`
+
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	// Transactions with a fee payer are fee-delegated
+	// transactions. The fee payer signs over the signed
+	// transaction of the sender, so /construction/combine
+	// first adds the sender signature and the payload of
+	// the fee payer.
+	FeePayer        string                `json:"fee_payer,omitempty"`
+	SenderSignature []byte                `json:"sender_signature,omitempty"`
+	FeePayerPayload *types.SigningPayload `json:"fee_payer_payload,omitempty"`
 }
 
 type transactionWire struct {
@@ -153,6 +597,18 @@ type transactionWire struct {
 	GasPrice string `json:"gas_price"`
 	GasLimit string `json:"gas"`
 	ChainID  string `json:"chain_id"`
+
+	MaxFeePerGas         string `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas string `json:"max_priority_fee_per_gas,omitempty"`
+
+	AccessList *ethTypes.AccessList `json:"access_list,omitempty"`
+
+	MethodSignature string   `json:"method_signature,omitempty"`
+	MethodArgs      []string `json:"method_args,omitempty"`
+
+	FeePayer        string                `json:"fee_payer,omitempty"`
+	SenderSignature string                `json:"sender_signature,omitempty"`
+	FeePayerPayload *types.SigningPayload `json:"fee_payer_payload,omitempty"`
 }
 
 func (t *transaction) MarshalJSON() ([]byte, error) {
@@ -165,6 +621,20 @@ func (t *transaction) MarshalJSON() ([]byte, error) {
 		GasPrice: hexutil.EncodeBig(t.GasPrice),
 		GasLimit: hexutil.EncodeUint64(t.GasLimit),
 		ChainID:  hexutil.EncodeBig(t.ChainID),
+
+		MaxFeePerGas:         encodeOptionalBig(t.MaxFeePerGas),
+		MaxPriorityFeePerGas: encodeOptionalBig(t.MaxPriorityFeePerGas),
+
+		AccessList: t.AccessList,
+
+		MethodSignature: t.MethodSignature,
+		MethodArgs:      t.MethodArgs,
+
+		FeePayer:        t.FeePayer,
+		FeePayerPayload: t.FeePayerPayload,
+	}
+	if len(t.SenderSignature) > 0 {
+		tw.SenderSignature = hexutil.Encode(t.SenderSignature)
 	}
 
 	return json.Marshal(tw)
@@ -206,6 +676,24 @@ func (t *transaction) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	maxFeePerGas, err := decodeOptionalBig(tw.MaxFeePerGas)
+	if err != nil {
+		return err
+	}
+
+	maxPriorityFeePerGas, err := decodeOptionalBig(tw.MaxPriorityFeePerGas)
+	if err != nil {
+		return err
+	}
+
+	if len(tw.SenderSignature) > 0 {
+		senderSignature, err := hexutil.Decode(tw.SenderSignature)
+		if err != nil {
+			return err
+		}
+		t.SenderSignature = senderSignature
+	}
+
 	t.From = tw.From
 	t.To = tw.To
 	t.Value = value
@@ -215,5 +703,12 @@ func (t *transaction) UnmarshalJSON(data []byte) error {
 	t.GasLimit = gasLimit
 	t.ChainID = chainID
 	t.GasPrice = gasPrice
+	t.MaxFeePerGas = maxFeePerGas
+	t.MaxPriorityFeePerGas = maxPriorityFeePerGas
+	t.AccessList = tw.AccessList
+	t.MethodSignature = tw.MethodSignature
+	t.MethodArgs = tw.MethodArgs
+	t.FeePayer = tw.FeePayer
+	t.FeePayerPayload = tw.FeePayerPayload
 	return nil
 }
@@ -16,6 +16,10 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
 // *JSONMap functions are needed because `types.MarshalMap/types.UnmarshalMap`
@@ -45,3 +49,38 @@ func unmarshalJSONMap(m map[string]interface{}, i interface{}) error {
 
 	return json.Unmarshal(b, i)
 }
+
+// encodeOptionalBig hex-encodes i, or returns an empty
+// string if i is nil.
+func encodeOptionalBig(i *big.Int) string {
+	if i == nil {
+		return ""
+	}
+
+	return hexutil.EncodeBig(i)
+}
+
+// decodeOptionalBig decodes a hex-encoded *big.Int, or returns
+// nil if s is empty.
+func decodeOptionalBig(s string) (*big.Int, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	return hexutil.DecodeBig(s)
+}
+
+// parseOptionalBig parses a non-negative decimal or 0x-prefixed
+// hex *big.Int, or returns nil if s is empty.
+func parseOptionalBig(s string) (*big.Int, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	i, ok := new(big.Int).SetString(s, 0)
+	if !ok || i.Sign() < 0 {
+		return nil, fmt.Errorf("%s is not a valid amount", s)
+	}
+
+	return i, nil
+}
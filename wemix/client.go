@@ -15,6 +15,7 @@
 package wemix
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -23,17 +24,20 @@ import (
 	"log"
 	"math/big"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	RosettaTypes "github.com/coinbase/rosetta-sdk-go/types"
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
 	"github.com/ethereum/go-ethereum/core/types"
 	EthTypes "github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
@@ -68,10 +72,27 @@ type Client struct {
 	traceSemaphore *semaphore.Weighted
 
 	skipAdminCalls bool
+
+	// tokens are the ERC-20 tokens whose Transfer
+	// events are represented as operations.
+	tokens map[common.Address]*RosettaTypes.Currency
+
+	// nfts are the standards of the non-fungible token
+	// contracts whose transfer events are represented
+	// as operations.
+	nfts map[common.Address]string
 }
 
 // NewClient creates a Client that from the provided url and params.
-func NewClient(url string, params *params.ChainConfig, skipAdminCalls bool) (*Client, error) {
+// Transfer events of tokens and of the nfts contracts are
+// represented as operations.
+func NewClient(
+	url string,
+	params *params.ChainConfig,
+	skipAdminCalls bool,
+	tokens []*RosettaTypes.Currency,
+	nfts []*RosettaTypes.Currency,
+) (*Client, error) {
 	c, err := rpc.DialHTTPWithClient(url, &http.Client{
 		Timeout: gwemixHTTPTimeout,
 	})
@@ -89,7 +110,30 @@ func NewClient(url string, params *params.ChainConfig, skipAdminCalls bool) (*Cl
 		return nil, fmt.Errorf("%w: unable to create GraphQL client", err)
 	}
 
-	return &Client{params, tc, c, g, semaphore.NewWeighted(maxTraceConcurrency), skipAdminCalls}, nil
+	tokenCurrencies := map[common.Address]*RosettaTypes.Currency{}
+	for _, token := range tokens {
+		if address, ok := TokenAddress(token); ok {
+			tokenCurrencies[common.HexToAddress(address)] = token
+		}
+	}
+
+	nftStandards := map[common.Address]string{}
+	for _, nft := range nfts {
+		if contract, standard, ok := NftContract(nft); ok {
+			nftStandards[contract] = standard
+		}
+	}
+
+	return &Client{
+		params,
+		tc,
+		c,
+		g,
+		semaphore.NewWeighted(maxTraceConcurrency),
+		skipAdminCalls,
+		tokenCurrencies,
+		nftStandards,
+	}, nil
 }
 
 // Close shuts down the RPC client connection.
@@ -160,6 +204,137 @@ func (ec *Client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
 	return (*big.Int)(&hex), nil
 }
 
+// SuggestGasTipCap retrieves the currently suggested gas tip cap after 1559 to
+// allow a timely execution of a transaction.
+func (ec *Client) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	var hex hexutil.Big
+	if err := ec.c.CallContext(ctx, &hex, "eth_maxPriorityFeePerGas"); err != nil {
+		return nil, err
+	}
+	return (*big.Int)(&hex), nil
+}
+
+// EstimateGas tries to estimate the gas needed to execute a specific transaction based on
+// the current pending state of the backend blockchain. There is no guarantee that this is
+// the true gas limit requirement as other transactions may be added or removed by miners,
+// but it should provide a basis for setting a reasonable default.
+func (ec *Client) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	var hex hexutil.Uint64
+	err := ec.c.CallContext(ctx, &hex, "eth_estimateGas", toCallArg(msg))
+	if err != nil {
+		return 0, err
+	}
+	return uint64(hex), nil
+}
+
+func toCallArg(msg ethereum.CallMsg) interface{} {
+	arg := map[string]interface{}{
+		"from": msg.From,
+		"to":   msg.To,
+	}
+	if len(msg.Data) > 0 {
+		arg["data"] = hexutil.Bytes(msg.Data)
+	}
+	if msg.Value != nil {
+		arg["value"] = (*hexutil.Big)(msg.Value)
+	}
+	if msg.Gas != 0 {
+		arg["gas"] = hexutil.Uint64(msg.Gas)
+	}
+	if msg.GasPrice != nil {
+		arg["gasPrice"] = (*hexutil.Big)(msg.GasPrice)
+	}
+	if msg.AccessList != nil {
+		arg["accessList"] = msg.AccessList
+	}
+	return arg
+}
+
+// accessListResult is the result of eth_createAccessList.
+type accessListResult struct {
+	AccessList types.AccessList `json:"accessList"`
+	GasUsed    hexutil.Uint64   `json:"gasUsed"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// CreateAccessList returns the EIP-2930 access list of the
+// storage slots a message call touches in the pending state.
+func (ec *Client) CreateAccessList(ctx context.Context, msg ethereum.CallMsg) (types.AccessList, error) {
+	var result accessListResult
+	err := ec.c.CallContext(ctx, &result, "eth_createAccessList", toCallArg(msg), "pending")
+	if err != nil {
+		return nil, err
+	}
+
+	// The list is still generated when the call fails,
+	// but a failing call cannot be constructed.
+	if len(result.Error) > 0 {
+		return nil, &RevertError{Reason: result.Error}
+	}
+
+	if result.AccessList == nil {
+		return types.AccessList{}, nil
+	}
+
+	return result.AccessList, nil
+}
+
+// FeeHistory is the result of eth_feeHistory.
+type FeeHistory struct {
+	OldestBlock  *big.Int     // block corresponding to first response value
+	Reward       [][]*big.Int // priority fees per gas at the requested percentiles, per block
+	BaseFee      []*big.Int   // base fees per gas, including the next block's
+	GasUsedRatio []float64    // gas used ratio, per block
+}
+
+type feeHistoryResultMarshaling struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory retrieves the fee market history of the blockCount
+// blocks up to lastBlock (latest if nil), with the priority fees
+// paid at each of rewardPercentiles.
+func (ec *Client) FeeHistory(
+	ctx context.Context,
+	blockCount uint64,
+	lastBlock *big.Int,
+	rewardPercentiles []float64,
+) (*FeeHistory, error) {
+	var res feeHistoryResultMarshaling
+	if err := ec.c.CallContext(
+		ctx,
+		&res,
+		"eth_feeHistory",
+		hexutil.Uint(blockCount),
+		toBlockNumArg(lastBlock),
+		rewardPercentiles,
+	); err != nil {
+		return nil, err
+	}
+
+	reward := make([][]*big.Int, len(res.Reward))
+	for i, r := range res.Reward {
+		reward[i] = make([]*big.Int, len(r))
+		for j, r := range r {
+			reward[i][j] = (*big.Int)(r)
+		}
+	}
+	baseFee := make([]*big.Int, len(res.BaseFee))
+	for i, b := range res.BaseFee {
+		baseFee[i] = (*big.Int)(b)
+	}
+
+	return &FeeHistory{
+		OldestBlock:  (*big.Int)(res.OldestBlock),
+		Reward:       reward,
+		BaseFee:      baseFee,
+		GasUsedRatio: res.GasUsedRatio,
+	}, nil
+}
+
 // Peers retrieves all peers of the node.
 func (ec *Client) peers(ctx context.Context) ([]*RosettaTypes.Peer, error) {
 	var info []*p2p.PeerInfo
@@ -201,6 +376,96 @@ func (ec *Client) SendTransaction(ctx context.Context, tx *types.Transaction) er
 	return ec.c.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(data))
 }
 
+// SendRawTransaction injects the raw bytes of a signed
+// transaction into the pending pool for execution. It sends
+// envelopes that go-ethereum cannot decode, such as
+// fee-delegated transactions.
+func (ec *Client) SendRawTransaction(ctx context.Context, raw []byte) error {
+	return ec.c.CallContext(ctx, nil, "eth_sendRawTransaction", hexutil.Encode(raw))
+}
+
+// PendingBalanceAt returns the wei balance of the given
+// account in the pending state.
+func (ec *Client) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	var result hexutil.Big
+	err := ec.c.CallContext(ctx, &result, "eth_getBalance", account, "pending")
+	return (*big.Int)(&result), err
+}
+
+// PendingCallContract executes a message call against the
+// pending state. If the call reverts, it returns a
+// *RevertError with the decoded revert reason.
+func (ec *Client) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	var hex hexutil.Bytes
+	err := ec.c.CallContext(ctx, &hex, "eth_call", toCallArg(msg), "pending")
+	if err == nil {
+		return hex, nil
+	}
+
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return nil, err
+	}
+
+	revertData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return nil, err
+	}
+
+	reason := revertData
+	if data, decodeErr := hexutil.Decode(revertData); decodeErr == nil {
+		if unpacked, unpackErr := abi.UnpackRevert(data); unpackErr == nil {
+			reason = unpacked
+		}
+	}
+
+	return nil, &RevertError{Reason: reason}
+}
+
+// PendingTransaction returns a transaction waiting in the
+// txpool, and its sender, by hash.
+func (ec *Client) PendingTransaction(
+	ctx context.Context,
+	hash common.Hash,
+) (*types.Transaction, common.Address, error) {
+	body, err := ec.pendingRPCTransaction(ctx, hash)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+
+	return body.tx, *body.From, nil
+}
+
+// pendingRPCTransaction returns a transaction waiting
+// in the txpool by hash.
+func (ec *Client) pendingRPCTransaction(
+	ctx context.Context,
+	hash common.Hash,
+) (*rpcTransaction, error) {
+	var raw json.RawMessage
+	err := ec.c.CallContext(ctx, &raw, "eth_getTransactionByHash", hash)
+	if err != nil {
+		return nil, fmt.Errorf("%w: transaction fetch failed", err)
+	} else if len(raw) == 0 || string(raw) == "null" {
+		return nil, ethereum.NotFound
+	}
+
+	var body rpcTransaction
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+
+	if body.BlockNumber != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransactionNotPending, hash.Hex())
+	}
+
+	if body.From == nil {
+		return nil, errors.New("missing required field 'from' for transaction")
+	}
+
+	return &body, nil
+}
+
 func toBlockNumArg(number *big.Int) string {
 	if number == nil {
 		return "latest"
@@ -249,7 +514,7 @@ func (ec *Client) Transaction(
 		return nil, fmt.Errorf("%w: could not get block header for %x", err, blockIdentifier.Hash)
 	}
 
-	receipt, err := ec.transactionReceipt(ctx, body.tx.Hash())
+	receipt, err := ec.transactionReceipt(ctx, body.Hash())
 	if receipt.BlockHash != *body.BlockHash {
 		return nil, fmt.Errorf(
 			"%w: expected block hash %s for transaction but got %s",
@@ -259,7 +524,7 @@ func (ec *Client) Transaction(
 		)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("%w: could not get receipt for %x", err, body.tx.Hash())
+		return nil, fmt.Errorf("%w: could not get receipt for %x", err, body.Hash())
 	}
 
 	var traces *Call
@@ -267,9 +532,9 @@ func (ec *Client) Transaction(
 	var addTraces bool
 	if header.Number.Int64() != GenesisBlockIndex { // not possible to get traces at genesis
 		addTraces = true
-		traces, rawTraces, err = ec.getTransactionTraces(ctx, body.tx.Hash())
+		traces, rawTraces, err = ec.getTransactionTraces(ctx, body.Hash())
 		if err != nil {
-			return nil, fmt.Errorf("%w: could not get traces for %x", err, body.tx.Hash())
+			return nil, fmt.Errorf("%w: could not get traces for %x", err, body.Hash())
 		}
 	}
 
@@ -291,7 +556,7 @@ func (ec *Client) Transaction(
 
 	tx, err := ec.populateTransaction(loadedTx)
 	if err != nil {
-		return nil, fmt.Errorf("%w: cannot parse %s", err, loadedTx.Transaction.Hash().Hex())
+		return nil, fmt.Errorf("%w: cannot parse %s", err, loadedTx.hash().Hex())
 	}
 	return tx, nil
 }
@@ -639,6 +904,10 @@ type Receipt struct {
 
 	BlockHash        common.Hash `json:"blockHash,omitempty"`
 	TransactionIndex uint        `json:"transactionIndex"`
+
+	// Logs are represented as operations and are
+	// not part of the receipt metadata.
+	Logs []*EthTypes.Log `json:"logs"`
 }
 
 func (r Receipt) MarshalJSON() ([]byte, error) {
@@ -679,6 +948,7 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 		GasUsed           *hexutil.Uint64 `json:"gasUsed" gencodec:"required"`
 		BlockHash         *common.Hash    `json:"blockHash,omitempty"`
 		TransactionIndex  *hexutil.Uint   `json:"transactionIndex"`
+		Logs              []*EthTypes.Log `json:"logs"`
 	}
 	var dec Receipt
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -717,6 +987,7 @@ func (r *Receipt) UnmarshalJSON(input []byte) error {
 	if dec.TransactionIndex != nil {
 		r.TransactionIndex = uint(*dec.TransactionIndex)
 	}
+	r.Logs = dec.Logs
 	return nil
 }
 
@@ -1058,6 +1329,8 @@ type txExtraInfo struct {
 	BlockNumber *string         `json:"blockNumber,omitempty"`
 	BlockHash   *common.Hash    `json:"blockHash,omitempty"`
 	From        *common.Address `json:"from,omitempty"`
+	TxHash      *common.Hash    `json:"hash,omitempty"`
+	FeePayer    *common.Address `json:"feePayer,omitempty"`
 }
 
 type rpcTransaction struct {
@@ -1065,20 +1338,71 @@ type rpcTransaction struct {
 	txExtraInfo
 }
 
+// UnmarshalJSON decodes a transaction returned by gwemix.
+// Fee-delegated transactions are decoded as the dynamic-fee
+// transaction of their sender, with their fee payer.
 func (tx *rpcTransaction) UnmarshalJSON(msg []byte) error {
-	if err := json.Unmarshal(msg, &tx.tx); err != nil {
+	var envelope struct {
+		Type hexutil.Uint64 `json:"type"`
+	}
+	if err := json.Unmarshal(msg, &envelope); err != nil {
+		return err
+	}
+
+	txMsg := msg
+	if envelope.Type == FeeDelegateDynamicFeeTxType {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(msg, &fields); err != nil {
+			return err
+		}
+
+		fields["type"] = json.RawMessage(`"` + hexutil.EncodeUint64(types.DynamicFeeTxType) + `"`)
+		senderMsg, err := json.Marshal(fields)
+		if err != nil {
+			return err
+		}
+		txMsg = senderMsg
+	}
+
+	if err := json.Unmarshal(txMsg, &tx.tx); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(msg, &tx.txExtraInfo); err != nil {
 		return err
 	}
-	return json.Unmarshal(msg, &tx.txExtraInfo)
+
+	if envelope.Type == FeeDelegateDynamicFeeTxType && (tx.FeePayer == nil || tx.TxHash == nil) {
+		return errors.New("missing required field 'feePayer' or 'hash' for fee-delegated transaction")
+	}
+	if envelope.Type != FeeDelegateDynamicFeeTxType {
+		tx.FeePayer = nil
+	}
+
+	return nil
+}
+
+// Hash returns the hash of the transaction. Fee-delegated
+// transactions are hashed with their fee payer signature, so
+// their hash is the one reported by gwemix.
+func (tx *rpcTransaction) Hash() common.Hash {
+	if tx.FeePayer != nil {
+		return *tx.TxHash
+	}
+
+	return tx.tx.Hash()
 }
 
 func (tx *rpcTransaction) LoadedTransaction() *loadedTransaction {
 	ethTx := &loadedTransaction{
 		Transaction: tx.tx,
 		From:        tx.txExtraInfo.From,
+		FeePayer:    tx.txExtraInfo.FeePayer,
 		BlockNumber: tx.txExtraInfo.BlockNumber,
 		BlockHash:   tx.txExtraInfo.BlockHash,
 	}
+	if tx.tx != nil {
+		ethTx.TxHash = tx.Hash()
+	}
 	return ethTx
 }
 
@@ -1092,12 +1416,214 @@ type loadedTransaction struct {
 	Miner       string
 	Status      bool
 
+	// FeePayer pays the fee of fee-delegated transactions,
+	// whose TxHash differs from the hash of Transaction
+	// (the dynamic-fee transaction of the sender).
+	FeePayer *common.Address
+	TxHash   common.Hash
+
 	Trace    *Call
 	RawTrace json.RawMessage
 	Receipt  *Receipt
 }
 
+// erc20TransferOps returns a debit and a credit operation
+// for each Transfer event emitted by a configured token. The
+// zero address, which mints and burns tokens, holds no
+// balance. Operations share the status of the receipt.
+func (ec *Client) erc20TransferOps(receipt *Receipt, startIndex int) []*RosettaTypes.Operation {
+	var ops []*RosettaTypes.Operation
+	if receipt == nil {
+		return ops
+	}
+
+	opStatus := receiptOpStatus(receipt)
+	for _, txLog := range receipt.Logs {
+		token, ok := ec.tokens[txLog.Address]
+		if !ok || len(txLog.Topics) != 3 || txLog.Topics[0] != TransferEventTopic || // nolint:gomnd
+			len(txLog.Data) != common.HashLength {
+			continue
+		}
+
+		ops = appendTransferOps(
+			ops,
+			startIndex,
+			Erc20TransferOpType,
+			opStatus,
+			common.BytesToAddress(txLog.Topics[1].Bytes()),
+			common.BytesToAddress(txLog.Topics[2].Bytes()),
+			new(big.Int).SetBytes(txLog.Data),
+			token,
+		)
+	}
+
+	return ops
+}
+
+// nftTransferOps returns a debit and a credit operation for
+// each token moved by an ERC-721 Transfer or an ERC-1155
+// TransferSingle or TransferBatch event emitted by a
+// configured contract of that standard, in the currency
+// returned by NftCurrency. ERC-721 transfers move an amount
+// of 1. Like erc20TransferOps, the zero address holds no
+// balance and operations share the status of the receipt.
+func (ec *Client) nftTransferOps(receipt *Receipt, startIndex int) []*RosettaTypes.Operation {
+	var ops []*RosettaTypes.Operation
+	if receipt == nil {
+		return ops
+	}
+
+	opStatus := receiptOpStatus(receipt)
+	for _, txLog := range receipt.Logs {
+		standard, ok := ec.nfts[txLog.Address]
+		if !ok || len(txLog.Topics) != 4 { // nolint:gomnd
+			continue
+		}
+
+		switch {
+		case standard == Erc721Standard && txLog.Topics[0] == TransferEventTopic:
+			// ERC-20 Transfer events have an unindexed amount
+			// and 3 topics.
+			if len(txLog.Data) != 0 {
+				continue
+			}
+
+			id := new(big.Int).SetBytes(txLog.Topics[3].Bytes())
+			ops = appendTransferOps(
+				ops,
+				startIndex,
+				Erc721TransferOpType,
+				opStatus,
+				common.BytesToAddress(txLog.Topics[1].Bytes()),
+				common.BytesToAddress(txLog.Topics[2].Bytes()),
+				big.NewInt(1),
+				NftCurrency(txLog.Address, id, Erc721Standard),
+			)
+		case standard == Erc1155Standard && txLog.Topics[0] == TransferSingleEventTopic:
+			if len(txLog.Data) != 2*common.HashLength { // nolint:gomnd
+				continue
+			}
+
+			id := new(big.Int).SetBytes(txLog.Data[:common.HashLength])
+			ops = appendTransferOps(
+				ops,
+				startIndex,
+				Erc1155TransferOpType,
+				opStatus,
+				common.BytesToAddress(txLog.Topics[2].Bytes()),
+				common.BytesToAddress(txLog.Topics[3].Bytes()),
+				new(big.Int).SetBytes(txLog.Data[common.HashLength:]),
+				NftCurrency(txLog.Address, id, Erc1155Standard),
+			)
+		case standard == Erc1155Standard && txLog.Topics[0] == TransferBatchEventTopic:
+			ids, values, err := ParseErc1155TransferBatchData(txLog.Data)
+			if err != nil {
+				continue
+			}
+
+			for i, id := range ids {
+				ops = appendTransferOps(
+					ops,
+					startIndex,
+					Erc1155TransferOpType,
+					opStatus,
+					common.BytesToAddress(txLog.Topics[2].Bytes()),
+					common.BytesToAddress(txLog.Topics[3].Bytes()),
+					values[i],
+					NftCurrency(txLog.Address, id, Erc1155Standard),
+				)
+			}
+		}
+	}
+
+	return ops
+}
+
+// receiptOpStatus returns the status of the
+// operations of the logs of receipt.
+func receiptOpStatus(receipt *Receipt) string {
+	if receipt.Status != EthTypes.ReceiptStatusSuccessful {
+		return FailureStatus
+	}
+
+	return SuccessStatus
+}
+
+// appendTransferOps appends to ops a debit of from and a
+// related credit of to, skipping the side of the zero address
+// and transfers of no amount.
+func appendTransferOps(
+	ops []*RosettaTypes.Operation,
+	startIndex int,
+	opType string,
+	opStatus string,
+	from common.Address,
+	to common.Address,
+	amount *big.Int,
+	currency *RosettaTypes.Currency,
+) []*RosettaTypes.Operation {
+	if amount.Sign() == 0 {
+		return ops
+	}
+
+	var related []*RosettaTypes.OperationIdentifier
+	if from != (common.Address{}) {
+		op := &RosettaTypes.Operation{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{
+				Index: int64(len(ops) + startIndex),
+			},
+			Type:   opType,
+			Status: RosettaTypes.String(opStatus),
+			Account: &RosettaTypes.AccountIdentifier{
+				Address: from.Hex(),
+			},
+			Amount: &RosettaTypes.Amount{
+				Value:    new(big.Int).Neg(amount).String(),
+				Currency: currency,
+			},
+		}
+		related = []*RosettaTypes.OperationIdentifier{{Index: op.OperationIdentifier.Index}}
+		ops = append(ops, op)
+	}
+
+	if to != (common.Address{}) {
+		ops = append(ops, &RosettaTypes.Operation{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{
+				Index: int64(len(ops) + startIndex),
+			},
+			RelatedOperations: related,
+			Type:              opType,
+			Status:            RosettaTypes.String(opStatus),
+			Account: &RosettaTypes.AccountIdentifier{
+				Address: to.Hex(),
+			},
+			Amount: &RosettaTypes.Amount{
+				Value:    amount.String(),
+				Currency: currency,
+			},
+		})
+	}
+
+	return ops
+}
+
+// hash returns the hash of the transaction.
+func (tx *loadedTransaction) hash() common.Hash {
+	if tx.TxHash != (common.Hash{}) {
+		return tx.TxHash
+	}
+
+	return tx.Transaction.Hash()
+}
+
+// feeOps returns the debit of the fee of tx from its
+// sender or, for fee-delegated transactions, its fee payer.
 func feeOps(tx *loadedTransaction) []*RosettaTypes.Operation {
+	payer := tx.From
+	if tx.FeePayer != nil {
+		payer = tx.FeePayer
+	}
+
 	return []*RosettaTypes.Operation{
 		{
 			OperationIdentifier: &RosettaTypes.OperationIdentifier{
@@ -1106,7 +1632,7 @@ func feeOps(tx *loadedTransaction) []*RosettaTypes.Operation {
 			Type:   FeeOpType,
 			Status: RosettaTypes.String(SuccessStatus),
 			Account: &RosettaTypes.AccountIdentifier{
-				Address: MustChecksum(tx.From.String()),
+				Address: MustChecksum(payer.String()),
 			},
 			Amount: &RosettaTypes.Amount{
 				Value:    new(big.Int).Neg(tx.FeeAmount).String(),
@@ -1396,7 +1922,7 @@ func (ec *Client) populateTransactions(
 			tx,
 		)
 		if err != nil {
-			return nil, fmt.Errorf("%w: cannot parse %s", err, tx.Transaction.Hash().Hex())
+			return nil, fmt.Errorf("%w: cannot parse %s", err, tx.hash().Hex())
 		}
 
 		transactions = append(transactions, transaction)
@@ -1418,6 +1944,10 @@ func (ec *Client) populateTransaction(tx *loadedTransaction) (*RosettaTypes.Tran
 	traceOps := traceOps(traces, len(ops))
 	ops = append(ops, traceOps...)
 
+	// Compute token transfer operations
+	ops = append(ops, ec.erc20TransferOps(tx.Receipt, len(ops))...)
+	ops = append(ops, ec.nftTransferOps(tx.Receipt, len(ops))...)
+
 	// Marshal receipt and trace data
 	// TODO: replace with marshalJSONMap (used in `services`)
 	receiptBytes, err := tx.Receipt.MarshalJSON()
@@ -1435,17 +1965,34 @@ func (ec *Client) populateTransaction(tx *loadedTransaction) (*RosettaTypes.Tran
 		return nil, err
 	}
 
+	metadata := map[string]interface{}{
+		"type":      hexutil.EncodeUint64(uint64(tx.Transaction.Type())),
+		"gas_limit": hexutil.EncodeUint64(tx.Transaction.Gas()),
+		"gas_price": hexutil.EncodeBig(tx.Transaction.GasPrice()),
+		"receipt":   receiptMap,
+		"trace":     traceMap,
+	}
+	if tx.FeePayer != nil {
+		metadata["type"] = hexutil.EncodeUint64(FeeDelegateDynamicFeeTxType)
+		metadata["fee_payer"] = MustChecksum(tx.FeePayer.Hex())
+	}
+
+	// Typed transactions carry an access list,
+	// which may be empty.
+	if tx.Transaction.Type() != types.LegacyTxType {
+		accessList := tx.Transaction.AccessList()
+		if accessList == nil {
+			accessList = types.AccessList{}
+		}
+		metadata["access_list"] = accessList
+	}
+
 	populatedTransaction := &RosettaTypes.Transaction{
 		TransactionIdentifier: &RosettaTypes.TransactionIdentifier{
-			Hash: tx.Transaction.Hash().Hex(),
+			Hash: tx.hash().Hex(),
 		},
 		Operations: ops,
-		Metadata: map[string]interface{}{
-			"gas_limit": hexutil.EncodeUint64(tx.Transaction.Gas()),
-			"gas_price": hexutil.EncodeBig(tx.Transaction.GasPrice()),
-			"receipt":   receiptMap,
-			"trace":     traceMap,
-		},
+		Metadata:   metadata,
 	}
 
 	return populatedTransaction, nil
@@ -1741,17 +2288,58 @@ func (bn *BlockNumber) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// graphqlCallResult is the result of a
+// GraphQL block call.
+type graphqlCallResult struct {
+	Data   hexutil.Bytes `json:"data"`
+	Status BlockNumber   `json:"status"`
+}
+
+// balanceCall returns the contract and the calldata of
+// the call reading the balance of account in currency:
+// balanceOf for ERC-20 and ERC-1155 tokens and ownerOf
+// for ERC-721 tokens.
+func balanceCall(account common.Address, currency *RosettaTypes.Currency) (string, []byte, error) {
+	if contract, id, standard, ok := NftToken(currency); ok {
+		if standard == Erc721Standard {
+			return contract.Hex(), Erc721OwnerOfData(id), nil
+		}
+
+		return contract.Hex(), Erc1155BalanceOfData(account, id), nil
+	}
+
+	token, ok := TokenAddress(currency)
+	if !ok {
+		return "", nil, fmt.Errorf("%s is not WEMIX or a token with a contract address", currency.Symbol)
+	}
+
+	return token, Erc20BalanceOfData(account), nil
+}
+
+// tokenBalanceField returns the alias of the
+// balanceOf call of the i-th currency.
+func tokenBalanceField(i int) string {
+	return fmt.Sprintf("token%d", i)
+}
+
 // Balance returns the balance of a *RosettaTypes.AccountIdentifier
-// at a *RosettaTypes.PartialBlockIdentifier.
+// at a *RosettaTypes.PartialBlockIdentifier. The WEMIX balance
+// is returned unless currencies are requested, in which case
+// an amount is returned for each currency: WEMIX, an ERC-20
+// token, whose balance is read with balanceOf, or a currency
+// returned by NftCurrency, whose balance is the number of
+// tokens of that id the account owns.
 //
 // We must use graphql to get the balance atomically (the
 // rpc method for balance does not allow for querying
 // by block hash nor return the block hash where
-// the balance was fetched).
+// the balance was fetched). Token balances are read with
+// calls in the same query, at the same block.
 func (ec *Client) Balance(
 	ctx context.Context,
 	account *RosettaTypes.AccountIdentifier,
 	block *RosettaTypes.PartialBlockIdentifier,
+	currencies []*RosettaTypes.Currency,
 ) (*RosettaTypes.AccountBalanceResponse, error) {
 	blockQuery := ""
 	if block != nil {
@@ -1763,6 +2351,28 @@ func (ec *Client) Balance(
 		}
 	}
 
+	callsQuery := ""
+	for i, currency := range currencies {
+		if RosettaTypes.Hash(currency) == RosettaTypes.Hash(Currency) {
+			continue
+		}
+
+		token, data, err := balanceCall(common.HexToAddress(account.Address), currency)
+		if err != nil {
+			return nil, err
+		}
+
+		callsQuery += fmt.Sprintf(`
+                                %s: call(data:{to:"%s", data:"%s"}){
+                                        data
+                                        status
+                                }`,
+			tokenBalanceField(i),
+			token,
+			hexutil.Encode(data),
+		)
+	}
+
 	result, err := ec.g.Query(ctx, fmt.Sprintf(`{
                         block(%s){
                                 hash
@@ -1771,9 +2381,9 @@ func (ec *Client) Balance(
                                         balance
                                         transactionCount
                                         code
-                                }
+                                }%s
                         }
-                }`, blockQuery, account.Address))
+                }`, blockQuery, account.Address, callsQuery))
 
 	if err != nil {
 		return nil, err
@@ -1808,17 +2418,25 @@ func (ec *Client) Balance(
 	balance := bal.Data.Block.Account.Balance
 	nonce := bal.Data.Block.Account.Nonce
 
+	balances := []*RosettaTypes.Amount{
+		{
+			Value:    balance.String(),
+			Currency: Currency,
+		},
+	}
+	if len(currencies) > 0 {
+		balances, err = currencyBalances(result, common.HexToAddress(account.Address), balance, currencies)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	tmp, err := json.Marshal(balance)
 	tmp1, err := json.Marshal(nonce)
 	log.Printf("Balance(): account: %s, balance: %s, nonce: %s, index: %d", account.Address, string(tmp), string(tmp1), *block.Index)
 
 	return &RosettaTypes.AccountBalanceResponse{
-		Balances: []*RosettaTypes.Amount{
-			{
-				Value:    balance.String(),
-				Currency: Currency,
-			},
-		},
+		Balances: balances,
 		BlockIdentifier: &RosettaTypes.BlockIdentifier{
 			Hash:  bal.Data.Block.Hash,
 			Index: bal.Data.Block.Number,
@@ -1830,6 +2448,76 @@ func (ec *Client) Balance(
 	}, nil
 }
 
+// currencyBalances returns the amount of each currency in
+// the result of a balance query: the WEMIX balance or the
+// result of the balance call of a token. An ERC-721 token
+// has a balance of 1 if account is its owner, and 0
+// otherwise.
+func currencyBalances(
+	result string,
+	account common.Address,
+	balance *big.Int,
+	currencies []*RosettaTypes.Currency,
+) ([]*RosettaTypes.Amount, error) {
+	var calls struct {
+		Data struct {
+			Block map[string]json.RawMessage `json:"block"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(result), &calls); err != nil {
+		return nil, err
+	}
+
+	balances := make([]*RosettaTypes.Amount, len(currencies))
+	for i, currency := range currencies {
+		if RosettaTypes.Hash(currency) == RosettaTypes.Hash(Currency) {
+			balances[i] = &RosettaTypes.Amount{
+				Value:    balance.String(),
+				Currency: currency,
+			}
+			continue
+		}
+
+		raw, ok := calls.Data.Block[tokenBalanceField(i)]
+		if !ok {
+			return nil, fmt.Errorf("missing balanceOf result of %s", currency.Symbol)
+		}
+
+		var call graphqlCallResult
+		if err := json.Unmarshal(raw, &call); err != nil {
+			return nil, err
+		}
+
+		// ownerOf reverts for tokens that do not exist
+		// (or were burned), which no account owns.
+		if _, _, standard, ok := NftToken(currency); ok && standard == Erc721Standard {
+			owned := call.Status == 1 && len(call.Data) == common.HashLength &&
+				common.BytesToAddress(call.Data) == account
+			value := "0"
+			if owned {
+				value = "1"
+			}
+
+			balances[i] = &RosettaTypes.Amount{
+				Value:    value,
+				Currency: currency,
+			}
+			continue
+		}
+
+		if call.Status != 1 || len(call.Data) != common.HashLength {
+			return nil, fmt.Errorf("balanceOf of %s failed", currency.Symbol)
+		}
+
+		balances[i] = &RosettaTypes.Amount{
+			Value:    new(big.Int).SetBytes(call.Data).String(),
+			Currency: currency,
+		}
+	}
+
+	return balances, nil
+}
+
 // GetBlockByNumberInput is the input to the call
 // method "eth_getBlockByNumber".
 type GetBlockByNumberInput struct {
@@ -1953,7 +2641,7 @@ func (ec *Client) GetMempool(ctx context.Context) (*RosettaTypes.MempoolResponse
 	for _, inner := range response.Pending {
 		for _, info := range inner {
 			identifiers = append(identifiers, &RosettaTypes.TransactionIdentifier{
-				Hash: info.tx.Hash().String(),
+				Hash: info.Hash().String(),
 			})
 		}
 	}
@@ -1961,10 +2649,225 @@ func (ec *Client) GetMempool(ctx context.Context) (*RosettaTypes.MempoolResponse
 	for _, inner := range response.Queued {
 		for _, info := range inner {
 			identifiers = append(identifiers, &RosettaTypes.TransactionIdentifier{
-				Hash: info.tx.Hash().String(),
+				Hash: info.Hash().String(),
 			})
 		}
 	}
 
 	return &RosettaTypes.MempoolResponse{TransactionIdentifiers: identifiers}, nil
 }
+
+// txPoolContentFromResponse represents the response for a call
+// to gwemix node on the "txpool_contentFrom" method.
+type txPoolContentFromResponse struct {
+	Pending txPoolInner `json:"pending"`
+	Queued  txPoolInner `json:"queued"`
+}
+
+// MempoolEntry is a transaction waiting in the txpool.
+type MempoolEntry struct {
+	Hash     common.Hash
+	Pool     string
+	Sender   common.Address
+	Nonce    uint64
+	GasPrice *big.Int
+}
+
+// MempoolEntries returns the transactions waiting in the pending
+// and queued pools of the txpool, ordered by pool, sender and
+// nonce. If account is not nil, only the transactions sent by
+// account are fetched, with txpool_contentFrom.
+func (ec *Client) MempoolEntries(
+	ctx context.Context,
+	account *common.Address,
+) ([]*MempoolEntry, error) {
+	var pools map[string]txPool
+	if account != nil {
+		var response txPoolContentFromResponse
+		if err := ec.c.CallContext(ctx, &response, "txpool_contentFrom", *account); err != nil {
+			return nil, err
+		}
+
+		pools = map[string]txPool{
+			PendingPool: {account.Hex(): response.Pending},
+			QueuedPool:  {account.Hex(): response.Queued},
+		}
+	} else {
+		var response txPoolContentResponse
+		if err := ec.c.CallContext(ctx, &response, "txpool_content"); err != nil {
+			return nil, err
+		}
+
+		pools = map[string]txPool{
+			PendingPool: response.Pending,
+			QueuedPool:  response.Queued,
+		}
+	}
+
+	entries := make([]*MempoolEntry, 0)
+	for _, pool := range []string{PendingPool, QueuedPool} {
+		for sender, inner := range pools[pool] {
+			for _, info := range inner {
+				if info.tx == nil {
+					continue
+				}
+
+				from := common.HexToAddress(sender)
+				if info.From != nil {
+					from = *info.From
+				}
+
+				entries = append(entries, &MempoolEntry{
+					Hash:     info.Hash(),
+					Pool:     pool,
+					Sender:   from,
+					Nonce:    info.tx.Nonce(),
+					GasPrice: info.tx.GasPrice(),
+				})
+			}
+		}
+	}
+
+	poolOrder := map[string]int{PendingPool: 0, QueuedPool: 1}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Pool != entries[j].Pool {
+			return poolOrder[entries[i].Pool] < poolOrder[entries[j].Pool]
+		}
+		if entries[i].Sender != entries[j].Sender {
+			return bytes.Compare(entries[i].Sender.Bytes(), entries[j].Sender.Bytes()) < 0
+		}
+
+		return entries[i].Nonce < entries[j].Nonce
+	})
+
+	return entries, nil
+}
+
+// TxPoolStatus is the number of transactions
+// in each pool of the txpool.
+type TxPoolStatus struct {
+	Pending uint64
+	Queued  uint64
+}
+
+// GetTxPoolStatus returns the number of transactions in the
+// txpool with txpool_status.
+func (ec *Client) GetTxPoolStatus(ctx context.Context) (*TxPoolStatus, error) {
+	var response map[string]hexutil.Uint64
+	if err := ec.c.CallContext(ctx, &response, "txpool_status"); err != nil {
+		return nil, err
+	}
+
+	return &TxPoolStatus{
+		Pending: uint64(response[PendingPool]),
+		Queued:  uint64(response[QueuedPool]),
+	}, nil
+}
+
+// MempoolTransaction returns a transaction waiting in the txpool
+// with the operations it is predicted to make once mined: a fee
+// debit of its gas limit at the offered gas price and the value
+// transfers of its calls. The calls are traced with
+// debug_traceCall against the pending state. If the trace fails,
+// only the value transfer of the transaction itself is predicted
+// and the "traced" metadata is false.
+func (ec *Client) MempoolTransaction(
+	ctx context.Context,
+	hash common.Hash,
+) (*RosettaTypes.Transaction, error) {
+	body, err := ec.pendingRPCTransaction(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	tx, from := body.tx, *body.From
+
+	traced := true
+	trace, err := ec.pendingTrace(ctx, tx, from)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		traced = false
+		trace = pendingCall(tx, from)
+	}
+
+	loaded := body.LoadedTransaction()
+	loaded.FeeAmount = new(big.Int).Mul(new(big.Int).SetUint64(tx.Gas()), tx.GasPrice())
+	loaded.Trace = trace
+
+	ops := feeOps(loaded)
+	ops = append(ops, traceOps(flattenTraces(loaded.Trace, []*flatCall{}), len(ops))...)
+
+	metadata := map[string]interface{}{
+		"type":      hexutil.EncodeUint64(uint64(tx.Type())),
+		"nonce":     hexutil.EncodeUint64(tx.Nonce()),
+		"gas_limit": hexutil.EncodeUint64(tx.Gas()),
+		"gas_price": hexutil.EncodeBig(tx.GasPrice()),
+		"traced":    traced,
+	}
+	if loaded.FeePayer != nil {
+		metadata["type"] = hexutil.EncodeUint64(FeeDelegateDynamicFeeTxType)
+		metadata["fee_payer"] = MustChecksum(loaded.FeePayer.Hex())
+	}
+
+	return &RosettaTypes.Transaction{
+		TransactionIdentifier: &RosettaTypes.TransactionIdentifier{
+			Hash: loaded.hash().Hex(),
+		},
+		Operations: ops,
+		Metadata:   metadata,
+	}, nil
+}
+
+// pendingTrace traces tx from from with debug_traceCall
+// against the pending state. The call has no gas price, so
+// the fee does not count against the balance of from.
+func (ec *Client) pendingTrace(
+	ctx context.Context,
+	tx *types.Transaction,
+	from common.Address,
+) (*Call, error) {
+	if err := ec.traceSemaphore.Acquire(ctx, semaphoreTraceWeight); err != nil {
+		return nil, err
+	}
+	defer ec.traceSemaphore.Release(semaphoreTraceWeight)
+
+	msg := ethereum.CallMsg{
+		From:       from,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}
+
+	var call *Call
+	if err := ec.c.CallContext(ctx, &call, "debug_traceCall", toCallArg(msg), "pending", ec.tc); err != nil {
+		return nil, err
+	}
+	if call == nil {
+		return nil, errors.New("empty trace")
+	}
+
+	return call, nil
+}
+
+// pendingCall returns the top-level call of tx from
+// from, which transfers the value of tx.
+func pendingCall(tx *types.Transaction, from common.Address) *Call {
+	call := &Call{
+		Type:    "CALL",
+		From:    from,
+		Value:   tx.Value(),
+		GasUsed: new(big.Int),
+	}
+	if tx.To() == nil {
+		call.Type = "CREATE"
+		call.To = crypto.CreateAddress(from, tx.Nonce())
+	} else {
+		call.To = *tx.To()
+	}
+
+	return call
+}
@@ -15,6 +15,7 @@
 package wemix
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -23,6 +24,7 @@ import (
 	"math/big"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 
 	mocks "github.com/wemixarchive/rosetta-wemix/mocks/wemix"
@@ -32,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
@@ -560,6 +563,7 @@ func TestBalance(t *testing.T) {
 			Address: "0x2f93B2f047E05cdf602820Ac4B3178efc2b43D55",
 		},
 		nil,
+		nil,
 	)
 
 	assert.Equal(t, &RosettaTypes.AccountBalanceResponse{
@@ -629,6 +633,7 @@ func TestBalance_Historical_Hash(t *testing.T) {
 			),
 			Index: RosettaTypes.Int64(19388485),
 		},
+		nil,
 	)
 	assert.Equal(t, &RosettaTypes.AccountBalanceResponse{
 		BlockIdentifier: &RosettaTypes.BlockIdentifier{
@@ -694,13 +699,88 @@ func TestBalance_Historical_Index(t *testing.T) {
 		&RosettaTypes.PartialBlockIdentifier{
 			Index: RosettaTypes.Int64(19388485),
 		},
+		nil,
+	)
+	assert.Equal(t, &RosettaTypes.AccountBalanceResponse{
+		BlockIdentifier: &RosettaTypes.BlockIdentifier{
+			Hash:  "0xb72ccf3ec2617015c9c3751c66a4918a7fd9a0d1667ac7cadb1601a6f442889d",
+			Index: 19388485,
+		},
+		Balances: []*RosettaTypes.Amount{
+			{
+				Value:    "1390630720000000000",
+				Currency: Currency,
+			},
+		},
+		Metadata: map[string]interface{}{
+			"code":  "0x",
+			"nonce": int64(0),
+		},
+	}, resp)
+	assert.NoError(t, err)
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestBalance_Tokens(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	account := common.HexToAddress("0x2f93B2f047E05cdf602820Ac4B3178efc2b43D55")
+	token := &RosettaTypes.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+		},
+	}
+	tokenCall := fmt.Sprintf(
+		`token0: call(data:{to:"0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1", data:"%s"})`,
+		hexutil.Encode(Erc20BalanceOfData(account)),
+	)
+	mockGraphQL.On(
+		"Query",
+		ctx,
+		mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, `block(number: 19388485)`) &&
+				strings.Contains(query, tokenCall) &&
+				!strings.Contains(query, "token1")
+		}),
+	).Return(
+		`{"data":{"block":{
+			"hash":"0xb72ccf3ec2617015c9c3751c66a4918a7fd9a0d1667ac7cadb1601a6f442889d",
+			"number":19388485,
+			"account":{"balance":"0x134c83459f588000","transactionCount":"0x0","code":"0x"},
+			"token0":{"data":"0x00000000000000000000000000000000000000000000000000000000000003e8","status":"0x1"}
+		}}}`,
+		nil,
+	).Once()
+
+	resp, err := c.Balance(
+		ctx,
+		&RosettaTypes.AccountIdentifier{Address: account.Hex()},
+		&RosettaTypes.PartialBlockIdentifier{Index: RosettaTypes.Int64(19388485)},
+		[]*RosettaTypes.Currency{token, Currency},
 	)
+	assert.NoError(t, err)
 	assert.Equal(t, &RosettaTypes.AccountBalanceResponse{
 		BlockIdentifier: &RosettaTypes.BlockIdentifier{
 			Hash:  "0xb72ccf3ec2617015c9c3751c66a4918a7fd9a0d1667ac7cadb1601a6f442889d",
 			Index: 19388485,
 		},
 		Balances: []*RosettaTypes.Amount{
+			{
+				Value:    "1000",
+				Currency: token,
+			},
 			{
 				Value:    "1390630720000000000",
 				Currency: Currency,
@@ -711,7 +791,93 @@ func TestBalance_Historical_Index(t *testing.T) {
 			"nonce": int64(0),
 		},
 	}, resp)
+
+	// A reverted balanceOf fails the query.
+	mockGraphQL.On("Query", ctx, mock.Anything).Return(
+		`{"data":{"block":{
+			"hash":"0xb72ccf3ec2617015c9c3751c66a4918a7fd9a0d1667ac7cadb1601a6f442889d",
+			"number":19388485,
+			"account":{"balance":"0x134c83459f588000","transactionCount":"0x0","code":"0x"},
+			"token0":{"data":"0x","status":"0x0"}
+		}}}`,
+		nil,
+	).Once()
+	resp, err = c.Balance(
+		ctx,
+		&RosettaTypes.AccountIdentifier{Address: account.Hex()},
+		&RosettaTypes.PartialBlockIdentifier{Index: RosettaTypes.Int64(19388485)},
+		[]*RosettaTypes.Currency{token},
+	)
+	assert.Nil(t, resp)
+	assert.Error(t, err)
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestBalance_Nfts(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	account := common.HexToAddress("0x2f93B2f047E05cdf602820Ac4B3178efc2b43D55")
+	collection := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	owned := NftCurrency(collection, big.NewInt(7), Erc721Standard)
+	burned := NftCurrency(collection, big.NewInt(8), Erc721Standard)
+	multi := NftCurrency(collection, big.NewInt(3), Erc1155Standard)
+	mockGraphQL.On(
+		"Query",
+		ctx,
+		mock.MatchedBy(func(query string) bool {
+			return strings.Contains(query, fmt.Sprintf(
+				`token0: call(data:{to:"%s", data:"%s"})`,
+				collection.Hex(),
+				hexutil.Encode(Erc721OwnerOfData(big.NewInt(7))),
+			)) && strings.Contains(query, fmt.Sprintf(
+				`token2: call(data:{to:"%s", data:"%s"})`,
+				collection.Hex(),
+				hexutil.Encode(Erc1155BalanceOfData(account, big.NewInt(3))),
+			))
+		}),
+	).Return(
+		fmt.Sprintf(`{"data":{"block":{
+			"hash":"0xb72ccf3ec2617015c9c3751c66a4918a7fd9a0d1667ac7cadb1601a6f442889d",
+			"number":19388485,
+			"account":{"balance":"0x134c83459f588000","transactionCount":"0x0","code":"0x"},
+			"token0":{"data":"%s","status":"0x1"},
+			"token1":{"data":"0x","status":"0x0"},
+			"token2":{"data":"0x0000000000000000000000000000000000000000000000000000000000000005","status":"0x1"}
+		}}}`, hexutil.Encode(common.LeftPadBytes(account.Bytes(), 32))),
+		nil,
+	).Once()
+
+	resp, err := c.Balance(
+		ctx,
+		&RosettaTypes.AccountIdentifier{Address: account.Hex()},
+		&RosettaTypes.PartialBlockIdentifier{Index: RosettaTypes.Int64(19388485)},
+		[]*RosettaTypes.Currency{owned, burned, multi},
+	)
 	assert.NoError(t, err)
+	assert.Equal(t, []*RosettaTypes.Amount{
+		{
+			Value:    "1",
+			Currency: owned,
+		},
+		{
+			Value:    "0",
+			Currency: burned,
+		},
+		{
+			Value:    "5",
+			Currency: multi,
+		},
+	}, resp.Balances)
 
 	mockJSONRPC.AssertExpectations(t)
 	mockGraphQL.AssertExpectations(t)
@@ -755,6 +921,7 @@ func TestBalance_InvalidAddress(t *testing.T) {
 			Address: "0x4cfc400fed52f9681b42454c2db4b18ab98f8de",
 		},
 		nil,
+		nil,
 	)
 	assert.Nil(t, resp)
 	assert.Error(t, err)
@@ -805,6 +972,7 @@ func TestBalance_InvalidHash(t *testing.T) {
 				"0x7d2a2713026a0e66f131878de2bb2df2fff6c24562c1df61ec0265e5fedf2626",
 			),
 		},
+		nil,
 	)
 	assert.Nil(t, resp)
 	assert.Error(t, err)
@@ -2079,7 +2247,7 @@ func TestSuggestGasPrice(t *testing.T) {
 	mockGraphQL.AssertExpectations(t)
 }
 
-func TestSendTransaction(t *testing.T) {
+func TestSuggestGasTipCap(t *testing.T) {
 	mockJSONRPC := &mocks.JSONRPC{}
 	mockGraphQL := &mocks.GraphQL{}
 
@@ -2094,51 +2262,78 @@ func TestSendTransaction(t *testing.T) {
 		"CallContext",
 		ctx,
 		mock.Anything,
-		"eth_sendRawTransaction",
-		"0xf86a80843b9aca00825208941ff502f9fe838cd772874cb67d0d96b93fd1d6d78725d4b6199a415d8029a01d110bf9fd468f7d00b3ce530832e99818835f45e9b08c66f8d9722264bb36c7a02711f47ec99f9ac585840daef41b7118b52ec72f02fcb30d874d36b10b668b59", // nolint
+		"eth_maxPriorityFeePerGas",
 	).Return(
 		nil,
-	).Once()
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*hexutil.Big)
 
-	rawTx, err := ioutil.ReadFile("testdata/submitted_tx.json")
+			*r = *(*hexutil.Big)(big.NewInt(1000000000))
+		},
+	).Once()
+	resp, err := c.SuggestGasTipCap(
+		ctx,
+	)
+	assert.Equal(t, big.NewInt(1000000000), resp)
 	assert.NoError(t, err)
 
-	tx := new(types.Transaction)
-	assert.NoError(t, tx.UnmarshalJSON(rawTx))
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
 
-	assert.NoError(t, c.SendTransaction(
+func TestFeeHistory(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	mockJSONRPC.On(
+		"CallContext",
 		ctx,
-		tx,
-	))
+		mock.Anything,
+		"eth_feeHistory",
+		hexutil.Uint(2),
+		"latest",
+		[]float64{10, 90},
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*feeHistoryResultMarshaling)
+
+			assert.NoError(t, json.Unmarshal([]byte(`{
+				"oldestBlock": "0x10",
+				"reward": [["0x1", "0x2"], ["0x3", "0x4"]],
+				"baseFeePerGas": ["0x64", "0x65", "0x66"],
+				"gasUsedRatio": [0.5, 0.25]
+			}`), r))
+		},
+	).Once()
+	resp, err := c.FeeHistory(ctx, 2, nil, []float64{10, 90})
+	assert.Equal(t, &FeeHistory{
+		OldestBlock: big.NewInt(16),
+		Reward: [][]*big.Int{
+			{big.NewInt(1), big.NewInt(2)},
+			{big.NewInt(3), big.NewInt(4)},
+		},
+		BaseFee:      []*big.Int{big.NewInt(100), big.NewInt(101), big.NewInt(102)},
+		GasUsedRatio: []float64{0.5, 0.25},
+	}, resp)
+	assert.NoError(t, err)
 
 	mockJSONRPC.AssertExpectations(t)
 	mockGraphQL.AssertExpectations(t)
 }
 
-func TestGetMempool(t *testing.T) {
+func TestPendingTransaction(t *testing.T) {
 	mockJSONRPC := &mocks.JSONRPC{}
 	mockGraphQL := &mocks.GraphQL{}
-	ctx := context.Background()
-	expectedMempool := &RosettaTypes.MempoolResponse{
-		TransactionIdentifiers: []*RosettaTypes.TransactionIdentifier{
-			{Hash: "0x994024ef9f05d1cb25d01572642c1f550c78d214a52c306bb100d22c025b59d4"},
-			{Hash: "0x0859cb844087a280fa031ce2a0879f4f9832f431d6de67f57d0ca32e90dd9e21"},
-			{Hash: "0xa6de13e0a4465c9b55726d0826d020ed179fa1bda882a00e90aa467266af1815"},
-			{Hash: "0x94e28f01121fd56dead7b89c46c8d6ba32bb79dad5f7e29c1d523224479f10f4"},
-			{Hash: "0xf98b8a6589fa27f66545f31d443140c16eaa3da3896e66c4f05a03defa12cda4"},
-			{Hash: "0x4c652b9e779277f06e17b6a4c4db6658ac012f7c93f5eeab80e7802cce3ff556"},
-			{Hash: "0xa81f636c4b47831efd324dce5c48fe3a3d7a4f0020fae063887e8c2765ff1088"},
-			{Hash: "0x3bc19098a49974002ba933ac8f9c753ce5af538ab93fc68586849e8b8b0dce80"},
-			{Hash: "0x3401ec802cbe4b02d5be18717b53bb8177bd746f95a54da4674d7c27620facda"},
-			{Hash: "0x3d1c58eced8f15f3224e9b2579420d078dbd2adba8e825cee91fd306b0943f89"},
-			{Hash: "0xda591f0b15423aedb52f6b0e778b1fbc6757547d69277e2ba1aa7093583d1efb"},
-			{Hash: "0xb39652e66c57a6693e44b92b5c471952c26cabf9442a9a76c372f8690658cb4c"},
-			{Hash: "0x1e8700bf7215b2da0cfadcf34717f387577254e0871d828e5453a0593ce8060f"},
-			{Hash: "0x83811383fb7840a03c25a7cbae7e9af138b17853563eb9e212727be2d0b9667f"},
-			{Hash: "0x1e53751e1312cae3324a6b36c67dc95bfec993d7b4939c0de8c0dc761a0afd31"},
-			{Hash: "0xc1052f9378db5a779c42ae2de9a0b94c8a6357c815446d6ba55485dcc1b187ef"},
-		},
-	}
 
 	c := &Client{
 		c:              mockJSONRPC,
@@ -2146,38 +2341,1162 @@ func TestGetMempool(t *testing.T) {
 		traceSemaphore: semaphore.NewWeighted(100),
 	}
 
+	key, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.NewLondonSigner(big.NewInt(1112))
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+		Nonce:    3,
+		GasPrice: big.NewInt(100000000000),
+		Gas:      21000,
+		To:       &common.Address{0x01},
+		Value:    big.NewInt(1000),
+	})
+	assert.NoError(t, err)
+
+	rpcTx := func(blockNumber interface{}) json.RawMessage {
+		txJSON, err := tx.MarshalJSON()
+		assert.NoError(t, err)
+
+		var m map[string]interface{}
+		assert.NoError(t, json.Unmarshal(txJSON, &m))
+		m["from"] = from.Hex()
+		m["blockNumber"] = blockNumber
+
+		raw, err := json.Marshal(m)
+		assert.NoError(t, err)
+		return raw
+	}
+
+	ctx := context.Background()
 	mockJSONRPC.On(
-		"CallContext", ctx, mock.Anything, "txpool_content",
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_getTransactionByHash",
+		tx.Hash(),
 	).Return(
 		nil,
 	).Run(
 		func(args mock.Arguments) {
-			r, ok := args.Get(1).(*txPoolContentResponse)
-			assert.True(t, ok)
+			r := args.Get(1).(*json.RawMessage)
 
-			file, err := ioutil.ReadFile("testdata/txpool_content.json")
-			assert.NoError(t, err)
+			*r = rpcTx(nil)
+		},
+	).Once()
+	pendingTx, pendingFrom, err := c.PendingTransaction(ctx, tx.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, tx.Hash(), pendingTx.Hash())
+	assert.Equal(t, from, pendingFrom)
 
-			err = json.Unmarshal(file, r)
-			assert.NoError(t, err)
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_getTransactionByHash",
+		tx.Hash(),
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*json.RawMessage)
+
+			*r = rpcTx("0x10")
 		},
 	).Once()
+	pendingTx, _, err = c.PendingTransaction(ctx, tx.Hash())
+	assert.Nil(t, pendingTx)
+	assert.True(t, errors.Is(err, ErrTransactionNotPending))
 
-	actualMempool, err := c.GetMempool(ctx)
-	assert.NoError(t, err)
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
 
-	assert.Len(t, actualMempool.TransactionIdentifiers, len(expectedMempool.TransactionIdentifiers))
+func TestPendingBalanceAt(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
 
-	// Sort both slices to compare later
-	sort.Slice(expectedMempool.TransactionIdentifiers, func(i, j int) bool {
-		return expectedMempool.TransactionIdentifiers[i].Hash < expectedMempool.TransactionIdentifiers[j].Hash
-	})
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
 
-	sort.Slice(actualMempool.TransactionIdentifiers, func(i, j int) bool {
-		return actualMempool.TransactionIdentifiers[i].Hash < actualMempool.TransactionIdentifiers[j].Hash
-	})
+	ctx := context.Background()
+	account := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_getBalance",
+		account,
+		"pending",
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*hexutil.Big)
 
-	assert.True(t, reflect.DeepEqual(actualMempool, expectedMempool))
+			*r = *(*hexutil.Big)(big.NewInt(1000))
+		},
+	).Once()
+	resp, err := c.PendingBalanceAt(ctx, account)
+	assert.Equal(t, big.NewInt(1000), resp)
+	assert.NoError(t, err)
 
 	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+type testDataError struct {
+	data string
+}
+
+func (e *testDataError) Error() string          { return "execution reverted" }
+func (e *testDataError) ErrorData() interface{} { return e.data }
+
+func TestPendingCallContract(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	msg := ethereum.CallMsg{
+		From: common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"),
+		To:   &to,
+		Data: []byte{0x01},
+	}
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_call",
+		toCallArg(msg),
+		"pending",
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*hexutil.Bytes)
+
+			*r = hexutil.Bytes{0x02}
+		},
+	).Once()
+	resp, err := c.PendingCallContract(ctx, msg)
+	assert.Equal(t, []byte{0x02}, resp)
+	assert.NoError(t, err)
+
+	// Error("not allowed")
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_call",
+		toCallArg(msg),
+		"pending",
+	).Return(
+		&testDataError{
+			data: "0x08c379a0" +
+				"0000000000000000000000000000000000000000000000000000000000000020" +
+				"000000000000000000000000000000000000000000000000000000000000000b" +
+				"6e6f7420616c6c6f776564000000000000000000000000000000000000000000",
+		},
+	).Once()
+	resp, err = c.PendingCallContract(ctx, msg)
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, ErrExecutionReverted))
+	assert.Equal(t, &RevertError{Reason: "not allowed"}, err)
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestEstimateGas(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	from := common.HexToAddress("0xfFC614eE978630D7fB0C06758DeB580c152154d3")
+	to := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	data := Erc20TransferData(from, big.NewInt(1000))
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_estimateGas",
+		map[string]interface{}{
+			"from": from,
+			"to":   &to,
+			"data": hexutil.Bytes(data),
+		},
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*hexutil.Uint64)
+
+			*r = hexutil.Uint64(51000)
+		},
+	).Once()
+	resp, err := c.EstimateGas(
+		ctx,
+		ethereum.CallMsg{
+			From: from,
+			To:   &to,
+			Data: data,
+		},
+	)
+	assert.Equal(t, uint64(51000), resp)
+	assert.NoError(t, err)
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestCreateAccessList(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	from := common.HexToAddress("0xfFC614eE978630D7fB0C06758DeB580c152154d3")
+	to := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	data := Erc20TransferData(from, big.NewInt(1000))
+	accessList := types.AccessList{
+		{
+			Address:     to,
+			StorageKeys: []common.Hash{common.HexToHash("0x01")},
+		},
+	}
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_createAccessList",
+		map[string]interface{}{
+			"from": from,
+			"to":   &to,
+			"data": hexutil.Bytes(data),
+		},
+		"pending",
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*accessListResult)
+
+			r.AccessList = accessList
+			r.GasUsed = hexutil.Uint64(31000)
+		},
+	).Once()
+	resp, err := c.CreateAccessList(
+		ctx,
+		ethereum.CallMsg{
+			From: from,
+			To:   &to,
+			Data: data,
+		},
+	)
+	assert.Equal(t, accessList, resp)
+	assert.NoError(t, err)
+
+	// Failing calls are reported as reverts.
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_createAccessList",
+		map[string]interface{}{
+			"from": from,
+			"to":   &to,
+		},
+		"pending",
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*accessListResult)
+
+			r.Error = "execution reverted"
+		},
+	).Once()
+	resp, err = c.CreateAccessList(
+		ctx,
+		ethereum.CallMsg{
+			From: from,
+			To:   &to,
+		},
+	)
+	assert.Nil(t, resp)
+	assert.True(t, errors.Is(err, ErrExecutionReverted))
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestPopulateTransaction_AccessList(t *testing.T) {
+	from := common.HexToAddress("0xfFC614eE978630D7fB0C06758DeB580c152154d3")
+	to := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	accessList := types.AccessList{
+		{
+			Address:     to,
+			StorageKeys: []common.Hash{common.HexToHash("0x01")},
+		},
+	}
+
+	var tests = map[string]struct {
+		tx *types.Transaction
+
+		expectedType       string
+		expectedAccessList interface{}
+	}{
+		"legacy": {
+			tx: types.NewTx(&types.LegacyTx{
+				GasPrice: big.NewInt(100),
+				Gas:      21000,
+				To:       &to,
+				Value:    big.NewInt(1),
+			}),
+			expectedType: "0x0",
+		},
+		"access list": {
+			tx: types.NewTx(&types.AccessListTx{
+				ChainID:    big.NewInt(1112),
+				GasPrice:   big.NewInt(100),
+				Gas:        25300,
+				To:         &to,
+				Value:      big.NewInt(1),
+				AccessList: accessList,
+			}),
+			expectedType:       "0x1",
+			expectedAccessList: accessList,
+		},
+		"dynamic fee": {
+			tx: types.NewTx(&types.DynamicFeeTx{
+				ChainID:   big.NewInt(1112),
+				GasTipCap: big.NewInt(1),
+				GasFeeCap: big.NewInt(100),
+				Gas:       21000,
+				To:        &to,
+				Value:     big.NewInt(1),
+			}),
+			expectedType:       "0x2",
+			expectedAccessList: types.AccessList{},
+		},
+	}
+
+	c := &Client{}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			tx, err := c.populateTransaction(&loadedTransaction{
+				Transaction: test.tx,
+				From:        &from,
+				FeeAmount:   big.NewInt(2100000),
+				Miner:       to.Hex(),
+				Trace: &Call{
+					Type:    "CALL",
+					From:    from,
+					To:      to,
+					Value:   big.NewInt(1),
+					GasUsed: big.NewInt(0),
+				},
+				RawTrace: json.RawMessage(`{}`),
+				Receipt:  &Receipt{Status: 1},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, test.expectedType, tx.Metadata["type"])
+
+			accessList, ok := tx.Metadata["access_list"]
+			assert.Equal(t, test.expectedAccessList != nil, ok)
+			if ok {
+				assert.Equal(t, test.expectedAccessList, accessList)
+			}
+		})
+	}
+}
+
+func TestPopulateTransaction_FeeDelegated(t *testing.T) {
+	key, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	feePayer := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	to := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	hash := common.HexToHash("0x5f7ec1f0a5b3e6c0f49f3c1d2e0b44c7d28c1a0fbd9e2b8d4f7a36c0b1e5d9a2")
+
+	senderTx, err := types.SignNewTx(key, types.NewLondonSigner(big.NewInt(1112)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1112),
+		Nonce:     3,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	})
+	assert.NoError(t, err)
+
+	rpcTxJSON := func(fields map[string]interface{}) []byte {
+		txJSON, err := senderTx.MarshalJSON()
+		assert.NoError(t, err)
+
+		var m map[string]interface{}
+		assert.NoError(t, json.Unmarshal(txJSON, &m))
+		m["type"] = "0x16"
+		m["from"] = from.Hex()
+		m["hash"] = hash.Hex()
+		m["feePayer"] = feePayer.Hex()
+		m["fv"] = "0x1"
+		m["fr"] = "0x2"
+		m["fs"] = "0x3"
+		for k, v := range fields {
+			m[k] = v
+		}
+
+		raw, err := json.Marshal(m)
+		assert.NoError(t, err)
+		return raw
+	}
+
+	var body rpcTransaction
+	assert.NoError(t, json.Unmarshal(rpcTxJSON(nil), &body))
+	assert.Equal(t, senderTx.Hash(), body.tx.Hash())
+	assert.Equal(t, hash, body.Hash())
+
+	loaded := body.LoadedTransaction()
+	loaded.FeeAmount = big.NewInt(2100000)
+	loaded.Trace = &Call{
+		Type:    "CALL",
+		From:    from,
+		To:      to,
+		Value:   big.NewInt(1),
+		GasUsed: big.NewInt(0),
+	}
+	loaded.RawTrace = json.RawMessage(`{}`)
+	loaded.Receipt = &Receipt{Status: 1}
+
+	c := &Client{}
+	tx, err := c.populateTransaction(loaded)
+	assert.NoError(t, err)
+	assert.Equal(t, hash.Hex(), tx.TransactionIdentifier.Hash)
+	assert.Equal(t, "0x16", tx.Metadata["type"])
+	assert.Equal(t, feePayer.Hex(), tx.Metadata["fee_payer"])
+	assert.Equal(t, &RosettaTypes.Operation{
+		OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 0},
+		Type:                FeeOpType,
+		Status:              RosettaTypes.String(SuccessStatus),
+		Account:             &RosettaTypes.AccountIdentifier{Address: feePayer.Hex()},
+		Amount:              &RosettaTypes.Amount{Value: "-2100000", Currency: Currency},
+	}, tx.Operations[0])
+	assert.Equal(t, from.Hex(), tx.Operations[1].Account.Address)
+	assert.Equal(t, "-1", tx.Operations[1].Amount.Value)
+
+	// The hash of a fee-delegated transaction
+	// cannot be computed from its sender transaction.
+	var missingHash rpcTransaction
+	assert.Error(t, json.Unmarshal(rpcTxJSON(map[string]interface{}{"hash": nil}), &missingHash))
+}
+
+func TestSendTransaction(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_sendRawTransaction",
+		"0xf86a80843b9aca00825208941ff502f9fe838cd772874cb67d0d96b93fd1d6d78725d4b6199a415d8029a01d110bf9fd468f7d00b3ce530832e99818835f45e9b08c66f8d9722264bb36c7a02711f47ec99f9ac585840daef41b7118b52ec72f02fcb30d874d36b10b668b59", // nolint
+	).Return(
+		nil,
+	).Once()
+
+	rawTx, err := ioutil.ReadFile("testdata/submitted_tx.json")
+	assert.NoError(t, err)
+
+	tx := new(types.Transaction)
+	assert.NoError(t, tx.UnmarshalJSON(rawTx))
+
+	assert.NoError(t, c.SendTransaction(
+		ctx,
+		tx,
+	))
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestSendRawTransaction(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	ctx := context.Background()
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_sendRawTransaction",
+		"0x16c0",
+	).Return(
+		nil,
+	).Once()
+
+	assert.NoError(t, c.SendRawTransaction(ctx, []byte{0x16, 0xc0}))
+
+	mockJSONRPC.AssertExpectations(t)
+}
+
+func TestGetMempool(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+	ctx := context.Background()
+	expectedMempool := &RosettaTypes.MempoolResponse{
+		TransactionIdentifiers: []*RosettaTypes.TransactionIdentifier{
+			{Hash: "0x994024ef9f05d1cb25d01572642c1f550c78d214a52c306bb100d22c025b59d4"},
+			{Hash: "0x0859cb844087a280fa031ce2a0879f4f9832f431d6de67f57d0ca32e90dd9e21"},
+			{Hash: "0xa6de13e0a4465c9b55726d0826d020ed179fa1bda882a00e90aa467266af1815"},
+			{Hash: "0x94e28f01121fd56dead7b89c46c8d6ba32bb79dad5f7e29c1d523224479f10f4"},
+			{Hash: "0xf98b8a6589fa27f66545f31d443140c16eaa3da3896e66c4f05a03defa12cda4"},
+			{Hash: "0x4c652b9e779277f06e17b6a4c4db6658ac012f7c93f5eeab80e7802cce3ff556"},
+			{Hash: "0xa81f636c4b47831efd324dce5c48fe3a3d7a4f0020fae063887e8c2765ff1088"},
+			{Hash: "0x3bc19098a49974002ba933ac8f9c753ce5af538ab93fc68586849e8b8b0dce80"},
+			{Hash: "0x3401ec802cbe4b02d5be18717b53bb8177bd746f95a54da4674d7c27620facda"},
+			{Hash: "0x3d1c58eced8f15f3224e9b2579420d078dbd2adba8e825cee91fd306b0943f89"},
+			{Hash: "0xda591f0b15423aedb52f6b0e778b1fbc6757547d69277e2ba1aa7093583d1efb"},
+			{Hash: "0xb39652e66c57a6693e44b92b5c471952c26cabf9442a9a76c372f8690658cb4c"},
+			{Hash: "0x1e8700bf7215b2da0cfadcf34717f387577254e0871d828e5453a0593ce8060f"},
+			{Hash: "0x83811383fb7840a03c25a7cbae7e9af138b17853563eb9e212727be2d0b9667f"},
+			{Hash: "0x1e53751e1312cae3324a6b36c67dc95bfec993d7b4939c0de8c0dc761a0afd31"},
+			{Hash: "0xc1052f9378db5a779c42ae2de9a0b94c8a6357c815446d6ba55485dcc1b187ef"},
+		},
+	}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	mockJSONRPC.On(
+		"CallContext", ctx, mock.Anything, "txpool_content",
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r, ok := args.Get(1).(*txPoolContentResponse)
+			assert.True(t, ok)
+
+			file, err := ioutil.ReadFile("testdata/txpool_content.json")
+			assert.NoError(t, err)
+
+			err = json.Unmarshal(file, r)
+			assert.NoError(t, err)
+		},
+	).Once()
+
+	actualMempool, err := c.GetMempool(ctx)
+	assert.NoError(t, err)
+
+	assert.Len(t, actualMempool.TransactionIdentifiers, len(expectedMempool.TransactionIdentifiers))
+
+	// Sort both slices to compare later
+	sort.Slice(expectedMempool.TransactionIdentifiers, func(i, j int) bool {
+		return expectedMempool.TransactionIdentifiers[i].Hash < expectedMempool.TransactionIdentifiers[j].Hash
+	})
+
+	sort.Slice(actualMempool.TransactionIdentifiers, func(i, j int) bool {
+		return actualMempool.TransactionIdentifiers[i].Hash < actualMempool.TransactionIdentifiers[j].Hash
+	})
+
+	assert.True(t, reflect.DeepEqual(actualMempool, expectedMempool))
+
+	mockJSONRPC.AssertExpectations(t)
+}
+
+func TestPopulateTransaction_Erc20Transfers(t *testing.T) {
+	from := common.HexToAddress("0xfFC614eE978630D7fB0C06758DeB580c152154d3")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tokenAddress := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	otherToken := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	token := &RosettaTypes.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			ContractAddressKey: tokenAddress.Hex(),
+		},
+	}
+
+	transferLog := func(address common.Address, from common.Address, to common.Address, amount int64) *types.Log {
+		return &types.Log{
+			Address: address,
+			Topics: []common.Hash{
+				TransferEventTopic,
+				common.BytesToHash(from.Bytes()),
+				common.BytesToHash(to.Bytes()),
+			},
+			Data: common.LeftPadBytes(big.NewInt(amount).Bytes(), 32),
+		}
+	}
+
+	c := &Client{tokens: map[common.Address]*RosettaTypes.Currency{tokenAddress: token}}
+	tx, err := c.populateTransaction(&loadedTransaction{
+		Transaction: types.NewTx(&types.LegacyTx{
+			GasPrice: big.NewInt(100),
+			Gas:      60000,
+			To:       &tokenAddress,
+			Value:    big.NewInt(0),
+		}),
+		From:      &from,
+		FeeAmount: big.NewInt(2100000),
+		Miner:     to.Hex(),
+		Trace: &Call{
+			Type:    "CALL",
+			From:    from,
+			To:      tokenAddress,
+			Value:   big.NewInt(0),
+			GasUsed: big.NewInt(0),
+		},
+		RawTrace: json.RawMessage(`{}`),
+		Receipt: &Receipt{
+			Status: 1,
+			Logs: []*types.Log{
+				transferLog(tokenAddress, from, to, 1000),
+				// Tokens that are not configured are ignored.
+				transferLog(otherToken, from, to, 1000),
+				// Mints only credit the recipient.
+				transferLog(tokenAddress, common.Address{}, to, 5),
+			},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []*RosettaTypes.Operation{
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 1},
+			Type:                Erc20TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: from.Hex()},
+			Amount:              &RosettaTypes.Amount{Value: "-1000", Currency: token},
+		},
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 2},
+			RelatedOperations:   []*RosettaTypes.OperationIdentifier{{Index: 1}},
+			Type:                Erc20TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: to.Hex()},
+			Amount:              &RosettaTypes.Amount{Value: "1000", Currency: token},
+		},
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 3},
+			Type:                Erc20TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: to.Hex()},
+			Amount:              &RosettaTypes.Amount{Value: "5", Currency: token},
+		},
+	}, tx.Operations[1:])
+}
+
+func TestPopulateTransaction_NftTransfers(t *testing.T) {
+	from := common.HexToAddress("0xfFC614eE978630D7fB0C06758DeB580c152154d3")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	collection := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	multiCollection := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	unlisted := common.HexToAddress("0x5BC2D3c2F8F5D4e4A9b0e7E27b2f7fC8Ca0D1a8E")
+	word := func(i int64) []byte {
+		return common.LeftPadBytes(big.NewInt(i).Bytes(), 32)
+	}
+
+	// abi.encode(uint256[] ids, uint256[] values) with ids [1, 2]
+	// and values [4, 0]
+	batchData := bytes.Join([][]byte{
+		word(0x40), word(0xa0),
+		word(2), word(1), word(2),
+		word(2), word(4), word(0),
+	}, nil)
+
+	c := &Client{
+		nfts: map[common.Address]string{
+			collection:      Erc721Standard,
+			multiCollection: Erc1155Standard,
+		},
+	}
+	tx, err := c.populateTransaction(&loadedTransaction{
+		Transaction: types.NewTx(&types.LegacyTx{
+			GasPrice: big.NewInt(100),
+			Gas:      60000,
+			To:       &collection,
+			Value:    big.NewInt(0),
+		}),
+		From:      &from,
+		FeeAmount: big.NewInt(2100000),
+		Miner:     to.Hex(),
+		Trace: &Call{
+			Type:    "CALL",
+			From:    from,
+			To:      collection,
+			Value:   big.NewInt(0),
+			GasUsed: big.NewInt(0),
+		},
+		RawTrace: json.RawMessage(`{}`),
+		Receipt: &Receipt{
+			Status: 1,
+			Logs: []*types.Log{
+				{
+					Address: collection,
+					Topics: []common.Hash{
+						TransferEventTopic,
+						common.BytesToHash(from.Bytes()),
+						common.BytesToHash(to.Bytes()),
+						common.BigToHash(big.NewInt(7)),
+					},
+				},
+				// Transfers of unlisted contracts, and events
+				// of another standard than the contract, are
+				// ignored.
+				{
+					Address: unlisted,
+					Topics: []common.Hash{
+						TransferEventTopic,
+						common.BytesToHash(from.Bytes()),
+						common.BytesToHash(to.Bytes()),
+						common.BigToHash(big.NewInt(7)),
+					},
+				},
+				{
+					Address: collection,
+					Topics: []common.Hash{
+						TransferSingleEventTopic,
+						common.BytesToHash(from.Bytes()),
+						common.BytesToHash(from.Bytes()),
+						common.BytesToHash(to.Bytes()),
+					},
+					Data: append(word(3), word(10)...),
+				},
+				{
+					Address: multiCollection,
+					Topics: []common.Hash{
+						TransferSingleEventTopic,
+						common.BytesToHash(from.Bytes()),
+						common.BytesToHash(from.Bytes()),
+						common.BytesToHash(to.Bytes()),
+					},
+					Data: append(word(3), word(10)...),
+				},
+				// Mints only credit the recipient and transfers
+				// of no amount are skipped.
+				{
+					Address: multiCollection,
+					Topics: []common.Hash{
+						TransferBatchEventTopic,
+						common.BytesToHash(from.Bytes()),
+						{},
+						common.BytesToHash(to.Bytes()),
+					},
+					Data: batchData,
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	erc721 := NftCurrency(collection, big.NewInt(7), Erc721Standard)
+	assert.Equal(t, &RosettaTypes.Currency{
+		Symbol:   "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1:7",
+		Decimals: 0,
+		Metadata: map[string]interface{}{
+			ContractAddressKey: "0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1",
+			TokenIDKey:         "7",
+			StandardKey:        Erc721Standard,
+		},
+	}, erc721)
+
+	erc1155 := NftCurrency(multiCollection, big.NewInt(3), Erc1155Standard)
+	assert.Equal(t, []*RosettaTypes.Operation{
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 1},
+			Type:                Erc721TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: from.Hex()},
+			Amount:              &RosettaTypes.Amount{Value: "-1", Currency: erc721},
+		},
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 2},
+			RelatedOperations:   []*RosettaTypes.OperationIdentifier{{Index: 1}},
+			Type:                Erc721TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: to.Hex()},
+			Amount:              &RosettaTypes.Amount{Value: "1", Currency: erc721},
+		},
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 3},
+			Type:                Erc1155TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: from.Hex()},
+			Amount:              &RosettaTypes.Amount{Value: "-10", Currency: erc1155},
+		},
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 4},
+			RelatedOperations:   []*RosettaTypes.OperationIdentifier{{Index: 3}},
+			Type:                Erc1155TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: to.Hex()},
+			Amount:              &RosettaTypes.Amount{Value: "10", Currency: erc1155},
+		},
+		{
+			OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 5},
+			Type:                Erc1155TransferOpType,
+			Status:              RosettaTypes.String(SuccessStatus),
+			Account:             &RosettaTypes.AccountIdentifier{Address: to.Hex()},
+			Amount: &RosettaTypes.Amount{
+				Value:    "4",
+				Currency: NftCurrency(multiCollection, big.NewInt(1), Erc1155Standard),
+			},
+		},
+	}, tx.Operations[1:])
+}
+
+func TestMempoolTransaction(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	key, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	internal := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	signer := types.NewLondonSigner(big.NewInt(1112))
+	tx, err := types.SignNewTx(key, signer, &types.LegacyTx{
+		Nonce:    3,
+		GasPrice: big.NewInt(100000000000),
+		Gas:      50000,
+		To:       &to,
+		Value:    big.NewInt(1000),
+	})
+	assert.NoError(t, err)
+
+	txJSON, err := tx.MarshalJSON()
+	assert.NoError(t, err)
+	var rpcTx map[string]interface{}
+	assert.NoError(t, json.Unmarshal(txJSON, &rpcTx))
+	rpcTx["from"] = from.Hex()
+	rpcTx["blockNumber"] = nil
+	rawTx, err := json.Marshal(rpcTx)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_getTransactionByHash",
+		tx.Hash(),
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*json.RawMessage)
+
+			*r = rawTx
+		},
+	).Twice()
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"debug_traceCall",
+		mock.Anything,
+		"pending",
+		mock.Anything,
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(**Call)
+
+			*r = &Call{
+				Type:    "CALL",
+				From:    from,
+				To:      to,
+				Value:   big.NewInt(1000),
+				GasUsed: big.NewInt(0),
+				Calls: []*Call{
+					{
+						Type:    "CALL",
+						From:    to,
+						To:      internal,
+						Value:   big.NewInt(400),
+						GasUsed: big.NewInt(0),
+					},
+				},
+			}
+		},
+	).Once()
+
+	fee := &RosettaTypes.Operation{
+		OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 0},
+		Type:                FeeOpType,
+		Status:              RosettaTypes.String(SuccessStatus),
+		Account:             &RosettaTypes.AccountIdentifier{Address: from.Hex()},
+		Amount:              &RosettaTypes.Amount{Value: "-5000000000000000", Currency: Currency},
+	}
+
+	mempoolTx, err := c.MempoolTransaction(ctx, tx.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, tx.Hash().Hex(), mempoolTx.TransactionIdentifier.Hash)
+	assert.Equal(t, true, mempoolTx.Metadata["traced"])
+	assert.Equal(t, "0x3", mempoolTx.Metadata["nonce"])
+	assert.Len(t, mempoolTx.Operations, 5)
+	assert.Equal(t, fee, mempoolTx.Operations[0])
+	for i, expected := range []struct {
+		account string
+		value   string
+	}{
+		{from.Hex(), "-1000"},
+		{to.Hex(), "1000"},
+		{to.Hex(), "-400"},
+		{internal.Hex(), "400"},
+	} {
+		op := mempoolTx.Operations[i+1]
+		assert.Equal(t, CallOpType, op.Type)
+		assert.Equal(t, expected.account, op.Account.Address)
+		assert.Equal(t, expected.value, op.Amount.Value)
+	}
+
+	// Without a trace, only the value transfer
+	// of the transaction is predicted.
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"debug_traceCall",
+		mock.Anything,
+		"pending",
+		mock.Anything,
+	).Return(
+		errors.New("the method debug_traceCall does not exist/is not available"),
+	).Once()
+
+	mempoolTx, err = c.MempoolTransaction(ctx, tx.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, false, mempoolTx.Metadata["traced"])
+	assert.Len(t, mempoolTx.Operations, 3)
+	assert.Equal(t, fee, mempoolTx.Operations[0])
+	assert.Equal(t, from.Hex(), mempoolTx.Operations[1].Account.Address)
+	assert.Equal(t, "-1000", mempoolTx.Operations[1].Amount.Value)
+	assert.Equal(t, to.Hex(), mempoolTx.Operations[2].Account.Address)
+	assert.Equal(t, "1000", mempoolTx.Operations[2].Amount.Value)
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestMempoolEntries(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+	ctx := context.Background()
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	file, err := ioutil.ReadFile("testdata/txpool_content.json")
+	assert.NoError(t, err)
+
+	mockJSONRPC.On(
+		"CallContext", ctx, mock.Anything, "txpool_content",
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r, ok := args.Get(1).(*txPoolContentResponse)
+			assert.True(t, ok)
+			assert.NoError(t, json.Unmarshal(file, r))
+		},
+	).Once()
+
+	entries, err := c.MempoolEntries(ctx, nil)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 16)
+	assert.Equal(t, &MempoolEntry{
+		Hash:     common.HexToHash("0x994024ef9f05d1cb25d01572642c1f550c78d214a52c306bb100d22c025b59d4"),
+		Pool:     PendingPool,
+		Sender:   common.HexToAddress("0x0297215e64d312d3A239995345E574F73Ef59B02"),
+		Nonce:    3,
+		GasPrice: big.NewInt(40000000000),
+	}, entries[0])
+	assert.Equal(t, PendingPool, entries[9].Pool)
+	assert.Equal(t, QueuedPool, entries[10].Pool)
+	assert.Equal(t, uint64(234), entries[10].Nonce)
+	assert.Equal(t, uint64(742), entries[15].Nonce)
+
+	// Only the transactions of account are
+	// fetched with txpool_contentFrom.
+	account := common.HexToAddress("0x4DE23f3f0Fb3318287378AdbdE030cf61714b2f3")
+	var content map[string]map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(file, &content))
+	contentFrom := fmt.Sprintf(`{"pending":{},"queued":%s}`, content[QueuedPool][account.Hex()])
+	mockJSONRPC.On(
+		"CallContext", ctx, mock.Anything, "txpool_contentFrom", account,
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r, ok := args.Get(1).(*txPoolContentFromResponse)
+			assert.True(t, ok)
+			assert.NoError(t, json.Unmarshal([]byte(contentFrom), r))
+		},
+	).Once()
+
+	entries, err = c.MempoolEntries(ctx, &account)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+	for i, entry := range entries {
+		assert.Equal(t, QueuedPool, entry.Pool)
+		assert.Equal(t, account, entry.Sender)
+		assert.Equal(t, uint64(234+i), entry.Nonce)
+	}
+
+	mockJSONRPC.On(
+		"CallContext", ctx, mock.Anything, "txpool_status",
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r, ok := args.Get(1).(*map[string]hexutil.Uint64)
+			assert.True(t, ok)
+			assert.NoError(t, json.Unmarshal([]byte(`{"pending":"0xa","queued":"0x6"}`), r))
+		},
+	).Once()
+
+	status, err := c.GetTxPoolStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, &TxPoolStatus{Pending: 10, Queued: 6}, status)
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
+}
+
+func TestMempoolTransaction_FeeDelegated(t *testing.T) {
+	mockJSONRPC := &mocks.JSONRPC{}
+	mockGraphQL := &mocks.GraphQL{}
+
+	c := &Client{
+		c:              mockJSONRPC,
+		g:              mockGraphQL,
+		traceSemaphore: semaphore.NewWeighted(100),
+	}
+
+	key, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	feePayer := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	hash := common.HexToHash("0x5f7ec1f0a5b3e6c0f49f3c1d2e0b44c7d28c1a0fbd9e2b8d4f7a36c0b1e5d9a2")
+	senderTx, err := types.SignNewTx(key, types.NewLondonSigner(big.NewInt(1112)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1112),
+		Nonce:     3,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1000),
+	})
+	assert.NoError(t, err)
+
+	txJSON, err := senderTx.MarshalJSON()
+	assert.NoError(t, err)
+	var rpcTx map[string]interface{}
+	assert.NoError(t, json.Unmarshal(txJSON, &rpcTx))
+	rpcTx["type"] = "0x16"
+	rpcTx["from"] = from.Hex()
+	rpcTx["hash"] = hash.Hex()
+	rpcTx["blockNumber"] = nil
+	rpcTx["feePayer"] = feePayer.Hex()
+	rpcTx["fv"] = "0x1"
+	rpcTx["fr"] = "0x2"
+	rpcTx["fs"] = "0x3"
+	rawTx, err := json.Marshal(rpcTx)
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"eth_getTransactionByHash",
+		hash,
+	).Return(
+		nil,
+	).Run(
+		func(args mock.Arguments) {
+			r := args.Get(1).(*json.RawMessage)
+
+			*r = rawTx
+		},
+	).Once()
+	mockJSONRPC.On(
+		"CallContext",
+		ctx,
+		mock.Anything,
+		"debug_traceCall",
+		mock.Anything,
+		"pending",
+		mock.Anything,
+	).Return(
+		errors.New("the method debug_traceCall does not exist/is not available"),
+	).Once()
+
+	// The fee of fee-delegated transactions is
+	// predicted to be debited from their fee payer.
+	mempoolTx, err := c.MempoolTransaction(ctx, hash)
+	assert.NoError(t, err)
+	assert.Equal(t, hash.Hex(), mempoolTx.TransactionIdentifier.Hash)
+	assert.Equal(t, "0x16", mempoolTx.Metadata["type"])
+	assert.Equal(t, feePayer.Hex(), mempoolTx.Metadata["fee_payer"])
+	assert.Len(t, mempoolTx.Operations, 3)
+	assert.Equal(t, &RosettaTypes.Operation{
+		OperationIdentifier: &RosettaTypes.OperationIdentifier{Index: 0},
+		Type:                FeeOpType,
+		Status:              RosettaTypes.String(SuccessStatus),
+		Account:             &RosettaTypes.AccountIdentifier{Address: feePayer.Hex()},
+		Amount:              &RosettaTypes.Amount{Value: "-2100000", Currency: Currency},
+	}, mempoolTx.Operations[0])
+	assert.Equal(t, from.Hex(), mempoolTx.Operations[1].Account.Address)
+	assert.Equal(t, "-1000", mempoolTx.Operations[1].Amount.Value)
+
+	mockJSONRPC.AssertExpectations(t)
+	mockGraphQL.AssertExpectations(t)
 }
@@ -0,0 +1,199 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ContractCallData returns the calldata of a call to the method
+// described by signature (e.g. "transfer(address,uint256)") with
+// args provided as strings.
+func ContractCallData(signature string, args []string) ([]byte, error) {
+	name, arguments, err := parseMethodSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(arguments) != len(args) {
+		return nil, fmt.Errorf(
+			"%s expects %d arguments but got %d",
+			signature,
+			len(arguments),
+			len(args),
+		)
+	}
+
+	types := make([]string, len(arguments))
+	values := make([]interface{}, len(arguments))
+	for i, argument := range arguments {
+		value, err := parseArg(argument.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to parse argument %d of %s", err, i, signature)
+		}
+
+		types[i] = argument.Type.String()
+		values[i] = value
+	}
+
+	packed, err := arguments.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	methodID := MethodID(fmt.Sprintf("%s(%s)", name, strings.Join(types, ",")))
+	return append(methodID, packed...), nil
+}
+
+// ConstructorCallData returns contract creation code followed
+// by the ABI-encoded constructor args. Constructor argument
+// types are given as a list (e.g. ["address","uint256"]).
+func ConstructorCallData(bytecode []byte, argTypes []string, args []string) ([]byte, error) {
+	_, arguments, err := parseMethodSignature(fmt.Sprintf("constructor(%s)", strings.Join(argTypes, ",")))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(arguments) != len(args) {
+		return nil, fmt.Errorf(
+			"constructor expects %d arguments but got %d",
+			len(arguments),
+			len(args),
+		)
+	}
+
+	values := make([]interface{}, len(arguments))
+	for i, argument := range arguments {
+		value, err := parseArg(argument.Type, args[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to parse constructor argument %d", err, i)
+		}
+
+		values[i] = value
+	}
+
+	packed, err := arguments.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, len(bytecode)+len(packed))
+	data = append(data, bytecode...)
+	return append(data, packed...), nil
+}
+
+// parseMethodSignature splits a method signature into its
+// name and argument types. Tuple arguments are not supported.
+func parseMethodSignature(signature string) (string, abi.Arguments, error) {
+	signature = strings.ReplaceAll(signature, " ", "")
+	open := strings.Index(signature, "(")
+	if open <= 0 || !strings.HasSuffix(signature, ")") {
+		return "", nil, fmt.Errorf("%s is not a valid method signature", signature)
+	}
+
+	name := signature[:open]
+	inner := signature[open+1 : len(signature)-1]
+	if strings.ContainsAny(inner, "()") {
+		return "", nil, fmt.Errorf("%s has unsupported tuple arguments", signature)
+	}
+
+	arguments := abi.Arguments{}
+	if len(inner) == 0 {
+		return name, arguments, nil
+	}
+
+	for _, t := range strings.Split(inner, ",") {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s is not a valid type", err, t)
+		}
+
+		arguments = append(arguments, abi.Argument{Type: typ})
+	}
+
+	return name, arguments, nil
+}
+
+// intInRange returns whether value fits in the integer
+// type typ: uintN holds 0 to 2^N-1 and intN holds
+// -2^(N-1) to 2^(N-1)-1.
+func intInRange(typ abi.Type, value *big.Int) bool {
+	if typ.T == abi.UintTy {
+		return value.Sign() >= 0 && value.BitLen() <= typ.Size
+	}
+
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(typ.Size-1))
+	return value.Cmp(new(big.Int).Neg(limit)) >= 0 && value.Cmp(limit) < 0
+}
+
+// parseArg converts a string argument into the
+// value abi.Arguments.Pack expects for typ.
+func parseArg(typ abi.Type, arg string) (interface{}, error) {
+	switch typ.T {
+	case abi.AddressTy:
+		if !common.IsHexAddress(arg) {
+			return nil, fmt.Errorf("%s is not a valid address", arg)
+		}
+		return common.HexToAddress(arg), nil
+	case abi.IntTy, abi.UintTy:
+		value, ok := new(big.Int).SetString(arg, 0)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a valid integer", arg)
+		}
+
+		if !intInRange(typ, value) {
+			return nil, fmt.Errorf("%s overflows %s", arg, typ.String())
+		}
+
+		// Integers of up to 64 bits are packed from
+		// native Go integer types.
+		goType := typ.GetType()
+		switch goType.Kind() { // nolint:exhaustive
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return reflect.ValueOf(value.Uint64()).Convert(goType).Interface(), nil
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return reflect.ValueOf(value.Int64()).Convert(goType).Interface(), nil
+		}
+		return value, nil
+	case abi.BoolTy:
+		return strconv.ParseBool(arg)
+	case abi.StringTy:
+		return arg, nil
+	case abi.BytesTy:
+		return hexutil.Decode(arg)
+	case abi.FixedBytesTy:
+		b, err := hexutil.Decode(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != typ.Size {
+			return nil, fmt.Errorf("%s is not %d bytes", arg, typ.Size)
+		}
+
+		value := reflect.New(typ.GetType()).Elem()
+		reflect.Copy(value, reflect.ValueOf(b))
+		return value.Interface(), nil
+	}
+
+	return nil, fmt.Errorf("%s arguments are not supported", typ.String())
+}
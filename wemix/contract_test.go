@@ -0,0 +1,174 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContractCallData(t *testing.T) {
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+
+	var tests = map[string]struct {
+		signature string
+		args      []string
+
+		expected string
+		err      bool
+	}{
+		"transfer": {
+			signature: "transfer(address,uint256)",
+			args:      []string{to.Hex(), "1000"},
+			expected:  hexutil.Encode(Erc20TransferData(to, big.NewInt(1000))),
+		},
+		"no arguments": {
+			signature: "pause()",
+			expected:  "0x8456cb59",
+		},
+		"small integers": {
+			signature: "set(uint8,int64)",
+			args:      []string{"255", "-1"},
+			expected: hexutil.Encode(MethodID("set(uint8,int64)")) +
+				"00000000000000000000000000000000000000000000000000000000000000ff" +
+				"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+		},
+		"wrong number of arguments": {
+			signature: "transfer(address,uint256)",
+			args:      []string{to.Hex()},
+			err:       true,
+		},
+		"overflow": {
+			signature: "set(uint8)",
+			args:      []string{"256"},
+			err:       true,
+		},
+		"invalid address": {
+			signature: "transfer(address,uint256)",
+			args:      []string{"hello", "1"},
+			err:       true,
+		},
+		"tuple": {
+			signature: "swap((address,uint256))",
+			args:      []string{"x"},
+			err:       true,
+		},
+		"invalid signature": {
+			signature: "transfer",
+			err:       true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			data, err := ContractCallData(test.signature, test.args)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, hexutil.Encode(data))
+		})
+	}
+}
+
+func TestContractCallDataIntegerRange(t *testing.T) {
+	var tests = map[string]struct {
+		typ string
+		arg string
+
+		err bool
+	}{
+		"uint8 max":        {typ: "uint8", arg: "255"},
+		"uint8 overflow":   {typ: "uint8", arg: "256", err: true},
+		"uint8 negative":   {typ: "uint8", arg: "-1", err: true},
+		"uint64 max":       {typ: "uint64", arg: "18446744073709551615"},
+		"uint64 overflow":  {typ: "uint64", arg: "18446744073709551616", err: true},
+		"uint128 max":      {typ: "uint128", arg: "0xffffffffffffffffffffffffffffffff"},
+		"uint128 overflow": {typ: "uint128", arg: "0x100000000000000000000000000000000", err: true},
+		"uint256 zero":     {typ: "uint256", arg: "0"},
+		"uint256 max":      {typ: "uint256", arg: "0x" + strings.Repeat("ff", 32)},
+		"uint256 overflow": {typ: "uint256", arg: "0x1" + strings.Repeat("00", 32), err: true},
+		"uint256 negative": {typ: "uint256", arg: "-1", err: true},
+		"int8 min":         {typ: "int8", arg: "-128"},
+		"int8 max":         {typ: "int8", arg: "127"},
+		"int8 underflow":   {typ: "int8", arg: "-129", err: true},
+		"int8 overflow":    {typ: "int8", arg: "128", err: true},
+		"int64 min":        {typ: "int64", arg: "-9223372036854775808"},
+		"int64 max":        {typ: "int64", arg: "9223372036854775807"},
+		"int64 underflow":  {typ: "int64", arg: "-9223372036854775809", err: true},
+		"int64 overflow":   {typ: "int64", arg: "9223372036854775808", err: true},
+		"int128 min":       {typ: "int128", arg: "-0x80000000000000000000000000000000"},
+		"int128 max":       {typ: "int128", arg: "0x7fffffffffffffffffffffffffffffff"},
+		"int128 underflow": {typ: "int128", arg: "-0x80000000000000000000000000000001", err: true},
+		"int128 overflow":  {typ: "int128", arg: "0x80000000000000000000000000000000", err: true},
+		"int256 min":       {typ: "int256", arg: "-0x8" + strings.Repeat("0", 63)},
+		"int256 max":       {typ: "int256", arg: "0x7" + strings.Repeat("f", 63)},
+		"int256 underflow": {typ: "int256", arg: "-0x8" + strings.Repeat("0", 62) + "1", err: true},
+		"int256 overflow":  {typ: "int256", arg: "0x8" + strings.Repeat("0", 63), err: true},
+		"int256 minus one": {typ: "int256", arg: "-1"},
+		"not an integer":   {typ: "uint256", arg: "one", err: true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			signature := "f(" + test.typ + ")"
+			data, err := ContractCallData(signature, []string{test.arg})
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+
+			// The encoded word is the two's complement
+			// of the argument.
+			value, ok := new(big.Int).SetString(test.arg, 0)
+			assert.True(t, ok)
+			assert.Equal(t, math.U256Bytes(value), data[4:])
+		})
+	}
+}
+
+func TestConstructorCallData(t *testing.T) {
+	bytecode := []byte{0x60, 0x80, 0x60, 0x40, 0x52}
+
+	data, err := ConstructorCallData(bytecode, []string{"address", "bool"}, []string{
+		"0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d",
+		"true",
+	})
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		"0x6080604052"+
+			"00000000000000000000000057b414a0332b5cab885a451c2a28a07d1e9b8a8d"+
+			"0000000000000000000000000000000000000000000000000000000000000001",
+		hexutil.Encode(data),
+	)
+
+	data, err = ConstructorCallData(bytecode, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, bytecode, data)
+
+	_, err = ConstructorCallData(bytecode, []string{"uint256"}, nil)
+	assert.Error(t, err)
+}
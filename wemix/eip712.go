@@ -0,0 +1,59 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// PermitTypeHash is the EIP-712 type hash of
+	// an EIP-2612 permit.
+	PermitTypeHash = crypto.Keccak256Hash([]byte(
+		"Permit(address owner,address spender,uint256 value,uint256 nonce,uint256 deadline)",
+	))
+)
+
+// PermitStructHash returns the EIP-712 struct hash of an
+// EIP-2612 permit.
+func PermitStructHash(
+	owner common.Address,
+	spender common.Address,
+	value *big.Int,
+	nonce *big.Int,
+	deadline *big.Int,
+) common.Hash {
+	return crypto.Keccak256Hash(
+		PermitTypeHash.Bytes(),
+		common.LeftPadBytes(owner.Bytes(), 32),    // nolint:gomnd
+		common.LeftPadBytes(spender.Bytes(), 32),  // nolint:gomnd
+		common.LeftPadBytes(value.Bytes(), 32),    // nolint:gomnd
+		common.LeftPadBytes(nonce.Bytes(), 32),    // nolint:gomnd
+		common.LeftPadBytes(deadline.Bytes(), 32), // nolint:gomnd
+	)
+}
+
+// TypedDataHash returns the EIP-712 digest that is signed
+// for the struct hash in the domain.
+func TypedDataHash(domainSeparator common.Hash, structHash common.Hash) common.Hash {
+	return crypto.Keccak256Hash(
+		[]byte{0x19, 0x01}, // nolint:gomnd
+		domainSeparator.Bytes(),
+		structHash.Bytes(),
+	)
+}
@@ -0,0 +1,85 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPermitTypedDataHash(t *testing.T) {
+	token := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	owner := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	spender := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	chainID := big.NewInt(1112)
+	value := big.NewInt(1000)
+	nonce := big.NewInt(3)
+	deadline := big.NewInt(1700000000)
+
+	// The digest must match the one signed by wallets
+	// implementing eth_signTypedData_v4.
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"Permit": {
+				{Name: "owner", Type: "address"},
+				{Name: "spender", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "nonce", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "Permit",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "Test Token",
+			Version:           "1",
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: token.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"owner":    owner.Hex(),
+			"spender":  spender.Hex(),
+			"value":    value.String(),
+			"nonce":    nonce.String(),
+			"deadline": deadline.String(),
+		},
+	}
+
+	expectedDomain, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	assert.NoError(t, err)
+	expectedStruct, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	assert.NoError(t, err)
+
+	// Tokens expose their domain separator through
+	// DOMAIN_SEPARATOR(), so it is not computed here.
+	domainSeparator := common.BytesToHash(expectedDomain)
+	structHash := PermitStructHash(owner, spender, value, nonce, deadline)
+	assert.Equal(t, []byte(expectedStruct), structHash.Bytes())
+
+	rawData := append([]byte{0x19, 0x01}, expectedDomain...)
+	rawData = append(rawData, expectedStruct...)
+	assert.Equal(t, common.BytesToHash(crypto.Keccak256(rawData)), TypedDataHash(domainSeparator, structHash))
+}
@@ -0,0 +1,240 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"bytes"
+	"math/big"
+
+	RosettaTypes "github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// ContractAddressKey is the key in *types.Currency metadata
+	// that holds the address of an ERC-20 token contract.
+	ContractAddressKey = "contractAddress"
+
+	// SpenderKey is the key in *types.Operation metadata
+	// that holds the spender of an ERC-20 allowance.
+	SpenderKey = "spender"
+
+	// DeadlineKey is the key in *types.Operation metadata
+	// that holds the deadline of an EIP-2612 permit.
+	DeadlineKey = "deadline"
+
+	// TransferFnSignature is the signature of the ERC-20
+	// transfer method.
+	TransferFnSignature = "transfer(address,uint256)"
+
+	// ApproveFnSignature is the signature of the ERC-20
+	// approve method.
+	ApproveFnSignature = "approve(address,uint256)"
+
+	// TransferFromFnSignature is the signature of the
+	// ERC-20 transferFrom method.
+	TransferFromFnSignature = "transferFrom(address,address,uint256)"
+
+	// PermitFnSignature is the signature of the EIP-2612
+	// permit method.
+	PermitFnSignature = "permit(address,address,uint256,uint256,uint8,bytes32,bytes32)"
+
+	// NoncesFnSignature is the signature of the EIP-2612
+	// nonces method.
+	NoncesFnSignature = "nonces(address)"
+
+	// DomainSeparatorFnSignature is the signature of the
+	// EIP-2612 DOMAIN_SEPARATOR method.
+	DomainSeparatorFnSignature = "DOMAIN_SEPARATOR()"
+
+	// BalanceOfFnSignature is the signature of the ERC-20
+	// balanceOf method.
+	BalanceOfFnSignature = "balanceOf(address)"
+
+	// TransferEventSignature is the signature of the
+	// ERC-20 Transfer event.
+	TransferEventSignature = "Transfer(address,address,uint256)"
+
+	// erc20TransferDataLength is the length of ABI-encoded
+	// transfer and approve calldata (selector + address +
+	// amount).
+	erc20TransferDataLength = 68
+
+	// erc20TransferFromDataLength is the length of
+	// ABI-encoded transferFrom calldata (selector +
+	// 2 addresses + amount).
+	erc20TransferFromDataLength = 100
+)
+
+var (
+	// TransferFnSelector is the 4-byte method id of the
+	// ERC-20 transfer method.
+	TransferFnSelector = MethodID(TransferFnSignature)
+
+	// ApproveFnSelector is the 4-byte method id of the
+	// ERC-20 approve method.
+	ApproveFnSelector = MethodID(ApproveFnSignature)
+
+	// TransferFromFnSelector is the 4-byte method id of
+	// the ERC-20 transferFrom method.
+	TransferFromFnSelector = MethodID(TransferFromFnSignature)
+
+	// PermitFnSelector is the 4-byte method id of the
+	// EIP-2612 permit method.
+	PermitFnSelector = MethodID(PermitFnSignature)
+
+	// NoncesFnSelector is the 4-byte method id of the
+	// EIP-2612 nonces method.
+	NoncesFnSelector = MethodID(NoncesFnSignature)
+
+	// DomainSeparatorFnSelector is the 4-byte method id
+	// of the EIP-2612 DOMAIN_SEPARATOR method.
+	DomainSeparatorFnSelector = MethodID(DomainSeparatorFnSignature)
+
+	// BalanceOfFnSelector is the 4-byte method id of the
+	// ERC-20 balanceOf method.
+	BalanceOfFnSelector = MethodID(BalanceOfFnSignature)
+
+	// TransferEventTopic is the topic of the
+	// ERC-20 Transfer event.
+	TransferEventTopic = crypto.Keccak256Hash([]byte(TransferEventSignature))
+)
+
+// MethodID returns the 4-byte method id of a
+// Solidity method signature.
+func MethodID(signature string) []byte {
+	return crypto.Keccak256([]byte(signature))[:4]
+}
+
+// TokenAddress returns the checksummed contract address stored
+// in the metadata of a token *types.Currency. If the currency
+// has no valid contract address, it returns !ok.
+func TokenAddress(currency *RosettaTypes.Currency) (string, bool) {
+	if currency == nil || currency.Metadata == nil {
+		return "", false
+	}
+
+	address, ok := currency.Metadata[ContractAddressKey].(string)
+	if !ok {
+		return "", false
+	}
+
+	return ChecksumAddress(address)
+}
+
+// Erc20TransferData returns the ABI-encoded calldata of
+// transfer(to, amount).
+func Erc20TransferData(to common.Address, amount *big.Int) []byte {
+	return callData(TransferFnSelector, to.Bytes(), amount.Bytes())
+}
+
+// ParseErc20TransferData decodes calldata produced by
+// Erc20TransferData. If the calldata is not an ERC-20
+// transfer, it returns !ok.
+func ParseErc20TransferData(data []byte) (common.Address, *big.Int, bool) {
+	if len(data) != erc20TransferDataLength || !bytes.Equal(data[:4], TransferFnSelector) {
+		return common.Address{}, nil, false
+	}
+
+	to := common.BytesToAddress(data[4:36])
+	amount := new(big.Int).SetBytes(data[36:68])
+	return to, amount, true
+}
+
+// Erc20ApproveData returns the ABI-encoded calldata of
+// approve(spender, amount).
+func Erc20ApproveData(spender common.Address, amount *big.Int) []byte {
+	return callData(ApproveFnSelector, spender.Bytes(), amount.Bytes())
+}
+
+// ParseErc20ApproveData decodes calldata produced by
+// Erc20ApproveData. If the calldata is not an ERC-20
+// approve, it returns !ok.
+func ParseErc20ApproveData(data []byte) (common.Address, *big.Int, bool) {
+	if len(data) != erc20TransferDataLength || !bytes.Equal(data[:4], ApproveFnSelector) {
+		return common.Address{}, nil, false
+	}
+
+	spender := common.BytesToAddress(data[4:36])
+	amount := new(big.Int).SetBytes(data[36:68])
+	return spender, amount, true
+}
+
+// Erc20TransferFromData returns the ABI-encoded calldata
+// of transferFrom(from, to, amount).
+func Erc20TransferFromData(from common.Address, to common.Address, amount *big.Int) []byte {
+	return callData(TransferFromFnSelector, from.Bytes(), to.Bytes(), amount.Bytes())
+}
+
+// ParseErc20TransferFromData decodes calldata produced by
+// Erc20TransferFromData. If the calldata is not an ERC-20
+// transferFrom, it returns !ok.
+func ParseErc20TransferFromData(data []byte) (common.Address, common.Address, *big.Int, bool) {
+	if len(data) != erc20TransferFromDataLength || !bytes.Equal(data[:4], TransferFromFnSelector) {
+		return common.Address{}, common.Address{}, nil, false
+	}
+
+	from := common.BytesToAddress(data[4:36])
+	to := common.BytesToAddress(data[36:68])
+	amount := new(big.Int).SetBytes(data[68:100])
+	return from, to, amount, true
+}
+
+// Erc20PermitData returns the ABI-encoded calldata of
+// permit(owner, spender, value, deadline, v, r, s) for a
+// 65-byte [R || S || V] signature with V as 0 or 1.
+func Erc20PermitData(
+	owner common.Address,
+	spender common.Address,
+	value *big.Int,
+	deadline *big.Int,
+	signature []byte,
+) []byte {
+	v := []byte{signature[64] + 27} // nolint:gomnd
+	return callData(
+		PermitFnSelector,
+		owner.Bytes(),
+		spender.Bytes(),
+		value.Bytes(),
+		deadline.Bytes(),
+		v,
+		signature[:32],
+		signature[32:64],
+	)
+}
+
+// Erc20NoncesData returns the ABI-encoded calldata
+// of nonces(owner).
+func Erc20NoncesData(owner common.Address) []byte {
+	return callData(NoncesFnSelector, owner.Bytes())
+}
+
+// Erc20BalanceOfData returns the ABI-encoded calldata
+// of balanceOf(owner).
+func Erc20BalanceOfData(owner common.Address) []byte {
+	return callData(BalanceOfFnSelector, owner.Bytes())
+}
+
+// callData returns the calldata of the method with
+// selector, left-padding each static argument.
+func callData(selector []byte, args ...[]byte) []byte {
+	data := append([]byte{}, selector...)
+	for _, arg := range args {
+		data = append(data, common.LeftPadBytes(arg, 32)...) // nolint:gomnd
+	}
+
+	return data
+}
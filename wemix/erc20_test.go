@@ -0,0 +1,78 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErc20AllowanceData(t *testing.T) {
+	owner := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	spender := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	amount := big.NewInt(1000)
+
+	approve := Erc20ApproveData(spender, amount)
+	expected, err := ContractCallData(ApproveFnSignature, []string{spender.Hex(), "1000"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, approve)
+
+	parsedSpender, parsedAmount, ok := ParseErc20ApproveData(approve)
+	assert.True(t, ok)
+	assert.Equal(t, spender, parsedSpender)
+	assert.Equal(t, amount, parsedAmount)
+
+	_, _, ok = ParseErc20TransferData(approve)
+	assert.False(t, ok)
+
+	transferFrom := Erc20TransferFromData(owner, spender, amount)
+	expected, err = ContractCallData(TransferFromFnSignature, []string{owner.Hex(), spender.Hex(), "1000"})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, transferFrom)
+
+	parsedOwner, parsedTo, parsedAmount, ok := ParseErc20TransferFromData(transferFrom)
+	assert.True(t, ok)
+	assert.Equal(t, owner, parsedOwner)
+	assert.Equal(t, spender, parsedTo)
+	assert.Equal(t, amount, parsedAmount)
+
+	_, _, _, ok = ParseErc20TransferFromData(approve)
+	assert.False(t, ok)
+}
+
+func TestErc20PermitData(t *testing.T) {
+	owner := common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b")
+	spender := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	signature := append(bytes.Repeat([]byte{0x11}, 32), bytes.Repeat([]byte{0x22}, 32)...)
+	signature = append(signature, 1)
+
+	data := Erc20PermitData(owner, spender, big.NewInt(1000), big.NewInt(1700000000), signature)
+	expected, err := ContractCallData(PermitFnSignature, []string{
+		owner.Hex(),
+		spender.Hex(),
+		"1000",
+		"1700000000",
+		"28",
+		hexutil.Encode(signature[:32]),
+		hexutil.Encode(signature[32:64]),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, data)
+}
@@ -14,7 +14,10 @@
 
 package wemix
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Client errors
 var (
@@ -22,4 +25,20 @@ var (
 	ErrCallParametersInvalid = errors.New("call parameters invalid")
 	ErrCallOutputMarshal     = errors.New("call output marshal")
 	ErrCallMethodInvalid     = errors.New("call method invalid")
+	ErrTransactionNotPending = errors.New("transaction not pending")
+	ErrExecutionReverted     = errors.New("execution reverted")
 )
+
+// RevertError is returned when a simulated call reverts.
+// It wraps ErrExecutionReverted.
+type RevertError struct {
+	Reason string
+}
+
+func (e *RevertError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrExecutionReverted, e.Reason)
+}
+
+func (e *RevertError) Unwrap() error {
+	return ErrExecutionReverted
+}
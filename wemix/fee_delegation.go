@@ -0,0 +1,173 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// FeeDelegateDynamicFeeTxType is the envelope type of WEMIX
+// fee-delegated transactions: a dynamic-fee transaction signed
+// by its sender and countersigned by a fee payer, who pays
+// its fee.
+const FeeDelegateDynamicFeeTxType = 0x16
+
+// FeeDelegatedTx is a decoded fee-delegated transaction.
+type FeeDelegatedTx struct {
+	// SenderTx is the signed dynamic-fee
+	// transaction of the sender.
+	SenderTx *types.Transaction
+
+	FeePayer common.Address
+
+	// FV, FR and FS are the signature values
+	// of the fee payer.
+	FV *big.Int
+	FR *big.Int
+	FS *big.Int
+}
+
+// feeDelegatedTxRLP is the RLP payload of a
+// fee-delegated transaction envelope.
+type feeDelegatedTxRLP struct {
+	SenderTx types.DynamicFeeTx
+	FeePayer *common.Address `rlp:"nil"`
+	FV       *big.Int
+	FR       *big.Int
+	FS       *big.Int
+}
+
+// DecodeFeeDelegatedTx decodes the raw bytes of a
+// fee-delegated transaction envelope.
+func DecodeFeeDelegatedTx(b []byte) (*FeeDelegatedTx, error) {
+	if len(b) == 0 || b[0] != FeeDelegateDynamicFeeTxType {
+		return nil, errors.New("not a fee-delegated transaction")
+	}
+
+	var payload feeDelegatedTxRLP
+	if err := rlp.DecodeBytes(b[1:], &payload); err != nil {
+		return nil, fmt.Errorf("%w: unable to decode fee-delegated transaction", err)
+	}
+
+	if payload.FeePayer == nil {
+		return nil, errors.New("fee-delegated transaction has no fee payer")
+	}
+
+	return &FeeDelegatedTx{
+		SenderTx: types.NewTx(&payload.SenderTx),
+		FeePayer: *payload.FeePayer,
+		FV:       payload.FV,
+		FR:       payload.FR,
+		FS:       payload.FS,
+	}, nil
+}
+
+// senderTx returns the dynamic-fee transaction of the
+// sender, including its signature values.
+func (tx *FeeDelegatedTx) senderTx() *types.DynamicFeeTx {
+	v, r, s := tx.SenderTx.RawSignatureValues()
+	return &types.DynamicFeeTx{
+		ChainID:    tx.SenderTx.ChainId(),
+		Nonce:      tx.SenderTx.Nonce(),
+		GasTipCap:  tx.SenderTx.GasTipCap(),
+		GasFeeCap:  tx.SenderTx.GasFeeCap(),
+		Gas:        tx.SenderTx.Gas(),
+		To:         tx.SenderTx.To(),
+		Value:      tx.SenderTx.Value(),
+		Data:       tx.SenderTx.Data(),
+		AccessList: tx.SenderTx.AccessList(),
+		V:          v,
+		R:          r,
+		S:          s,
+	}
+}
+
+// MarshalBinary returns the raw bytes of the envelope.
+func (tx *FeeDelegatedTx) MarshalBinary() ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(&feeDelegatedTxRLP{
+		SenderTx: *tx.senderTx(),
+		FeePayer: &tx.FeePayer,
+		FV:       tx.FV,
+		FR:       tx.FR,
+		FS:       tx.FS,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{FeeDelegateDynamicFeeTxType}, payload...), nil
+}
+
+// Hash returns the transaction hash.
+func (tx *FeeDelegatedTx) Hash() (common.Hash, error) {
+	b, err := tx.MarshalBinary()
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return crypto.Keccak256Hash(b), nil
+}
+
+// FeePayerHash returns the hash signed by the fee payer:
+// the signed transaction of the sender and the fee payer.
+func (tx *FeeDelegatedTx) FeePayerHash() (common.Hash, error) {
+	payload, err := rlp.EncodeToBytes([]interface{}{tx.senderTx(), tx.FeePayer})
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return crypto.Keccak256Hash([]byte{FeeDelegateDynamicFeeTxType}, payload), nil
+}
+
+// Sender returns the sender of the transaction.
+func (tx *FeeDelegatedTx) Sender() (common.Address, error) {
+	return types.Sender(types.NewLondonSigner(tx.SenderTx.ChainId()), tx.SenderTx)
+}
+
+// VerifyFeePayer checks that the fee payer signed
+// the transaction.
+func (tx *FeeDelegatedTx) VerifyFeePayer() error {
+	if tx.FV == nil || tx.FR == nil || tx.FS == nil ||
+		!tx.FV.IsUint64() || tx.FV.Uint64() > 1 ||
+		tx.FR.BitLen() > 256 || tx.FS.BitLen() > 256 {
+		return errors.New("invalid fee payer signature values")
+	}
+
+	hash, err := tx.FeePayerHash()
+	if err != nil {
+		return err
+	}
+
+	sig := append(common.LeftPadBytes(tx.FR.Bytes(), 32), common.LeftPadBytes(tx.FS.Bytes(), 32)...) // nolint:gomnd
+	sig = append(sig, byte(tx.FV.Uint64()))
+
+	pubkey, err := crypto.SigToPub(hash.Bytes(), sig)
+	if err != nil {
+		return err
+	}
+
+	if signer := crypto.PubkeyToAddress(*pubkey); signer != tx.FeePayer {
+		return fmt.Errorf("fee payer signature is from %s, not %s", signer.Hex(), tx.FeePayer.Hex())
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeDelegatedTx(t *testing.T) {
+	senderKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+	feePayerKey, err := crypto.GenerateKey()
+	assert.NoError(t, err)
+
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	chainID := big.NewInt(1112)
+	senderTx, err := types.SignNewTx(senderKey, types.NewLondonSigner(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     3,
+		GasTipCap: big.NewInt(1),
+		GasFeeCap: big.NewInt(100),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1000),
+	})
+	assert.NoError(t, err)
+
+	tx := &FeeDelegatedTx{
+		SenderTx: senderTx,
+		FeePayer: crypto.PubkeyToAddress(feePayerKey.PublicKey),
+	}
+	feePayerHash, err := tx.FeePayerHash()
+	assert.NoError(t, err)
+	sig, err := crypto.Sign(feePayerHash.Bytes(), feePayerKey)
+	assert.NoError(t, err)
+	tx.FR = new(big.Int).SetBytes(sig[:32])
+	tx.FS = new(big.Int).SetBytes(sig[32:64])
+	tx.FV = big.NewInt(int64(sig[64]))
+
+	raw, err := tx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, byte(FeeDelegateDynamicFeeTxType), raw[0])
+
+	decoded, err := DecodeFeeDelegatedTx(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, tx.FeePayer, decoded.FeePayer)
+	assert.Equal(t, senderTx.Hash(), decoded.SenderTx.Hash())
+	assert.NoError(t, decoded.VerifyFeePayer())
+
+	sender, err := decoded.Sender()
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.PubkeyToAddress(senderKey.PublicKey), sender)
+
+	hash, err := decoded.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(raw), hash)
+
+	// A fee payer signature over another fee payer is rejected.
+	decoded.FeePayer = to
+	assert.Error(t, decoded.VerifyFeePayer())
+
+	// Other envelopes are rejected.
+	legacy, err := senderTx.MarshalBinary()
+	assert.NoError(t, err)
+	_, err = DecodeFeeDelegatedTx(legacy)
+	assert.Error(t, err)
+}
+
+// TestFeeDelegatedTxLayout checks the envelope against the
+// layout of go-wemix, built field by field with plain RLP
+// lists: 0x16 || rlp([senderTx, feePayer, fv, fr, fs]) where
+// senderTx is the signed dynamic-fee transaction, and the
+// fee payer signs 0x16 || rlp([senderTx, feePayer]).
+func TestFeeDelegatedTxLayout(t *testing.T) {
+	senderKey, err := crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+	assert.NoError(t, err)
+	feePayerKey, err := crypto.HexToECDSA("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	assert.NoError(t, err)
+	feePayer := crypto.PubkeyToAddress(feePayerKey.PublicKey)
+
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	chainID := big.NewInt(1112)
+	senderTx, err := types.SignNewTx(senderKey, types.NewLondonSigner(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     3,
+		GasTipCap: big.NewInt(100000000000),
+		GasFeeCap: big.NewInt(101000000000),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1000),
+		Data:      []byte{0x01, 0x02},
+	})
+	assert.NoError(t, err)
+	v, r, s := senderTx.RawSignatureValues()
+
+	senderFields := []interface{}{
+		chainID,
+		uint64(3),
+		big.NewInt(100000000000),
+		big.NewInt(101000000000),
+		uint64(21000),
+		to,
+		big.NewInt(1000),
+		[]byte{0x01, 0x02},
+		[]interface{}{},
+		v,
+		r,
+		s,
+	}
+	feePayerPayload, err := rlp.EncodeToBytes([]interface{}{senderFields, feePayer})
+	assert.NoError(t, err)
+	feePayerHash := crypto.Keccak256Hash([]byte{0x16}, feePayerPayload)
+
+	tx := &FeeDelegatedTx{SenderTx: senderTx, FeePayer: feePayer}
+	hash, err := tx.FeePayerHash()
+	assert.NoError(t, err)
+	assert.Equal(t, feePayerHash, hash)
+
+	sig, err := crypto.Sign(feePayerHash.Bytes(), feePayerKey)
+	assert.NoError(t, err)
+	fv := big.NewInt(int64(sig[64]))
+	fr := new(big.Int).SetBytes(sig[:32])
+	fs := new(big.Int).SetBytes(sig[32:64])
+	payload, err := rlp.EncodeToBytes([]interface{}{senderFields, feePayer, fv, fr, fs})
+	assert.NoError(t, err)
+	expectedRaw := append([]byte{0x16}, payload...)
+
+	tx.FV, tx.FR, tx.FS = fv, fr, fs
+	raw, err := tx.MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, expectedRaw, raw)
+
+	txHash, err := tx.Hash()
+	assert.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256Hash(expectedRaw), txHash)
+
+	decoded, err := DecodeFeeDelegatedTx(expectedRaw)
+	assert.NoError(t, err)
+	assert.Equal(t, feePayer, decoded.FeePayer)
+	assert.Equal(t, senderTx.Hash(), decoded.SenderTx.Hash())
+	assert.Equal(t, []*big.Int{fv, fr, fs}, []*big.Int{decoded.FV, decoded.FR, decoded.FS})
+	assert.NoError(t, decoded.VerifyFeePayer())
+}
@@ -0,0 +1,170 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	RosettaTypes "github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// TokenIDKey is the key in *types.Currency metadata
+	// that holds the decimal id of a non-fungible token.
+	TokenIDKey = "tokenId"
+
+	// StandardKey is the key in *types.Currency metadata
+	// that holds the standard of a non-fungible token.
+	StandardKey = "standard"
+
+	// Erc721Standard is the standard of ERC-721 tokens.
+	Erc721Standard = "ERC721"
+
+	// Erc1155Standard is the standard of ERC-1155 tokens.
+	Erc1155Standard = "ERC1155"
+
+	// OwnerOfFnSignature is the signature of the ERC-721
+	// ownerOf method.
+	OwnerOfFnSignature = "ownerOf(uint256)"
+
+	// Erc1155BalanceOfFnSignature is the signature of the
+	// ERC-1155 balanceOf method.
+	Erc1155BalanceOfFnSignature = "balanceOf(address,uint256)"
+
+	// TransferSingleEventSignature is the signature of the
+	// ERC-1155 TransferSingle event.
+	TransferSingleEventSignature = "TransferSingle(address,address,address,uint256,uint256)"
+
+	// TransferBatchEventSignature is the signature of the
+	// ERC-1155 TransferBatch event.
+	TransferBatchEventSignature = "TransferBatch(address,address,address,uint256[],uint256[])"
+)
+
+var (
+	// OwnerOfFnSelector is the 4-byte method id of the
+	// ERC-721 ownerOf method.
+	OwnerOfFnSelector = MethodID(OwnerOfFnSignature)
+
+	// Erc1155BalanceOfFnSelector is the 4-byte method id
+	// of the ERC-1155 balanceOf method.
+	Erc1155BalanceOfFnSelector = MethodID(Erc1155BalanceOfFnSignature)
+
+	// TransferSingleEventTopic is the topic of the
+	// ERC-1155 TransferSingle event.
+	TransferSingleEventTopic = crypto.Keccak256Hash([]byte(TransferSingleEventSignature))
+
+	// TransferBatchEventTopic is the topic of the
+	// ERC-1155 TransferBatch event.
+	TransferBatchEventTopic = crypto.Keccak256Hash([]byte(TransferBatchEventSignature))
+)
+
+// NftCurrency returns the currency of the non-fungible token
+// id of contract. Its symbol is "<contract>:<id>", it has no
+// decimals and its metadata holds the contract address, the
+// decimal token id and the standard (ERC721 or ERC1155).
+func NftCurrency(contract common.Address, id *big.Int, standard string) *RosettaTypes.Currency {
+	return &RosettaTypes.Currency{
+		Symbol:   fmt.Sprintf("%s:%s", contract.Hex(), id.String()),
+		Decimals: 0,
+		Metadata: map[string]interface{}{
+			ContractAddressKey: contract.Hex(),
+			TokenIDKey:         id.String(),
+			StandardKey:        standard,
+		},
+	}
+}
+
+// NftContract returns the contract address and standard
+// stored in the metadata of currency. If the currency is not
+// of a non-fungible token contract, it returns !ok.
+func NftContract(currency *RosettaTypes.Currency) (common.Address, string, bool) {
+	contract, ok := TokenAddress(currency)
+	if !ok {
+		return common.Address{}, "", false
+	}
+
+	standard, ok := currency.Metadata[StandardKey].(string)
+	if !ok || (standard != Erc721Standard && standard != Erc1155Standard) {
+		return common.Address{}, "", false
+	}
+
+	return common.HexToAddress(contract), standard, true
+}
+
+// NftToken returns the contract address, token id and standard
+// stored in the metadata of a currency created with NftCurrency.
+// If the currency is not a non-fungible token, it returns !ok.
+func NftToken(currency *RosettaTypes.Currency) (common.Address, *big.Int, string, bool) {
+	contract, standard, ok := NftContract(currency)
+	if !ok {
+		return common.Address{}, nil, "", false
+	}
+
+	rawID, ok := currency.Metadata[TokenIDKey].(string)
+	if !ok {
+		return common.Address{}, nil, "", false
+	}
+
+	id, ok := new(big.Int).SetString(rawID, 10) // nolint:gomnd
+	if !ok || id.Sign() < 0 || id.BitLen() > 256 {
+		return common.Address{}, nil, "", false
+	}
+
+	return contract, id, standard, true
+}
+
+// Erc721OwnerOfData returns the ABI-encoded calldata
+// of ownerOf(id).
+func Erc721OwnerOfData(id *big.Int) []byte {
+	return callData(OwnerOfFnSelector, id.Bytes())
+}
+
+// Erc1155BalanceOfData returns the ABI-encoded calldata
+// of balanceOf(owner, id).
+func Erc1155BalanceOfData(owner common.Address, id *big.Int) []byte {
+	return callData(Erc1155BalanceOfFnSelector, owner.Bytes(), id.Bytes())
+}
+
+// ParseErc1155TransferBatchData decodes the ids and values
+// in the data of a TransferBatch event.
+func ParseErc1155TransferBatchData(data []byte) ([]*big.Int, []*big.Int, error) {
+	// The data is decoded like the arguments of a
+	// method taking the ids and values.
+	_, args, err := parseMethodSignature("batch(uint256[],uint256[])")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := args.Unpack(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ids, ok := values[0].([]*big.Int)
+	if !ok {
+		return nil, nil, errors.New("invalid TransferBatch ids")
+	}
+
+	amounts, ok := values[1].([]*big.Int)
+	if !ok || len(amounts) != len(ids) {
+		return nil, nil, errors.New("invalid TransferBatch values")
+	}
+
+	return ids, amounts, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"math/big"
+	"testing"
+
+	RosettaTypes "github.com/coinbase/rosetta-sdk-go/types"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNftToken(t *testing.T) {
+	collection := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	id, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+
+	contract, parsedID, standard, ok := NftToken(NftCurrency(collection, id, Erc1155Standard))
+	assert.True(t, ok)
+	assert.Equal(t, collection, contract)
+	assert.Equal(t, id, parsedID)
+	assert.Equal(t, Erc1155Standard, standard)
+
+	// ERC-20 tokens are not non-fungible tokens.
+	_, _, _, ok = NftToken(&RosettaTypes.Currency{
+		Symbol:   "WEMIX$",
+		Decimals: 18,
+		Metadata: map[string]interface{}{
+			ContractAddressKey: collection.Hex(),
+		},
+	})
+	assert.False(t, ok)
+
+	_, _, _, ok = NftToken(&RosettaTypes.Currency{
+		Symbol: "NFT",
+		Metadata: map[string]interface{}{
+			ContractAddressKey: collection.Hex(),
+			TokenIDKey:         "0x7",
+			StandardKey:        Erc721Standard,
+		},
+	})
+	assert.False(t, ok)
+}
+
+func TestNftSelectors(t *testing.T) {
+	assert.Equal(t, "0x6352211e", hexutil.Encode(OwnerOfFnSelector))
+	assert.Equal(t, "0x00fdd58e", hexutil.Encode(Erc1155BalanceOfFnSelector))
+	assert.Equal(
+		t,
+		"0xc3d58168c5ae7397731d063d5bbf3d657854427343f4c083240f7aacaa2d0f62",
+		TransferSingleEventTopic.Hex(),
+	)
+	assert.Equal(
+		t,
+		"0x4a39dc06d4c0dbc64b70af90fd698a233a518aa5d07e595d983b8c0526c8f7fb",
+		TransferBatchEventTopic.Hex(),
+	)
+}
@@ -0,0 +1,231 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const (
+	// SafeExecTransactionFnSignature is the signature of
+	// the Safe execTransaction method.
+	SafeExecTransactionFnSignature = "execTransaction(address,uint256,bytes,uint8,uint256,uint256,uint256,address,address,bytes)"
+
+	// SafeNonceFnSignature is the signature of the
+	// Safe nonce method.
+	SafeNonceFnSignature = "nonce()"
+
+	// SafeGetOwnersFnSignature is the signature of the
+	// Safe getOwners method.
+	SafeGetOwnersFnSignature = "getOwners()"
+
+	// SafeGetThresholdFnSignature is the signature of the
+	// Safe getThreshold method.
+	SafeGetThresholdFnSignature = "getThreshold()"
+
+	// SafeDomainSeparatorFnSignature is the signature of
+	// the Safe domainSeparator method.
+	SafeDomainSeparatorFnSignature = "domainSeparator()"
+
+	// SafeSignatureLength is the length of an owner
+	// signature in the signatures of execTransaction.
+	SafeSignatureLength = 65
+)
+
+var (
+	// SafeExecTransactionFnSelector is the 4-byte method
+	// id of the Safe execTransaction method.
+	SafeExecTransactionFnSelector = MethodID(SafeExecTransactionFnSignature)
+
+	// SafeNonceFnSelector is the 4-byte method id of the
+	// Safe nonce method.
+	SafeNonceFnSelector = MethodID(SafeNonceFnSignature)
+
+	// SafeGetOwnersFnSelector is the 4-byte method id of
+	// the Safe getOwners method.
+	SafeGetOwnersFnSelector = MethodID(SafeGetOwnersFnSignature)
+
+	// SafeGetThresholdFnSelector is the 4-byte method id
+	// of the Safe getThreshold method.
+	SafeGetThresholdFnSelector = MethodID(SafeGetThresholdFnSignature)
+
+	// SafeDomainSeparatorFnSelector is the 4-byte method
+	// id of the Safe domainSeparator method.
+	SafeDomainSeparatorFnSelector = MethodID(SafeDomainSeparatorFnSignature)
+
+	// SafeDomainTypeHash is the EIP-712 type hash of the
+	// domain of Safe (v1.3.0) contracts.
+	SafeDomainTypeHash = crypto.Keccak256Hash([]byte(
+		"EIP712Domain(uint256 chainId,address verifyingContract)",
+	))
+
+	// SafeTxTypeHash is the EIP-712 type hash of
+	// a Safe transaction.
+	SafeTxTypeHash = crypto.Keccak256Hash([]byte(
+		"SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)",
+	))
+)
+
+// SafeTx is a transaction executed by a Safe
+// through execTransaction.
+type SafeTx struct {
+	To             common.Address
+	Value          *big.Int
+	Data           []byte
+	Operation      uint8
+	SafeTxGas      *big.Int
+	BaseGas        *big.Int
+	GasPrice       *big.Int
+	GasToken       common.Address
+	RefundReceiver common.Address
+	Nonce          *big.Int
+}
+
+// NewSafeTx returns a Safe transaction calling to with
+// value and data, whose gas is paid by the executor.
+func NewSafeTx(to common.Address, value *big.Int, data []byte, nonce *big.Int) *SafeTx {
+	return &SafeTx{
+		To:        to,
+		Value:     value,
+		Data:      data,
+		SafeTxGas: big.NewInt(0),
+		BaseGas:   big.NewInt(0),
+		GasPrice:  big.NewInt(0),
+		Nonce:     nonce,
+	}
+}
+
+// SafeDomainSeparator returns the EIP-712 domain separator
+// of the Safe (v1.3.0) at verifyingContract. Safes expose it
+// through domainSeparator().
+func SafeDomainSeparator(chainID *big.Int, verifyingContract common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		SafeDomainTypeHash.Bytes(),
+		common.LeftPadBytes(chainID.Bytes(), 32),           // nolint:gomnd
+		common.LeftPadBytes(verifyingContract.Bytes(), 32), // nolint:gomnd
+	)
+}
+
+// StructHash returns the EIP-712 struct hash of tx.
+func (tx *SafeTx) StructHash() common.Hash {
+	return crypto.Keccak256Hash(
+		SafeTxTypeHash.Bytes(),
+		common.LeftPadBytes(tx.To.Bytes(), 32),    // nolint:gomnd
+		common.LeftPadBytes(tx.Value.Bytes(), 32), // nolint:gomnd
+		crypto.Keccak256(tx.Data),
+		common.LeftPadBytes([]byte{tx.Operation}, 32),      // nolint:gomnd
+		common.LeftPadBytes(tx.SafeTxGas.Bytes(), 32),      // nolint:gomnd
+		common.LeftPadBytes(tx.BaseGas.Bytes(), 32),        // nolint:gomnd
+		common.LeftPadBytes(tx.GasPrice.Bytes(), 32),       // nolint:gomnd
+		common.LeftPadBytes(tx.GasToken.Bytes(), 32),       // nolint:gomnd
+		common.LeftPadBytes(tx.RefundReceiver.Bytes(), 32), // nolint:gomnd
+		common.LeftPadBytes(tx.Nonce.Bytes(), 32),          // nolint:gomnd
+	)
+}
+
+// Hash returns the Safe transaction hash the owners
+// sign, in the domain of the Safe.
+func (tx *SafeTx) Hash(domainSeparator common.Hash) common.Hash {
+	return TypedDataHash(domainSeparator, tx.StructHash())
+}
+
+// safeExecTransactionArgs returns the arguments
+// of execTransaction.
+func safeExecTransactionArgs() abi.Arguments {
+	_, args, err := parseMethodSignature(SafeExecTransactionFnSignature)
+	if err != nil {
+		panic(err)
+	}
+
+	return args
+}
+
+// SafeExecTransactionData returns the ABI-encoded calldata
+// of execTransaction for tx with the owner signatures.
+func SafeExecTransactionData(tx *SafeTx, signatures []byte) ([]byte, error) {
+	packed, err := safeExecTransactionArgs().Pack(
+		tx.To,
+		tx.Value,
+		tx.Data,
+		tx.Operation,
+		tx.SafeTxGas,
+		tx.BaseGas,
+		tx.GasPrice,
+		tx.GasToken,
+		tx.RefundReceiver,
+		signatures,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append([]byte{}, SafeExecTransactionFnSelector...), packed...), nil
+}
+
+// ParseSafeExecTransactionData decodes calldata produced by
+// SafeExecTransactionData. The nonce of the returned
+// transaction is not part of the calldata and is nil. If the
+// calldata is not an execTransaction, it returns !ok.
+func ParseSafeExecTransactionData(data []byte) (*SafeTx, []byte, bool) {
+	if len(data) < 4 || !bytes.Equal(data[:4], SafeExecTransactionFnSelector) {
+		return nil, nil, false
+	}
+
+	values, err := safeExecTransactionArgs().Unpack(data[4:])
+	if err != nil {
+		return nil, nil, false
+	}
+
+	tx := &SafeTx{
+		To:             values[0].(common.Address),
+		Value:          values[1].(*big.Int),
+		Data:           values[2].([]byte),
+		Operation:      values[3].(uint8),
+		SafeTxGas:      values[4].(*big.Int),
+		BaseGas:        values[5].(*big.Int),
+		GasPrice:       values[6].(*big.Int),
+		GasToken:       values[7].(common.Address),
+		RefundReceiver: values[8].(common.Address),
+	}
+	return tx, values[9].([]byte), true
+}
+
+// ParseSafeOwners decodes the result of getOwners().
+func ParseSafeOwners(result []byte) ([]common.Address, error) {
+	// The result is decoded like the arguments of a
+	// method taking the returned address[].
+	_, args, err := parseMethodSignature("owners(address[])")
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := args.Unpack(result)
+	if err != nil {
+		return nil, err
+	}
+
+	owners, ok := values[0].([]common.Address)
+	if !ok || len(owners) == 0 {
+		return nil, errors.New("safe has no owners")
+	}
+
+	return owners, nil
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Coinbase, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wemix
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSafeTxHash(t *testing.T) {
+	safe := common.HexToAddress("0x622Fbe99b3A378FAC736bf29d7e23B85E18816eB")
+	token := common.HexToAddress("0x8E81fCc2d4A3bAa0eE9044E0D7E36F59C9BbA9c1")
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	chainID := big.NewInt(1112)
+	data := Erc20TransferData(to, big.NewInt(1000))
+	tx := NewSafeTx(token, big.NewInt(0), data, big.NewInt(7))
+
+	// The hash must match the one signed by wallets
+	// implementing eth_signTypedData_v4.
+	typedData := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"SafeTx": {
+				{Name: "to", Type: "address"},
+				{Name: "value", Type: "uint256"},
+				{Name: "data", Type: "bytes"},
+				{Name: "operation", Type: "uint8"},
+				{Name: "safeTxGas", Type: "uint256"},
+				{Name: "baseGas", Type: "uint256"},
+				{Name: "gasPrice", Type: "uint256"},
+				{Name: "gasToken", Type: "address"},
+				{Name: "refundReceiver", Type: "address"},
+				{Name: "nonce", Type: "uint256"},
+			},
+		},
+		PrimaryType: "SafeTx",
+		Domain: apitypes.TypedDataDomain{
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: safe.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"to":             token.Hex(),
+			"value":          "0",
+			"data":           hexutil.Encode(data),
+			"operation":      "0",
+			"safeTxGas":      "0",
+			"baseGas":        "0",
+			"gasPrice":       "0",
+			"gasToken":       common.Address{}.Hex(),
+			"refundReceiver": common.Address{}.Hex(),
+			"nonce":          "7",
+		},
+	}
+
+	expectedDomain, err := typedData.HashStruct("EIP712Domain", typedData.Domain.Map())
+	assert.NoError(t, err)
+	expectedStruct, err := typedData.HashStruct(typedData.PrimaryType, typedData.Message)
+	assert.NoError(t, err)
+
+	domainSeparator := SafeDomainSeparator(chainID, safe)
+	assert.Equal(t, []byte(expectedDomain), domainSeparator.Bytes())
+	assert.Equal(t, []byte(expectedStruct), tx.StructHash().Bytes())
+
+	rawData := append([]byte{0x19, 0x01}, expectedDomain...)
+	rawData = append(rawData, expectedStruct...)
+	assert.Equal(t, common.BytesToHash(crypto.Keccak256(rawData)), tx.Hash(domainSeparator))
+}
+
+func TestSafeExecTransactionData(t *testing.T) {
+	to := common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d")
+	tx := NewSafeTx(to, big.NewInt(1000), []byte{}, big.NewInt(1))
+	signatures := make([]byte, 2*SafeSignatureLength)
+	signatures[SafeSignatureLength-1] = 27
+	signatures[2*SafeSignatureLength-1] = 28
+
+	data, err := SafeExecTransactionData(tx, signatures)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x6a761202", hexutil.Encode(SafeExecTransactionFnSelector))
+	assert.Equal(t, SafeExecTransactionFnSelector, data[:4])
+
+	parsed, parsedSignatures, ok := ParseSafeExecTransactionData(data)
+	assert.True(t, ok)
+	assert.Equal(t, tx.To, parsed.To)
+	assert.Equal(t, tx.Value, parsed.Value)
+	assert.Equal(t, tx.Data, parsed.Data)
+	assert.Equal(t, signatures, parsedSignatures)
+
+	_, _, ok = ParseSafeExecTransactionData(Erc20TransferData(to, big.NewInt(1)))
+	assert.False(t, ok)
+}
+
+func TestParseSafeOwners(t *testing.T) {
+	owners := []common.Address{
+		common.HexToAddress("0x57B414a0332B5CaB885a451c2a28a07d1e9b8a8d"),
+		common.HexToAddress("0xb22694a52EA2a9564001aF4AA61ecD9672E0D26b"),
+	}
+
+	// abi.encode(address[])
+	result := append(common.LeftPadBytes([]byte{0x20}, 32), common.LeftPadBytes([]byte{2}, 32)...)
+	for _, owner := range owners {
+		result = append(result, common.LeftPadBytes(owner.Bytes(), 32)...)
+	}
+
+	parsed, err := ParseSafeOwners(result)
+	assert.NoError(t, err)
+	assert.Equal(t, owners, parsed)
+
+	_, err = ParseSafeOwners(common.LeftPadBytes([]byte{1}, 32))
+	assert.Error(t, err)
+}
@@ -87,6 +87,36 @@ const (
 	// of a transaction.
 	DestructOpType = "DESTRUCT"
 
+	// ApproveOpType is used to construct ERC-20 approve calls.
+	// It sets an allowance and does not change any balance.
+	ApproveOpType = "APPROVE"
+
+	// PermitOpType is used to construct EIP-2612 permits, which
+	// set an allowance with a typed-data signature.
+	PermitOpType = "PERMIT"
+
+	// Erc20TransferOpType is used to represent the balance
+	// changes of Transfer events emitted by configured
+	// ERC-20 tokens.
+	Erc20TransferOpType = "ERC20_TRANSFER"
+
+	// Erc721TransferOpType is used to represent the balance
+	// changes of ERC-721 Transfer events.
+	Erc721TransferOpType = "ERC721_TRANSFER"
+
+	// Erc1155TransferOpType is used to represent the balance
+	// changes of ERC-1155 TransferSingle and TransferBatch
+	// events.
+	Erc1155TransferOpType = "ERC1155_TRANSFER"
+
+	// PendingPool is the txpool of transactions
+	// that may be included in the next block.
+	PendingPool = "pending"
+
+	// QueuedPool is the txpool of transactions that
+	// wait for a nonce gap to be filled.
+	QueuedPool = "queued"
+
 	// SuccessStatus is the status of any
 	// Ethereum operation considered successful.
 	SuccessStatus = "SUCCESS"
@@ -161,6 +191,11 @@ var (
 		DelegateCallOpType,
 		StaticCallOpType,
 		DestructOpType,
+		ApproveOpType,
+		PermitOpType,
+		Erc20TransferOpType,
+		Erc721TransferOpType,
+		Erc1155TransferOpType,
 	}
 
 	// OperationStatuses are all supported operation statuses.